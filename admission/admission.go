@@ -3,6 +3,7 @@ package admission
 import (
 	"bufio"
 	"context"
+	"fmt"
 	"io"
 	"net"
 	"strings"
@@ -15,6 +16,22 @@ import (
 	"github.com/go-gost/x/internal/matcher"
 )
 
+// ValidateMatcher checks the matcher pattern of the admission,
+// which is an IP address, a CIDR or a host:port pattern.
+func ValidateMatcher(pattern string) error {
+	if host, port, ok := matcher.SplitHostPort(pattern); ok {
+		_, err := parsePortPattern(host, port)
+		return err
+	}
+	if net.ParseIP(pattern) != nil {
+		return nil
+	}
+	if _, _, err := net.ParseCIDR(pattern); err == nil {
+		return nil
+	}
+	return fmt.Errorf("invalid pattern %s", pattern)
+}
+
 type options struct {
 	whitelist   bool
 	matchers    []string
@@ -70,11 +87,12 @@ func LoggerOption(logger logger.Logger) Option {
 }
 
 type admission struct {
-	ipMatcher   matcher.Matcher
-	cidrMatcher matcher.Matcher
-	mu          sync.RWMutex
-	cancelFunc  context.CancelFunc
-	options     options
+	ipMatcher    matcher.Matcher
+	cidrMatcher  matcher.Matcher
+	portMatchers []matcher.Matcher
+	mu           sync.RWMutex
+	cancelFunc   context.CancelFunc
+	options      options
 }
 
 // NewAdmission creates and initializes a new Admission using matcher patterns as its match rules.
@@ -106,12 +124,13 @@ func (p *admission) Admit(addr string) bool {
 		return true
 	}
 
+	host := addr
 	// try to strip the port
-	if host, _, _ := net.SplitHostPort(addr); host != "" {
-		addr = host
+	if h, _, _ := net.SplitHostPort(addr); h != "" {
+		host = h
 	}
 
-	matched := p.matched(addr)
+	matched := p.matched(host) || p.matchedPorts(addr)
 
 	return !p.options.whitelist && !matched ||
 		p.options.whitelist && matched
@@ -147,7 +166,17 @@ func (p *admission) reload(ctx context.Context) error {
 
 	var ips []net.IP
 	var inets []*net.IPNet
+	var portMatchers []matcher.Matcher
 	for _, pattern := range patterns {
+		if host, port, ok := matcher.SplitHostPort(pattern); ok {
+			m, err := parsePortPattern(host, port)
+			if err != nil {
+				p.options.logger.Warnf("%s: %v", pattern, err)
+				continue
+			}
+			portMatchers = append(portMatchers, m)
+			continue
+		}
 		if ip := net.ParseIP(pattern); ip != nil {
 			ips = append(ips, ip)
 			continue
@@ -163,10 +192,31 @@ func (p *admission) reload(ctx context.Context) error {
 
 	p.ipMatcher = matcher.IPMatcher(ips)
 	p.cidrMatcher = matcher.CIDRMatcher(inets)
+	p.portMatchers = portMatchers
 
 	return nil
 }
 
+// parsePortPattern creates a Matcher for the host:port pattern,
+// the host part should be empty, '*', an IP address or a CIDR.
+func parsePortPattern(host, port string) (matcher.Matcher, error) {
+	pm, err := matcher.PortMatcher(strings.Split(port, ","))
+	if err != nil {
+		return nil, err
+	}
+
+	var hm matcher.Matcher
+	if ip := net.ParseIP(host); ip != nil {
+		hm = matcher.IPMatcher([]net.IP{ip})
+	} else if _, inet, err := net.ParseCIDR(host); err == nil {
+		hm = matcher.CIDRMatcher([]*net.IPNet{inet})
+	} else if host != "" && host != "*" {
+		return nil, fmt.Errorf("invalid host %s", host)
+	}
+
+	return matcher.HostPortMatcher(hm, pm), nil
+}
+
 func (p *admission) load(ctx context.Context) (patterns []string, err error) {
 	if p.options.fileLoader != nil {
 		if lister, ok := p.options.fileLoader.(loader.Lister); ok {
@@ -252,6 +302,18 @@ func (p *admission) matched(addr string) bool {
 		p.cidrMatcher.Match(addr)
 }
 
+func (p *admission) matchedPorts(addr string) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	for _, m := range p.portMatchers {
+		if m.Match(addr) {
+			return true
+		}
+	}
+	return false
+}
+
 func (p *admission) Close() error {
 	p.cancelFunc()
 	if p.options.fileLoader != nil {
@@ -7,6 +7,7 @@ import (
 	"syscall"
 
 	"github.com/go-gost/core/admission"
+	xctx "github.com/go-gost/x/ctx"
 	xnet "github.com/go-gost/x/internal/net"
 	"github.com/go-gost/x/internal/net/udp"
 )
@@ -39,6 +40,14 @@ func (c *serverConn) Read(b []byte) (n int, err error) {
 	return c.Conn.Read(b)
 }
 
+// CountConn implements xctx.ConnCounter by the wrapped connection.
+func (c *serverConn) CountConn(info *xctx.ConnInfo) bool {
+	if cc, ok := c.Conn.(xctx.ConnCounter); ok {
+		return cc.CountConn(info)
+	}
+	return false
+}
+
 func (c *serverConn) SyscallConn() (rc syscall.RawConn, err error) {
 	if sc, ok := c.Conn.(syscall.Conn); ok {
 		rc, err = sc.SyscallConn()
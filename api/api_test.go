@@ -0,0 +1,141 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-gost/core/handler"
+	"github.com/go-gost/core/logger"
+	"github.com/go-gost/core/metadata"
+	"github.com/go-gost/core/sniff/stun"
+	xctx "github.com/go-gost/x/ctx"
+	xlogger "github.com/go-gost/x/logger"
+	"github.com/go-gost/x/registry"
+	xservice "github.com/go-gost/x/service"
+)
+
+func TestMain(m *testing.M) {
+	logger.SetDefault(xlogger.NewLogger(xlogger.LevelLoggerOption(logger.FatalLevel)))
+	os.Exit(m.Run())
+}
+
+// newTestHandler creates the handler of the API service with the options.
+func newTestHandler(t *testing.T, opts ...Option) http.Handler {
+	t.Helper()
+
+	svc, err := NewService("127.0.0.1:0", opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { svc.Close() })
+	return svc.(*server).s.Handler
+}
+
+// do sends the request with the header to the handler.
+func do(h http.Handler, method, target string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+type testListener struct {
+	net.Listener
+}
+
+func (l *testListener) Init(metadata.Metadata) error {
+	return nil
+}
+
+// dstHandler reads the user and the destination from the first line of the connection,
+// and keeps the connection until it is closed.
+type dstHandler struct{}
+
+func (h *dstHandler) Init(metadata.Metadata) error {
+	return nil
+}
+
+func (h *dstHandler) Handle(ctx context.Context, conn net.Conn, opts ...handler.HandleOption) error {
+	defer conn.Close()
+
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return nil
+	}
+	var user, dst string
+	fmt.Sscan(line, &user, &dst)
+	xctx.ConnInfoFromContext(ctx).SetDst("tcp", dst, user)
+	conn.Write([]byte("ok\n"))
+
+	io.Copy(io.Discard, conn)
+	return nil
+}
+
+// newTestService registers and serves the service of the dstHandler with the options,
+// and returns the address of it.
+func newTestService(t *testing.T, name string, opts ...xservice.Option) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts = append([]xservice.Option{xservice.LoggerOption(xlogger.Nop())}, opts...)
+	svc := xservice.NewService(name, &testListener{ln}, &dstHandler{}, stun.Spoof{}, opts...)
+	go svc.Serve()
+	if err := registry.ServiceRegistry().Register(name, svc); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { registry.ServiceRegistry().Unregister(name) })
+
+	return ln.Addr().String()
+}
+
+// dialTestService connects to the service of the dstHandler at addr with the line in the format of "user dst".
+func dialTestService(t *testing.T, addr string, line string) net.Conn {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	conn.SetDeadline(time.Now().Add(time.Second))
+	fmt.Fprintln(conn, line)
+	if _, err := bufio.NewReader(conn).ReadString('\n'); err != nil {
+		t.Fatal(err)
+	}
+	conn.SetDeadline(time.Time{})
+	return conn
+}
+
+// errorCode returns the code of the error in the response, 0 if it succeeds.
+func errorCode(t *testing.T, w *httptest.ResponseRecorder) int {
+	t.Helper()
+
+	if w.Code == http.StatusOK {
+		return 0
+	}
+	var e Error
+	if err := json.Unmarshal(w.Body.Bytes(), &e); err != nil {
+		t.Fatal(err)
+	}
+	return e.Code
+}
+
+func bearer(token string) map[string]string {
+	return map[string]string{"Authorization": "Bearer " + token}
+}
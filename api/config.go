@@ -2,12 +2,22 @@ package api
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"os"
+	"reflect"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-gost/x/config"
+	"github.com/go-gost/x/config/loader"
+	"github.com/go-gost/x/config/parsing"
+	"gopkg.in/yaml.v2"
 )
 
 // swagger:parameters getConfigRequest
@@ -30,6 +40,7 @@ func getConfig(ctx *gin.Context) {
 	//
 	//     Security:
 	//       basicAuth: []
+	//       bearerAuth: []
 	//
 	//     Responses:
 	//       200: getConfigResponse
@@ -38,7 +49,8 @@ func getConfig(ctx *gin.Context) {
 	ctx.ShouldBindQuery(&req)
 
 	var resp getConfigResponse
-	resp.Config = config.Global()
+	// the callers with the read permission must not get the credentials of the API and the backend.
+	resp.Config = redactConfig(config.Global())
 
 	buf := &bytes.Buffer{}
 	switch req.Format {
@@ -73,10 +85,12 @@ type saveConfigResponse struct {
 func saveConfig(ctx *gin.Context) {
 	// swagger:route POST /config Config saveConfigRequest
 	//
-	// Save current config to file (gost.yaml or gost.json).
+	// Save current config to file (gost.yaml or gost.json),
+	// or to the config backend if it is configured, in the format of the backend.
 	//
 	//     Security:
 	//       basicAuth: []
+	//       bearerAuth: []
 	//
 	//     Responses:
 	//       200: saveConfigResponse
@@ -84,6 +98,26 @@ func saveConfig(ctx *gin.Context) {
 	var req saveConfigRequest
 	ctx.ShouldBindQuery(&req)
 
+	if loader.GetBackend() != nil {
+		if err := loader.SaveBackend(ctx.Request.Context()); err != nil {
+			// the config changed by others is not overwritten, it should be reloaded first.
+			if errors.Is(err, loader.ErrConflict) {
+				writeError(ctx, ErrConflict)
+				return
+			}
+			writeError(ctx, &Error{
+				statusCode: http.StatusInternalServerError,
+				Code:       40005,
+				Msg:        fmt.Sprintf("save to backend: %s", err.Error()),
+			})
+			return
+		}
+		ctx.JSON(http.StatusOK, Response{
+			Msg: "OK",
+		})
+		return
+	}
+
 	file := "gost.yaml"
 	switch req.Format {
 	case "json":
@@ -116,3 +150,260 @@ func saveConfig(ctx *gin.Context) {
 		Msg: "OK",
 	})
 }
+
+// successful operation.
+// swagger:response reloadConfigResponse
+type reloadConfigResponse struct {
+	Data Response
+}
+
+func reloadConfig(ctx *gin.Context) {
+	// swagger:route POST /config/reload Config reloadConfigRequest
+	//
+	// Reload the config file, or the config backend if it is configured,
+	// only the changed objects are created, replaced or removed.
+	//
+	//     Security:
+	//       basicAuth: []
+	//       bearerAuth: []
+	//
+	//     Responses:
+	//       200: reloadConfigResponse
+
+	reload := func() error { return loader.ReloadFile("") }
+	if loader.GetBackend() != nil {
+		reload = func() error { return loader.ReloadBackend(ctx.Request.Context()) }
+	}
+	if err := reload(); err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, loader.ErrNoConfigFile) {
+			statusCode = http.StatusBadRequest
+		}
+		writeError(ctx, &Error{
+			statusCode: statusCode,
+			Code:       40007,
+			Msg:        fmt.Sprintf("reload: %s", err.Error()),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, Response{
+		Msg: "OK",
+	})
+}
+
+// swagger:parameters validateConfigRequest
+type validateConfigRequest struct {
+	// in: body
+	Data config.Config `json:"data"`
+}
+
+// ConfigError is a problem of the config.
+type ConfigError struct {
+	// path of the invalid field, such as services[0].handler.chain,
+	// empty if the variables of the config can not be expanded.
+	Path  string `json:"path"`
+	Error string `json:"error"`
+}
+
+// ConfigValidation is the result of the config validation.
+type ConfigValidation struct {
+	Valid  bool           `json:"valid"`
+	Errors []*ConfigError `json:"errors,omitempty"`
+}
+
+// successful operation.
+// swagger:response validateConfigResponse
+type validateConfigResponse struct {
+	Data ConfigValidation
+}
+
+func validateConfig(ctx *gin.Context) {
+	// swagger:route POST /config/validate Config validateConfigRequest
+	//
+	// Validate the config without applying it.
+	//
+	//     Security:
+	//       basicAuth: []
+	//       bearerAuth: []
+	//
+	//     Responses:
+	//       200: validateConfigResponse
+
+	var req validateConfigRequest
+	if err := ctx.ShouldBindJSON(&req.Data); err != nil {
+		writeError(ctx, ErrInvalid)
+		return
+	}
+
+	var resp validateConfigResponse
+
+	// the config is validated with the variables expanded, as it is reloaded.
+	cfg, err := config.Expanded(&req.Data)
+	if err != nil {
+		resp.Data.Errors = append(resp.Data.Errors, &ConfigError{
+			Error: err.Error(),
+		})
+		ctx.JSON(http.StatusOK, resp.Data)
+		return
+	}
+
+	for _, err := range parsing.Validate(cfg) {
+		resp.Data.Errors = append(resp.Data.Errors, &ConfigError{
+			Path:  err.Path,
+			Error: err.Err.Error(),
+		})
+	}
+	resp.Data.Valid = len(resp.Data.Errors) == 0
+
+	ctx.JSON(http.StatusOK, resp.Data)
+}
+
+const (
+	redacted = "******"
+)
+
+// redactConfig returns a copy of the config c with the secrets of the API and the backend replaced,
+// the token material would let the callers with the read permission gain the other permissions.
+func redactConfig(c *config.Config) *config.Config {
+	if c == nil {
+		return nil
+	}
+	cc := *c
+
+	if c.API != nil {
+		api := *c.API
+		if api.Auth != nil {
+			auth := *api.Auth
+			if auth.Password != "" {
+				auth.Password = redacted
+			}
+			api.Auth = &auth
+		}
+		api.Tokens = nil
+		for _, t := range c.API.Tokens {
+			if t == nil {
+				continue
+			}
+			token := *t
+			token.Token = redacted
+			api.Tokens = append(api.Tokens, &token)
+		}
+		if c.API.JWT != nil {
+			jwt := *c.API.JWT
+			jwt.Keys = nil
+			for range c.API.JWT.Keys {
+				jwt.Keys = append(jwt.Keys, redacted)
+			}
+			api.JWT = &jwt
+		}
+		cc.API = &api
+	}
+
+	if c.Backend != nil {
+		backend := *c.Backend
+		if c.Backend.Redis != nil {
+			redis := *c.Backend.Redis
+			if redis.Password != "" {
+				redis.Password = redacted
+			}
+			backend.Redis = &redis
+		}
+		if c.Backend.HTTP != nil {
+			h := *c.Backend.HTTP
+			if u, err := url.Parse(h.URL); err == nil {
+				h.URL = u.Redacted()
+			}
+			backend.HTTP = &h
+		}
+		cc.Backend = &backend
+	}
+
+	return &cc
+}
+
+// objectETag returns the ETag of the config object, which changes when the object is changed.
+func objectETag(v any) string {
+	b, _ := json.Marshal(v)
+	sum := sha256.Sum256(b)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// ifMatch reports whether the If-Match header of the request matches the current config object v,
+// it is true if the header is absent.
+func ifMatch(ctx *gin.Context, v any) bool {
+	match := ctx.GetHeader("If-Match")
+	if match == "" || match == "*" {
+		return true
+	}
+	etag := objectETag(v)
+	for _, s := range strings.Split(match, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(s), "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// writeObject writes the config object v in the format yaml or json with its ETag.
+// The status 304 is written if the If-None-Match header of the request matches the ETag.
+func writeObject(ctx *gin.Context, format string, v any) {
+	etag := objectETag(v)
+	ctx.Header("ETag", etag)
+	if ctx.GetHeader("If-None-Match") == etag {
+		ctx.Status(http.StatusNotModified)
+		return
+	}
+
+	if format == "yaml" {
+		b, err := yaml.Marshal(v)
+		if err != nil {
+			writeError(ctx, &Error{
+				statusCode: http.StatusInternalServerError,
+				Code:       40006,
+				Msg:        fmt.Sprintf("write: %s", err.Error()),
+			})
+			return
+		}
+		ctx.Data(http.StatusOK, "text/x-yaml", b)
+		return
+	}
+	ctx.JSON(http.StatusOK, v)
+}
+
+// objectList is the list of the config objects.
+type objectList[C any] struct {
+	Count int  `yaml:"count" json:"count"`
+	List  []*C `yaml:"list" json:"list"`
+}
+
+// getObjectList writes the list of the config objects in the format.
+func getObjectList[C any](ctx *gin.Context, list []*C, format string) {
+	writeObject(ctx, format, &objectList[C]{
+		Count: len(list),
+		List:  list,
+	})
+}
+
+// getObject writes the config object with the name in the list in the format,
+// or the error ErrNotFound if there is no such object.
+func getObject[C any](ctx *gin.Context, list []*C, name string, format string) {
+	v := findObject(list, name)
+	if v == nil {
+		writeError(ctx, ErrNotFound)
+		return
+	}
+
+	writeObject(ctx, format, v)
+}
+
+// findObject returns the config object with the name in the list,
+// all the named config objects have the Name field.
+func findObject[C any](list []*C, name string) *C {
+	for _, c := range list {
+		if c != nil && reflect.ValueOf(c).Elem().FieldByName("Name").String() == name {
+			return c
+		}
+	}
+	return nil
+}
@@ -28,6 +28,7 @@ func createAdmission(ctx *gin.Context) {
 	//
 	//     Security:
 	//       basicAuth: []
+	//       bearerAuth: []
 	//
 	//     Responses:
 	//       200: createAdmissionResponse
@@ -56,6 +57,74 @@ func createAdmission(ctx *gin.Context) {
 	})
 }
 
+// swagger:parameters getAdmissionListRequest
+type getAdmissionListRequest struct {
+	// output format, one of yaml|json, default is json.
+	// in: query
+	Format string `form:"format" json:"format"`
+}
+
+type admissionList = objectList[config.AdmissionConfig]
+
+// successful operation.
+// swagger:response getAdmissionListResponse
+type getAdmissionListResponse struct {
+	Data admissionList
+}
+
+func getAdmissionList(ctx *gin.Context) {
+	// swagger:route GET /config/admissions Admission getAdmissionListRequest
+	//
+	// Get admission list.
+	//
+	//     Security:
+	//       basicAuth: []
+	//       bearerAuth: []
+	//
+	//     Responses:
+	//       200: getAdmissionListResponse
+
+	var req getAdmissionListRequest
+	ctx.ShouldBindQuery(&req)
+
+	getObjectList(ctx, config.Global().Admissions, req.Format)
+}
+
+// swagger:parameters getAdmissionRequest
+type getAdmissionRequest struct {
+	// in: path
+	// required: true
+	Admission string `uri:"admission" json:"admission"`
+	// output format, one of yaml|json, default is json.
+	// in: query
+	Format string `form:"format" json:"format"`
+}
+
+// successful operation.
+// swagger:response getAdmissionResponse
+type getAdmissionResponse struct {
+	Data *config.AdmissionConfig
+}
+
+func getAdmission(ctx *gin.Context) {
+	// swagger:route GET /config/admissions/{admission} Admission getAdmissionRequest
+	//
+	// Get admission by name, the ETag of the response can be used in the If-Match header of the update.
+	//
+	//     Security:
+	//       basicAuth: []
+	//       bearerAuth: []
+	//
+	//     Responses:
+	//       200: getAdmissionResponse
+
+	var req getAdmissionRequest
+	ctx.ShouldBindUri(&req)
+	ctx.ShouldBindQuery(&req)
+
+	getObject(ctx, config.Global().Admissions, req.Admission, req.Format)
+}
+
 // swagger:parameters updateAdmissionRequest
 type updateAdmissionRequest struct {
 	// in: path
@@ -78,6 +147,7 @@ func updateAdmission(ctx *gin.Context) {
 	//
 	//     Security:
 	//       basicAuth: []
+	//       bearerAuth: []
 	//
 	//     Responses:
 	//       200: updateAdmissionResponse
@@ -90,6 +160,10 @@ func updateAdmission(ctx *gin.Context) {
 		writeError(ctx, ErrNotFound)
 		return
 	}
+	if !ifMatch(ctx, findObject(config.Global().Admissions, req.Admission)) {
+		writeError(ctx, ErrChanged)
+		return
+	}
 
 	req.Data.Name = req.Admission
 
@@ -136,6 +210,7 @@ func deleteAdmission(ctx *gin.Context) {
 	//
 	//     Security:
 	//       basicAuth: []
+	//       bearerAuth: []
 	//
 	//     Responses:
 	//       200: deleteAdmissionResponse
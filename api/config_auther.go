@@ -28,6 +28,7 @@ func createAuther(ctx *gin.Context) {
 	//
 	//     Security:
 	//       basicAuth: []
+	//       bearerAuth: []
 	//
 	//     Responses:
 	//       200: createAutherResponse
@@ -55,6 +56,74 @@ func createAuther(ctx *gin.Context) {
 	})
 }
 
+// swagger:parameters getAutherListRequest
+type getAutherListRequest struct {
+	// output format, one of yaml|json, default is json.
+	// in: query
+	Format string `form:"format" json:"format"`
+}
+
+type autherList = objectList[config.AutherConfig]
+
+// successful operation.
+// swagger:response getAutherListResponse
+type getAutherListResponse struct {
+	Data autherList
+}
+
+func getAutherList(ctx *gin.Context) {
+	// swagger:route GET /config/authers Auther getAutherListRequest
+	//
+	// Get auther list.
+	//
+	//     Security:
+	//       basicAuth: []
+	//       bearerAuth: []
+	//
+	//     Responses:
+	//       200: getAutherListResponse
+
+	var req getAutherListRequest
+	ctx.ShouldBindQuery(&req)
+
+	getObjectList(ctx, config.Global().Authers, req.Format)
+}
+
+// swagger:parameters getAutherRequest
+type getAutherRequest struct {
+	// in: path
+	// required: true
+	Auther string `uri:"auther" json:"auther"`
+	// output format, one of yaml|json, default is json.
+	// in: query
+	Format string `form:"format" json:"format"`
+}
+
+// successful operation.
+// swagger:response getAutherResponse
+type getAutherResponse struct {
+	Data *config.AutherConfig
+}
+
+func getAuther(ctx *gin.Context) {
+	// swagger:route GET /config/authers/{auther} Auther getAutherRequest
+	//
+	// Get auther by name, the ETag of the response can be used in the If-Match header of the update.
+	//
+	//     Security:
+	//       basicAuth: []
+	//       bearerAuth: []
+	//
+	//     Responses:
+	//       200: getAutherResponse
+
+	var req getAutherRequest
+	ctx.ShouldBindUri(&req)
+	ctx.ShouldBindQuery(&req)
+
+	getObject(ctx, config.Global().Authers, req.Auther, req.Format)
+}
+
 // swagger:parameters updateAutherRequest
 type updateAutherRequest struct {
 	// in: path
@@ -77,6 +146,7 @@ func updateAuther(ctx *gin.Context) {
 	//
 	//     Security:
 	//       basicAuth: []
+	//       bearerAuth: []
 	//
 	//     Responses:
 	//       200: updateAutherResponse
@@ -89,6 +159,10 @@ func updateAuther(ctx *gin.Context) {
 		writeError(ctx, ErrNotFound)
 		return
 	}
+	if !ifMatch(ctx, findObject(config.Global().Authers, req.Auther)) {
+		writeError(ctx, ErrChanged)
+		return
+	}
 
 	req.Data.Name = req.Auther
 
@@ -134,6 +208,7 @@ func deleteAuther(ctx *gin.Context) {
 	//
 	//     Security:
 	//       basicAuth: []
+	//       bearerAuth: []
 	//
 	//     Responses:
 	//       200: deleteAutherResponse
@@ -28,6 +28,7 @@ func createBypass(ctx *gin.Context) {
 	//
 	//     Security:
 	//       basicAuth: []
+	//       bearerAuth: []
 	//
 	//     Responses:
 	//       200: createBypassResponse
@@ -56,6 +57,74 @@ func createBypass(ctx *gin.Context) {
 	})
 }
 
+// swagger:parameters getBypassListRequest
+type getBypassListRequest struct {
+	// output format, one of yaml|json, default is json.
+	// in: query
+	Format string `form:"format" json:"format"`
+}
+
+type bypassList = objectList[config.BypassConfig]
+
+// successful operation.
+// swagger:response getBypassListResponse
+type getBypassListResponse struct {
+	Data bypassList
+}
+
+func getBypassList(ctx *gin.Context) {
+	// swagger:route GET /config/bypasses Bypass getBypassListRequest
+	//
+	// Get bypass list.
+	//
+	//     Security:
+	//       basicAuth: []
+	//       bearerAuth: []
+	//
+	//     Responses:
+	//       200: getBypassListResponse
+
+	var req getBypassListRequest
+	ctx.ShouldBindQuery(&req)
+
+	getObjectList(ctx, config.Global().Bypasses, req.Format)
+}
+
+// swagger:parameters getBypassRequest
+type getBypassRequest struct {
+	// in: path
+	// required: true
+	Bypass string `uri:"bypass" json:"bypass"`
+	// output format, one of yaml|json, default is json.
+	// in: query
+	Format string `form:"format" json:"format"`
+}
+
+// successful operation.
+// swagger:response getBypassResponse
+type getBypassResponse struct {
+	Data *config.BypassConfig
+}
+
+func getBypass(ctx *gin.Context) {
+	// swagger:route GET /config/bypasses/{bypass} Bypass getBypassRequest
+	//
+	// Get bypass by name, the ETag of the response can be used in the If-Match header of the update.
+	//
+	//     Security:
+	//       basicAuth: []
+	//       bearerAuth: []
+	//
+	//     Responses:
+	//       200: getBypassResponse
+
+	var req getBypassRequest
+	ctx.ShouldBindUri(&req)
+	ctx.ShouldBindQuery(&req)
+
+	getObject(ctx, config.Global().Bypasses, req.Bypass, req.Format)
+}
+
 // swagger:parameters updateBypassRequest
 type updateBypassRequest struct {
 	// in: path
@@ -78,6 +147,7 @@ func updateBypass(ctx *gin.Context) {
 	//
 	//     Security:
 	//       basicAuth: []
+	//       bearerAuth: []
 	//
 	//     Responses:
 	//       200: updateBypassResponse
@@ -90,6 +160,10 @@ func updateBypass(ctx *gin.Context) {
 		writeError(ctx, ErrNotFound)
 		return
 	}
+	if !ifMatch(ctx, findObject(config.Global().Bypasses, req.Bypass)) {
+		writeError(ctx, ErrChanged)
+		return
+	}
 
 	req.Data.Name = req.Bypass
 
@@ -136,6 +210,7 @@ func deleteBypass(ctx *gin.Context) {
 	//
 	//     Security:
 	//       basicAuth: []
+	//       bearerAuth: []
 	//
 	//     Responses:
 	//       200: deleteBypassResponse
@@ -28,6 +28,7 @@ func createChain(ctx *gin.Context) {
 	//
 	//     Security:
 	//       basicAuth: []
+	//       bearerAuth: []
 	//
 	//     Responses:
 	//       200: createChainResponse
@@ -60,6 +61,74 @@ func createChain(ctx *gin.Context) {
 	})
 }
 
+// swagger:parameters getChainListRequest
+type getChainListRequest struct {
+	// output format, one of yaml|json, default is json.
+	// in: query
+	Format string `form:"format" json:"format"`
+}
+
+type chainList = objectList[config.ChainConfig]
+
+// successful operation.
+// swagger:response getChainListResponse
+type getChainListResponse struct {
+	Data chainList
+}
+
+func getChainList(ctx *gin.Context) {
+	// swagger:route GET /config/chains Chain getChainListRequest
+	//
+	// Get chain list.
+	//
+	//     Security:
+	//       basicAuth: []
+	//       bearerAuth: []
+	//
+	//     Responses:
+	//       200: getChainListResponse
+
+	var req getChainListRequest
+	ctx.ShouldBindQuery(&req)
+
+	getObjectList(ctx, config.Global().Chains, req.Format)
+}
+
+// swagger:parameters getChainRequest
+type getChainRequest struct {
+	// in: path
+	// required: true
+	Chain string `uri:"chain" json:"chain"`
+	// output format, one of yaml|json, default is json.
+	// in: query
+	Format string `form:"format" json:"format"`
+}
+
+// successful operation.
+// swagger:response getChainResponse
+type getChainResponse struct {
+	Data *config.ChainConfig
+}
+
+func getChain(ctx *gin.Context) {
+	// swagger:route GET /config/chains/{chain} Chain getChainRequest
+	//
+	// Get chain by name, the ETag of the response can be used in the If-Match header of the update.
+	//
+	//     Security:
+	//       basicAuth: []
+	//       bearerAuth: []
+	//
+	//     Responses:
+	//       200: getChainResponse
+
+	var req getChainRequest
+	ctx.ShouldBindUri(&req)
+	ctx.ShouldBindQuery(&req)
+
+	getObject(ctx, config.Global().Chains, req.Chain, req.Format)
+}
+
 // swagger:parameters updateChainRequest
 type updateChainRequest struct {
 	// in: path
@@ -83,6 +152,7 @@ func updateChain(ctx *gin.Context) {
 	//
 	//     Security:
 	//       basicAuth: []
+	//       bearerAuth: []
 	//
 	//     Responses:
 	//       200: updateChainResponse
@@ -95,6 +165,10 @@ func updateChain(ctx *gin.Context) {
 		writeError(ctx, ErrNotFound)
 		return
 	}
+	if !ifMatch(ctx, findObject(config.Global().Chains, req.Chain)) {
+		writeError(ctx, ErrChanged)
+		return
+	}
 
 	req.Data.Name = req.Chain
 
@@ -145,6 +219,7 @@ func deleteChain(ctx *gin.Context) {
 	//
 	//     Security:
 	//       basicAuth: []
+	//       bearerAuth: []
 	//
 	//     Responses:
 	//       200: deleteChainResponse
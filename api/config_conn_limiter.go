@@ -28,6 +28,7 @@ func createConnLimiter(ctx *gin.Context) {
 	//
 	//     Security:
 	//       basicAuth: []
+	//       bearerAuth: []
 	//
 	//     Responses:
 	//       200: createConnLimiterResponse
@@ -56,6 +57,74 @@ func createConnLimiter(ctx *gin.Context) {
 	})
 }
 
+// swagger:parameters getConnLimiterListRequest
+type getConnLimiterListRequest struct {
+	// output format, one of yaml|json, default is json.
+	// in: query
+	Format string `form:"format" json:"format"`
+}
+
+type connLimiterList = objectList[config.LimiterConfig]
+
+// successful operation.
+// swagger:response getConnLimiterListResponse
+type getConnLimiterListResponse struct {
+	Data connLimiterList
+}
+
+func getConnLimiterList(ctx *gin.Context) {
+	// swagger:route GET /config/climiters Limiter getConnLimiterListRequest
+	//
+	// Get conn limiter list.
+	//
+	//     Security:
+	//       basicAuth: []
+	//       bearerAuth: []
+	//
+	//     Responses:
+	//       200: getConnLimiterListResponse
+
+	var req getConnLimiterListRequest
+	ctx.ShouldBindQuery(&req)
+
+	getObjectList(ctx, config.Global().CLimiters, req.Format)
+}
+
+// swagger:parameters getConnLimiterRequest
+type getConnLimiterRequest struct {
+	// in: path
+	// required: true
+	Limiter string `uri:"limiter" json:"limiter"`
+	// output format, one of yaml|json, default is json.
+	// in: query
+	Format string `form:"format" json:"format"`
+}
+
+// successful operation.
+// swagger:response getConnLimiterResponse
+type getConnLimiterResponse struct {
+	Data *config.LimiterConfig
+}
+
+func getConnLimiter(ctx *gin.Context) {
+	// swagger:route GET /config/climiters/{limiter} Limiter getConnLimiterRequest
+	//
+	// Get conn limiter by name, the ETag of the response can be used in the If-Match header of the update.
+	//
+	//     Security:
+	//       basicAuth: []
+	//       bearerAuth: []
+	//
+	//     Responses:
+	//       200: getConnLimiterResponse
+
+	var req getConnLimiterRequest
+	ctx.ShouldBindUri(&req)
+	ctx.ShouldBindQuery(&req)
+
+	getObject(ctx, config.Global().CLimiters, req.Limiter, req.Format)
+}
+
 // swagger:parameters updateConnLimiterRequest
 type updateConnLimiterRequest struct {
 	// in: path
@@ -78,6 +147,7 @@ func updateConnLimiter(ctx *gin.Context) {
 	//
 	//     Security:
 	//       basicAuth: []
+	//       bearerAuth: []
 	//
 	//     Responses:
 	//       200: updateConnLimiterResponse
@@ -90,6 +160,10 @@ func updateConnLimiter(ctx *gin.Context) {
 		writeError(ctx, ErrNotFound)
 		return
 	}
+	if !ifMatch(ctx, findObject(config.Global().CLimiters, req.Limiter)) {
+		writeError(ctx, ErrChanged)
+		return
+	}
 
 	req.Data.Name = req.Limiter
 
@@ -136,6 +210,7 @@ func deleteConnLimiter(ctx *gin.Context) {
 	//
 	//     Security:
 	//       basicAuth: []
+	//       bearerAuth: []
 	//
 	//     Responses:
 	//       200: deleteConnLimiterResponse
@@ -5,8 +5,10 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-gost/x/config"
+	"github.com/go-gost/x/config/loader"
 	"github.com/go-gost/x/config/parsing"
 	"github.com/go-gost/x/registry"
+	xservice "github.com/go-gost/x/service"
 )
 
 // swagger:parameters createServiceRequest
@@ -28,6 +30,7 @@ func createService(ctx *gin.Context) {
 	//
 	//     Security:
 	//       basicAuth: []
+	//       bearerAuth: []
 	//
 	//     Responses:
 	//       200: createServiceResponse
@@ -68,6 +71,74 @@ func createService(ctx *gin.Context) {
 	})
 }
 
+// swagger:parameters getServiceListRequest
+type getServiceListRequest struct {
+	// output format, one of yaml|json, default is json.
+	// in: query
+	Format string `form:"format" json:"format"`
+}
+
+type serviceList = objectList[config.ServiceConfig]
+
+// successful operation.
+// swagger:response getServiceListResponse
+type getServiceListResponse struct {
+	Data serviceList
+}
+
+func getServiceList(ctx *gin.Context) {
+	// swagger:route GET /config/services Service getServiceListRequest
+	//
+	// Get service list.
+	//
+	//     Security:
+	//       basicAuth: []
+	//       bearerAuth: []
+	//
+	//     Responses:
+	//       200: getServiceListResponse
+
+	var req getServiceListRequest
+	ctx.ShouldBindQuery(&req)
+
+	getObjectList(ctx, config.Global().Services, req.Format)
+}
+
+// swagger:parameters getServiceRequest
+type getServiceRequest struct {
+	// in: path
+	// required: true
+	Service string `uri:"service" json:"service"`
+	// output format, one of yaml|json, default is json.
+	// in: query
+	Format string `form:"format" json:"format"`
+}
+
+// successful operation.
+// swagger:response getServiceResponse
+type getServiceResponse struct {
+	Data *config.ServiceConfig
+}
+
+func getService(ctx *gin.Context) {
+	// swagger:route GET /config/services/{service} Service getServiceRequest
+	//
+	// Get service by name, the ETag of the response can be used in the If-Match header of the update.
+	//
+	//     Security:
+	//       basicAuth: []
+	//       bearerAuth: []
+	//
+	//     Responses:
+	//       200: getServiceResponse
+
+	var req getServiceRequest
+	ctx.ShouldBindUri(&req)
+	ctx.ShouldBindQuery(&req)
+
+	getObject(ctx, config.Global().Services, req.Service, req.Format)
+}
+
 // swagger:parameters updateServiceRequest
 type updateServiceRequest struct {
 	// in: path
@@ -90,6 +161,7 @@ func updateService(ctx *gin.Context) {
 	//
 	//     Security:
 	//       basicAuth: []
+	//       bearerAuth: []
 	//
 	//     Responses:
 	//       200: updateServiceResponse
@@ -98,31 +170,24 @@ func updateService(ctx *gin.Context) {
 	ctx.ShouldBindUri(&req)
 	ctx.ShouldBindJSON(&req.Data)
 
-	old := registry.ServiceRegistry().Get(req.Service)
-	if old == nil {
+	if !registry.ServiceRegistry().IsRegistered(req.Service) {
 		writeError(ctx, ErrNotFound)
 		return
 	}
-	old.Close()
-
-	req.Data.Name = req.Service
-
-	svc, err := parsing.ParseService(&req.Data)
-	if err != nil {
-		writeError(ctx, ErrCreate)
+	old := findObject(config.Global().Services, req.Service)
+	if !ifMatch(ctx, old) {
+		writeError(ctx, ErrChanged)
 		return
 	}
 
-	registry.ServiceRegistry().Unregister(req.Service)
+	req.Data.Name = req.Service
 
-	if err := registry.ServiceRegistry().Register(req.Service, svc); err != nil {
-		svc.Close()
-		writeError(ctx, ErrDup)
+	// the old service keeps running if the new one fails to be created.
+	if err := loader.ReplaceService(old, &req.Data); err != nil {
+		writeError(ctx, ErrCreate)
 		return
 	}
 
-	go svc.Serve()
-
 	cfg := config.Global()
 	for i := range cfg.Services {
 		if cfg.Services[i].Name == req.Service {
@@ -157,6 +222,7 @@ func deleteService(ctx *gin.Context) {
 	//
 	//     Security:
 	//       basicAuth: []
+	//       bearerAuth: []
 	//
 	//     Responses:
 	//       200: deleteServiceResponse
@@ -188,3 +254,72 @@ func deleteService(ctx *gin.Context) {
 		Msg: "OK",
 	})
 }
+
+// swagger:parameters updateServiceRecordersRequest
+type updateServiceRecordersRequest struct {
+	// in: path
+	// required: true
+	Service string `uri:"service" json:"service"`
+	// in: body
+	Data []*config.RecorderObject `json:"data"`
+}
+
+// successful operation.
+// swagger:response updateServiceRecordersResponse
+type updateServiceRecordersResponse struct {
+	Data Response
+}
+
+func updateServiceRecorders(ctx *gin.Context) {
+	// swagger:route PUT /config/services/{service}/recorders Service updateServiceRecordersRequest
+	//
+	// Replace the recorders of the running service without restarting it, an empty list detaches all the recorders.
+	//
+	//     Security:
+	//       basicAuth: []
+	//       bearerAuth: []
+	//
+	//     Responses:
+	//       200: updateServiceRecordersResponse
+
+	var req updateServiceRecordersRequest
+	ctx.ShouldBindUri(&req)
+	ctx.ShouldBindJSON(&req.Data)
+
+	svc, ok := registry.ServiceRegistry().Get(req.Service).(xservice.RecorderService)
+	if !ok {
+		writeError(ctx, ErrNotFound)
+		return
+	}
+	if !ifMatch(ctx, findObject(config.Global().Services, req.Service)) {
+		writeError(ctx, ErrChanged)
+		return
+	}
+
+	for _, r := range req.Data {
+		if r == nil || r.Record == "" || !registry.RecorderRegistry().IsRegistered(r.Name) {
+			writeError(ctx, ErrInvalid)
+			return
+		}
+	}
+
+	if err := svc.SetRecorders(parsing.ParseRecorderObjects(req.Data)...); err != nil {
+		writeError(ctx, ErrInvalid)
+		return
+	}
+
+	cfg := config.Global()
+	for i := range cfg.Services {
+		if cfg.Services[i].Name == req.Service {
+			c := *cfg.Services[i]
+			c.Recorders = req.Data
+			cfg.Services[i] = &c
+			break
+		}
+	}
+	config.SetGlobal(cfg)
+
+	ctx.JSON(http.StatusOK, Response{
+		Msg: "OK",
+	})
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-gost/core/recorder"
+	xrecorder "github.com/go-gost/x/recorder"
+	"github.com/go-gost/x/registry"
+	xservice "github.com/go-gost/x/service"
+)
+
+// memRecorder records the data in memory.
+type memRecorder struct {
+	mu   sync.Mutex
+	data []string
+}
+
+func (r *memRecorder) Record(ctx context.Context, b []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.data = append(r.data, string(b))
+	return nil
+}
+
+func (r *memRecorder) get() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]string(nil), r.data...)
+}
+
+func TestUpdateServiceRecorders(t *testing.T) {
+	rec := &memRecorder{}
+	if err := registry.RecorderRegistry().Register("recorder-0", rec); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { registry.RecorderRegistry().Unregister("recorder-0") })
+
+	// the recorders of the service are set by the group as the service is parsed.
+	group := xrecorder.NewGroup()
+	addr := newTestService(t, "service-0",
+		xservice.RecordersOption(group.Objects()...),
+		xservice.RecorderGroupOption(group),
+	)
+	h := newTestHandler(t)
+
+	put := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/config/services/service-0/recorders", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	dialTestService(t, addr, "alice example.com:443")
+	if got := rec.get(); len(got) > 0 {
+		t.Fatalf("got %q recorded without the recorders", got)
+	}
+
+	w := put(`[{"name": "recorder-0", "record": "` + recorder.RecorderServiceClientAddress + `"}]`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("got status %d, want %d", w.Code, http.StatusOK)
+	}
+	objects := registry.ServiceRegistry().Get("service-0").(xservice.RecorderService).Recorders()
+	if len(objects) != 1 || objects[0].Record != recorder.RecorderServiceClientAddress {
+		t.Errorf("got recorders %+v", objects)
+	}
+
+	// the recorders take effect for the new connections.
+	c := dialTestService(t, addr, "bob example.org:443")
+	host, _, _ := net.SplitHostPort(c.LocalAddr().String())
+	if got := rec.get(); len(got) != 1 || got[0] != host {
+		t.Errorf("got %q recorded, want the client address %s", got, host)
+	}
+
+	// the recorders are detached by the empty list.
+	if w := put(`[]`); w.Code != http.StatusOK {
+		t.Fatalf("got status %d, want %d", w.Code, http.StatusOK)
+	}
+	dialTestService(t, addr, "dave example.com:80")
+	if got := rec.get(); len(got) != 1 {
+		t.Errorf("got %q recorded after the recorders are detached", got)
+	}
+
+	// the unknown recorder is rejected.
+	if code := errorCode(t, put(`[{"name": "recorder-1", "record": "`+recorder.RecorderServiceClientAddress+`"}]`)); code != ErrInvalid.Code {
+		t.Errorf("got error code %d for the unknown recorder, want %d", code, ErrInvalid.Code)
+	}
+}
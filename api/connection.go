@@ -0,0 +1,157 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-gost/x/registry"
+	xservice "github.com/go-gost/x/service"
+)
+
+// Connection is an active client connection of a service.
+type Connection struct {
+	ID      uint64 `json:"id"`
+	Service string `json:"service"`
+	// address of the client.
+	Client string `json:"client"`
+	// the authenticated user of the client.
+	User    string `json:"user,omitempty"`
+	Network string `json:"network,omitempty"`
+	// the destination address the client connects to.
+	Dst   string `json:"dst,omitempty"`
+	Chain string `json:"chain,omitempty"`
+	// the nodes of the chain in name@addr.
+	Nodes []string  `json:"nodes,omitempty"`
+	Start time.Time `json:"start"`
+	// the number of bytes received from the destination.
+	InputBytes int64 `json:"inputBytes"`
+	// the number of bytes sent to the destination.
+	OutputBytes int64 `json:"outputBytes"`
+}
+
+type connectionList struct {
+	Count int           `json:"count"`
+	List  []*Connection `json:"list"`
+}
+
+// swagger:parameters getConnectionListRequest
+type getConnectionListRequest struct {
+	// in: path
+	// required: true
+	Service string `uri:"service" json:"service"`
+	// only the connections of the user.
+	// in: query
+	User string `form:"user" json:"user"`
+	// only the connections whose client address contains the value.
+	// in: query
+	Client string `form:"client" json:"client"`
+	// only the connections whose destination address contains the value.
+	// in: query
+	Dst string `form:"dst" json:"dst"`
+	// only the connections through the chain.
+	// in: query
+	Chain string `form:"chain" json:"chain"`
+}
+
+// successful operation.
+// swagger:response getConnectionListResponse
+type getConnectionListResponse struct {
+	Data connectionList
+}
+
+func getConnectionList(ctx *gin.Context) {
+	// swagger:route GET /services/{service}/connections Connection getConnectionListRequest
+	//
+	// Get the active connections of the service, ordered by the connection ID.
+	//
+	//     Security:
+	//       basicAuth: []
+	//       bearerAuth: []
+	//
+	//     Responses:
+	//       200: getConnectionListResponse
+
+	var req getConnectionListRequest
+	ctx.ShouldBindUri(&req)
+	ctx.ShouldBindQuery(&req)
+
+	svc, ok := registry.ServiceRegistry().Get(req.Service).(xservice.ConnService)
+	if !ok {
+		writeError(ctx, ErrNotFound)
+		return
+	}
+
+	var resp getConnectionListResponse
+	for _, st := range svc.Connections() {
+		if (req.User != "" && st.User != req.User) ||
+			(req.Chain != "" && st.Chain != req.Chain) ||
+			!strings.Contains(st.Client, req.Client) ||
+			!strings.Contains(st.Dst, req.Dst) {
+			continue
+		}
+		resp.Data.List = append(resp.Data.List, &Connection{
+			ID:          st.ID,
+			Service:     st.Service,
+			Client:      st.Client,
+			User:        st.User,
+			Network:     st.Network,
+			Dst:         st.Dst,
+			Chain:       st.Chain,
+			Nodes:       st.Nodes,
+			Start:       st.Start,
+			InputBytes:  st.InputBytes,
+			OutputBytes: st.OutputBytes,
+		})
+	}
+	sort.Slice(resp.Data.List, func(i, j int) bool {
+		return resp.Data.List[i].ID < resp.Data.List[j].ID
+	})
+	resp.Data.Count = len(resp.Data.List)
+
+	ctx.JSON(http.StatusOK, resp.Data)
+}
+
+// swagger:parameters deleteConnectionRequest
+type deleteConnectionRequest struct {
+	// in: path
+	// required: true
+	ID uint64 `uri:"id" json:"id"`
+}
+
+// successful operation.
+// swagger:response deleteConnectionResponse
+type deleteConnectionResponse struct {
+	Data Response
+}
+
+func deleteConnection(ctx *gin.Context) {
+	// swagger:route DELETE /connections/{id} Connection deleteConnectionRequest
+	//
+	// Close the active connection by ID.
+	//
+	//     Security:
+	//       basicAuth: []
+	//       bearerAuth: []
+	//
+	//     Responses:
+	//       200: deleteConnectionResponse
+
+	var req deleteConnectionRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		writeError(ctx, ErrInvalid)
+		return
+	}
+
+	// the connections of the services draining after they are replaced or deleted are found too.
+	if !xservice.CloseConnection(req.ID) {
+		writeError(ctx, ErrNotFound)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, Response{
+		Msg: "OK",
+	})
+}
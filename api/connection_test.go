@@ -0,0 +1,154 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestConnService registers the service serving the connections of the users to the destinations,
+// the lines are in the format of "user dst".
+func newTestConnService(t *testing.T, name string, lines ...string) []net.Conn {
+	t.Helper()
+
+	addr := newTestService(t, name)
+	var conns []net.Conn
+	for _, line := range lines {
+		conns = append(conns, dialTestService(t, addr, line))
+	}
+	return conns
+}
+
+// getConnections returns the error code and the connections listed.
+func getConnections(t *testing.T, h http.Handler, target string) (int, []*Connection) {
+	t.Helper()
+
+	w := do(h, http.MethodGet, target, nil)
+	if code := errorCode(t, w); code != 0 {
+		return code, nil
+	}
+	var list connectionList
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatal(err)
+	}
+	if list.Count != len(list.List) {
+		t.Errorf("got count %d of %d connections", list.Count, len(list.List))
+	}
+	return 0, list.List
+}
+
+func TestGetConnectionList(t *testing.T) {
+	newTestConnService(t, "conns",
+		"alice example.com:443",
+		"bob example.com:80",
+		"alice example.org:443",
+	)
+	h := newTestHandler(t)
+
+	tests := []struct {
+		name   string
+		target string
+		code   int
+		want   []string // the connections in "user dst".
+	}{
+		{
+			name:   "all",
+			target: "/services/conns/connections",
+			want:   []string{"alice example.com:443", "bob example.com:80", "alice example.org:443"},
+		},
+		{
+			name:   "user",
+			target: "/services/conns/connections?user=alice",
+			want:   []string{"alice example.com:443", "alice example.org:443"},
+		},
+		{
+			name:   "dst",
+			target: "/services/conns/connections?dst=example.com",
+			want:   []string{"alice example.com:443", "bob example.com:80"},
+		},
+		{
+			name:   "user and dst",
+			target: "/services/conns/connections?user=alice&dst=:443",
+			want:   []string{"alice example.com:443", "alice example.org:443"},
+		},
+		{
+			name:   "client",
+			target: "/services/conns/connections?client=127.0.0.1:",
+			want:   []string{"alice example.com:443", "bob example.com:80", "alice example.org:443"},
+		},
+		{
+			name:   "chain",
+			target: "/services/conns/connections?chain=chain-0",
+		},
+		{
+			name:   "unknown service",
+			target: "/services/unknown/connections",
+			code:   ErrNotFound.Code,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, list := getConnections(t, h, tt.target)
+			if code != tt.code {
+				t.Fatalf("got error code %d, want %d", code, tt.code)
+			}
+			var got []string
+			for i, c := range list {
+				if c.Service != "conns" || c.Network != "tcp" {
+					t.Errorf("got connection %+v", c)
+				}
+				if i > 0 && c.ID <= list[i-1].ID {
+					t.Errorf("the connections are not ordered by the id")
+				}
+				got = append(got, c.User+" "+c.Dst)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("got connections %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeleteConnection(t *testing.T) {
+	conns := newTestConnService(t, "kill",
+		"alice example.com:443",
+		"bob example.com:80",
+	)
+	h := newTestHandler(t)
+
+	_, list := getConnections(t, h, "/services/kill/connections?user=bob")
+	if len(list) != 1 {
+		t.Fatalf("got %d connections, want 1", len(list))
+	}
+
+	if w := do(h, http.MethodDelete, fmt.Sprintf("/connections/%d", list[0].ID), nil); w.Code != http.StatusOK {
+		t.Fatalf("got status %d, want %d", w.Code, http.StatusOK)
+	}
+	conns[1].SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := conns[1].Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("got error %v, want the connection closed", err)
+	}
+
+	// the connection is untracked once the handler returns.
+	for i := 0; i < 10; i++ {
+		if _, list = getConnections(t, h, "/services/kill/connections"); len(list) == 1 {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if len(list) != 1 || list[0].User != "alice" {
+		t.Errorf("got connections %+v, want only the connection of alice", list)
+	}
+
+	if code := errorCode(t, do(h, http.MethodDelete, fmt.Sprintf("/connections/%d", list[0].ID+100), nil)); code != ErrNotFound.Code {
+		t.Errorf("got error code %d for the unknown connection, want %d", code, ErrNotFound.Code)
+	}
+	if code := errorCode(t, do(h, http.MethodDelete, "/connections/abc", nil)); code != ErrInvalid.Code {
+		t.Errorf("got error code %d for the invalid id, want %d", code, ErrInvalid.Code)
+	}
+}
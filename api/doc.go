@@ -13,6 +13,10 @@
 //     SecurityDefinitions:
 //     basicAuth:
 //       type: basic
+//     bearerAuth:
+//       type: apiKey
+//       name: Authorization
+//       in: header
 //
 // swagger:meta
 package api
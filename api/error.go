@@ -13,6 +13,9 @@ var (
 	ErrCreate   = &Error{statusCode: http.StatusConflict, Code: 40003, Msg: "object creation failed"}
 	ErrNotFound = &Error{statusCode: http.StatusBadRequest, Code: 40004, Msg: "object not found"}
 	ErrSave     = &Error{statusCode: http.StatusInternalServerError, Code: 40005, Msg: "save config failed"}
+	ErrChanged  = &Error{statusCode: http.StatusPreconditionFailed, Code: 40008, Msg: "object changed"}
+	ErrDenied   = &Error{statusCode: http.StatusForbidden, Code: 40009, Msg: "permission denied"}
+	ErrConflict = &Error{statusCode: http.StatusConflict, Code: 40010, Msg: "config in backend changed"}
 )
 
 // Error is an api error.
@@ -1,14 +1,43 @@
 package api
 
 import (
+	"crypto/subtle"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/go-gost/core/auth"
 	"github.com/go-gost/core/logger"
+	"github.com/go-gost/x/config/loader"
+	"github.com/golang-jwt/jwt/v5"
 )
 
+const (
+	// identityKey is the key of the caller identity in the gin context.
+	identityKey = "identity"
+)
+
+const (
+	PermissionRead   = "read"
+	PermissionSave   = "save"
+	PermissionReload = "reload"
+	// PermissionWrite is the prefix of the write permission of an object type, such as write:services.
+	PermissionWrite = "write:"
+	// PermissionAll grants all permissions.
+	PermissionAll = "*"
+)
+
+// Identity is the caller of the API.
+type Identity struct {
+	// Name is the name of the token, the subject of the JWT,
+	// the common name of the client certificate or the username of basic auth.
+	Name string
+	// Method is the authentication method: token, jwt, cert or basic,
+	// it is empty for the anonymous caller.
+	Method string
+	Roles  []string
+}
+
 func mwLogger() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		// start time
@@ -29,14 +58,196 @@ func mwLogger() gin.HandlerFunc {
 	}
 }
 
-func mwBasicAuth(auther auth.Authenticator) gin.HandlerFunc {
+// mwAuth authenticates the caller by bearer token, client certificate or basic auth in order.
+// The caller is anonymous if no authentication method is configured.
+func mwAuth(options *options) gin.HandlerFunc {
+	clientCert := options.tlsConfig != nil && options.tlsConfig.ClientCAs != nil
+	required := options.auther != nil || len(options.tokens) > 0 || len(options.jwtKeys) > 0 || clientCert
+
 	return func(c *gin.Context) {
-		if auther == nil {
+		if !required {
+			c.Set(identityKey, &Identity{})
 			return
 		}
-		u, p, _ := c.Request.BasicAuth()
-		if !auther.Authenticate(u, p) {
+
+		var id *Identity
+		if token, ok := bearerToken(c.Request); ok {
+			id = authToken(options, token)
+		} else if clientCert && c.Request.TLS != nil && len(c.Request.TLS.PeerCertificates) > 0 {
+			// the certificate has been verified by the client CAs in handshake.
+			id = &Identity{
+				Name:   c.Request.TLS.PeerCertificates[0].Subject.CommonName,
+				Method: "cert",
+			}
+		} else if options.auther != nil {
+			if u, p, ok := c.Request.BasicAuth(); ok && options.auther.Authenticate(u, p) {
+				id = &Identity{
+					Name:   u,
+					Method: "basic",
+				}
+			}
+		}
+		if id == nil {
 			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		if id.Roles == nil {
+			id.Roles = options.userRoles[id.Name]
+		}
+		c.Set(identityKey, id)
+	}
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	s := r.Header.Get("Authorization")
+	if len(s) < 7 || !strings.EqualFold(s[:7], "bearer ") {
+		return "", false
+	}
+	return strings.TrimSpace(s[7:]), true
+}
+
+func authToken(options *options, token string) *Identity {
+	for _, t := range options.tokens {
+		if t.Token != "" && subtle.ConstantTimeCompare([]byte(t.Token), []byte(token)) == 1 {
+			return &Identity{
+				Name:   t.Name,
+				Method: "token",
+				Roles:  t.Roles,
+			}
+		}
+	}
+
+	// the token without expiration is rejected, so that the leaked tokens do not last forever.
+	parserOpts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"HS256", "HS384", "HS512"}),
+		jwt.WithExpirationRequired(),
+	}
+	if options.jwtIssuer != "" {
+		parserOpts = append(parserOpts, jwt.WithIssuer(options.jwtIssuer))
+	}
+	if options.jwtAudience != "" {
+		parserOpts = append(parserOpts, jwt.WithAudience(options.jwtAudience))
+	}
+	parser := jwt.NewParser(parserOpts...)
+	for _, key := range options.jwtKeys {
+		claims := jwt.MapClaims{}
+		if _, err := parser.ParseWithClaims(token, claims, func(*jwt.Token) (any, error) {
+			return key, nil
+		}); err != nil {
+			continue
+		}
+
+		id := &Identity{
+			Method: "jwt",
+		}
+		id.Name, _ = claims["sub"].(string)
+		if roles, ok := claims["roles"].([]any); ok {
+			id.Roles = []string{}
+			for _, role := range roles {
+				if s, ok := role.(string); ok {
+					id.Roles = append(id.Roles, s)
+				}
+			}
+		}
+		return id
+	}
+
+	return nil
+}
+
+// mwPermission checks the permission required by the request against the roles of the caller.
+// The permission is not checked if no role is configured.
+func mwPermission(roles map[string][]string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if len(roles) == 0 {
+			return
+		}
+
+		id := getIdentity(c)
+		perm := permission(c)
+		for _, role := range id.Roles {
+			for _, p := range roles[role] {
+				if p == perm || p == PermissionAll ||
+					(p == PermissionWrite+"*" && strings.HasPrefix(perm, PermissionWrite)) {
+					return
+				}
+			}
+		}
+
+		writeError(c, ErrDenied)
+		c.Abort()
+	}
+}
+
+// permission returns the permission required by the request.
+func permission(c *gin.Context) string {
+	if c.Request.Method == http.MethodGet {
+		return PermissionRead
+	}
+
+	// the path is /config or /config/<object type>/...
+	path := c.FullPath()
+	if i := strings.Index(path, "/config"); i >= 0 {
+		path = path[i+len("/config"):]
+	}
+	path = strings.Trim(path, "/")
+	switch path {
+	case "":
+		return PermissionSave
+	case "reload":
+		return PermissionReload
+	case "validate":
+		return PermissionRead
+	}
+	kind, _, _ := strings.Cut(path, "/")
+	return PermissionWrite + kind
+}
+
+// mwConfigLock holds the config lock while the request changes a config object,
+// so that the check of the object, the registries and the global config are changed as a whole.
+func mwConfigLock() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodGet ||
+			!strings.HasPrefix(permission(c), PermissionWrite) {
+			return
+		}
+
+		loader.Lock()
+		defer loader.Unlock()
+		c.Next()
+	}
+}
+
+// mwAudit logs the mutating requests with the caller identity.
+func mwAudit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if c.Request.Method == http.MethodGet ||
+			c.Request.Method == http.MethodHead ||
+			c.Request.Method == http.MethodOptions {
+			return
+		}
+
+		id := getIdentity(c)
+		logger.Default().WithFields(map[string]any{
+			"kind":     "audit",
+			"method":   c.Request.Method,
+			"uri":      c.Request.RequestURI,
+			"code":     c.Writer.Status(),
+			"client":   c.ClientIP(),
+			"identity": id.Name,
+			"auth":     id.Method,
+		}).Infof("%s %s %s by %q(%s): %d",
+			c.ClientIP(), c.Request.Method, c.Request.RequestURI, id.Name, id.Method, c.Writer.Status())
+	}
+}
+
+func getIdentity(c *gin.Context) *Identity {
+	if v, ok := c.Get(identityKey); ok {
+		if id, _ := v.(*Identity); id != nil {
+			return id
 		}
 	}
+	return &Identity{}
 }
@@ -0,0 +1,242 @@
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	xauth "github.com/go-gost/x/auth"
+	xlogger "github.com/go-gost/x/logger"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func signJWT(t *testing.T, key string, claims jwt.MapClaims) string {
+	t.Helper()
+
+	s, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestAuth(t *testing.T) {
+	exp := time.Now().Add(time.Hour).Unix()
+	options := &options{
+		auther: xauth.NewAuthenticator(
+			xauth.AuthsOption(map[string]string{"admin": "pass"}),
+			xauth.LoggerOption(xlogger.Nop()),
+		),
+		tokens:      []Token{{Token: "s3cret", Name: "ci", Roles: []string{"deployer"}}},
+		jwtKeys:     [][]byte{[]byte("old"), []byte("key")},
+		jwtIssuer:   "gost",
+		jwtAudience: "api",
+		tlsConfig:   &tls.Config{ClientCAs: x509.NewCertPool()},
+		userRoles:   map[string][]string{"admin": {"admin"}, "ops": {"operator"}},
+	}
+
+	tests := []struct {
+		name   string
+		header map[string]string
+		cert   string // the common name of the verified client certificate.
+		want   *Identity
+	}{
+		{name: "no credential"},
+		{
+			name:   "token",
+			header: bearer("s3cret"),
+			want:   &Identity{Name: "ci", Method: "token", Roles: []string{"deployer"}},
+		},
+		{name: "wrong token", header: bearer("wrong")},
+		{
+			name:   "jwt",
+			header: bearer(signJWT(t, "key", jwt.MapClaims{"sub": "bot", "roles": []string{"reader"}, "iss": "gost", "aud": "api", "exp": exp})),
+			want:   &Identity{Name: "bot", Method: "jwt", Roles: []string{"reader"}},
+		},
+		{
+			name:   "jwt without roles claim",
+			header: bearer(signJWT(t, "old", jwt.MapClaims{"sub": "admin", "iss": "gost", "aud": "api", "exp": exp})),
+			want:   &Identity{Name: "admin", Method: "jwt", Roles: []string{"admin"}},
+		},
+		{
+			name:   "jwt without expiration",
+			header: bearer(signJWT(t, "key", jwt.MapClaims{"sub": "bot", "iss": "gost", "aud": "api"})),
+		},
+		{
+			name:   "expired jwt",
+			header: bearer(signJWT(t, "key", jwt.MapClaims{"sub": "bot", "iss": "gost", "aud": "api", "exp": time.Now().Add(-time.Hour).Unix()})),
+		},
+		{
+			name:   "jwt of other issuer",
+			header: bearer(signJWT(t, "key", jwt.MapClaims{"sub": "bot", "iss": "other", "aud": "api", "exp": exp})),
+		},
+		{
+			name:   "jwt of other audience",
+			header: bearer(signJWT(t, "key", jwt.MapClaims{"sub": "bot", "iss": "gost", "aud": "other", "exp": exp})),
+		},
+		{
+			name:   "jwt signed by unknown key",
+			header: bearer(signJWT(t, "unknown", jwt.MapClaims{"sub": "bot", "iss": "gost", "aud": "api", "exp": exp})),
+		},
+		{
+			name: "cert",
+			cert: "ops",
+			want: &Identity{Name: "ops", Method: "cert", Roles: []string{"operator"}},
+		},
+		{
+			name:   "token before cert",
+			header: bearer("s3cret"),
+			cert:   "ops",
+			want:   &Identity{Name: "ci", Method: "token", Roles: []string{"deployer"}},
+		},
+		{
+			name:   "basic",
+			header: map[string]string{"Authorization": "Basic YWRtaW46cGFzcw=="}, // admin:pass
+			want:   &Identity{Name: "admin", Method: "basic", Roles: []string{"admin"}},
+		},
+		{
+			name:   "wrong password",
+			header: map[string]string{"Authorization": "Basic YWRtaW46d3Jvbmc="}, // admin:wrong
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(mwAuth(options))
+			r.GET("/", func(c *gin.Context) {
+				c.JSON(http.StatusOK, getIdentity(c))
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			if tt.cert != "" {
+				req.TLS = &tls.ConnectionState{
+					PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: tt.cert}}},
+				}
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if tt.want == nil {
+				if w.Code != http.StatusUnauthorized {
+					t.Errorf("got status %d, want %d", w.Code, http.StatusUnauthorized)
+				}
+				return
+			}
+			var id Identity
+			if err := json.Unmarshal(w.Body.Bytes(), &id); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(&id, tt.want) {
+				t.Errorf("got identity %+v, want %+v", id, *tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthAnonymous(t *testing.T) {
+	r := gin.New()
+	r.Use(mwAuth(&options{}))
+	r.GET("/", func(c *gin.Context) {
+		c.JSON(http.StatusOK, getIdentity(c))
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("got status %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestPermission(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		want   string
+	}{
+		{http.MethodGet, "/config", PermissionRead},
+		{http.MethodGet, "/config/services/:service", PermissionRead},
+		{http.MethodGet, "/services/:service/connections", PermissionRead},
+		{http.MethodPost, "/config", PermissionSave},
+		{http.MethodPost, "/config/reload", PermissionReload},
+		{http.MethodPost, "/config/validate", PermissionRead},
+		{http.MethodPost, "/config/services", PermissionWrite + "services"},
+		{http.MethodPut, "/config/services/:service/recorders", PermissionWrite + "services"},
+		{http.MethodDelete, "/config/authers/:auther", PermissionWrite + "authers"},
+		{http.MethodPost, "/api/config/chains", PermissionWrite + "chains"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			var got string
+			r := gin.New()
+			r.Handle(tt.method, tt.path, func(c *gin.Context) {
+				got = permission(c)
+			})
+			r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, tt.path, nil))
+			if got != tt.want {
+				t.Errorf("got permission %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMWPermission(t *testing.T) {
+	roles := map[string][]string{
+		"reader":   {PermissionRead},
+		"deployer": {PermissionRead, PermissionWrite + "services"},
+		"writer":   {PermissionWrite + "*"},
+		"admin":    {PermissionAll},
+	}
+
+	tests := []struct {
+		name   string
+		roles  map[string][]string
+		caller []string
+		method string
+		path   string
+		allow  bool
+	}{
+		{name: "no role configured", method: http.MethodPost, path: "/config/reload", allow: true},
+		{name: "read", roles: roles, caller: []string{"reader"}, method: http.MethodGet, path: "/config", allow: true},
+		{name: "read denies save", roles: roles, caller: []string{"reader"}, method: http.MethodPost, path: "/config"},
+		{name: "read denies write", roles: roles, caller: []string{"reader"}, method: http.MethodDelete, path: "/config/services/:service"},
+		{name: "write of the type", roles: roles, caller: []string{"deployer"}, method: http.MethodPut, path: "/config/services/:service", allow: true},
+		{name: "write of other type", roles: roles, caller: []string{"deployer"}, method: http.MethodPut, path: "/config/chains/:chain"},
+		{name: "write of any type", roles: roles, caller: []string{"writer"}, method: http.MethodPost, path: "/config/chains", allow: true},
+		{name: "write denies reload", roles: roles, caller: []string{"writer"}, method: http.MethodPost, path: "/config/reload"},
+		{name: "all", roles: roles, caller: []string{"admin"}, method: http.MethodPost, path: "/config/reload", allow: true},
+		{name: "any of the roles", roles: roles, caller: []string{"reader", "admin"}, method: http.MethodPost, path: "/config", allow: true},
+		{name: "unknown role", roles: roles, caller: []string{"guest"}, method: http.MethodGet, path: "/config"},
+		{name: "no role", roles: roles, method: http.MethodGet, path: "/config"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				c.Set(identityKey, &Identity{Name: "caller", Roles: tt.caller})
+			}, mwPermission(tt.roles))
+			r.Handle(tt.method, tt.path, func(c *gin.Context) {
+				c.Status(http.StatusNoContent)
+			})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+			want := http.StatusForbidden
+			if tt.allow {
+				want = http.StatusNoContent
+			}
+			if w.Code != want {
+				t.Errorf("got status %d, want %d", w.Code, want)
+			}
+		})
+	}
+}
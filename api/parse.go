@@ -0,0 +1,95 @@
+package api
+
+import (
+	"github.com/go-gost/core/auth"
+	xauth "github.com/go-gost/x/auth"
+	"github.com/go-gost/x/config"
+	"github.com/go-gost/x/config/parsing"
+	"github.com/go-gost/x/registry"
+)
+
+// ParseOptions parses the options of the web API service from the config,
+// it is kept here rather than in the parsing package which is imported by this package.
+// The auther is got from the registry by cfg.Auther, or created from cfg.Auth.
+func ParseOptions(cfg *config.APIConfig) ([]Option, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+
+	opts := []Option{
+		PathPrefixOption(cfg.PathPrefix),
+		AccessLogOption(cfg.AccessLog),
+	}
+
+	var authers []auth.Authenticator
+	if auther := parsing.ParseAutherFromAuth(cfg.Auth); auther != nil {
+		authers = append(authers, auther)
+	}
+	if cfg.Auther != "" {
+		authers = append(authers, registry.AutherRegistry().Get(cfg.Auther))
+	}
+	switch len(authers) {
+	case 0:
+	case 1:
+		opts = append(opts, AutherOption(authers[0]))
+	default:
+		opts = append(opts, AutherOption(xauth.AuthenticatorGroup(authers...)))
+	}
+
+	var tokens []Token
+	for _, t := range cfg.Tokens {
+		if t == nil || t.Token == "" {
+			continue
+		}
+		tokens = append(tokens, Token{
+			Token: t.Token,
+			Name:  t.Name,
+			Roles: t.Roles,
+		})
+	}
+	if len(tokens) > 0 {
+		opts = append(opts, TokensOption(tokens...))
+	}
+
+	if cfg.JWT != nil && len(cfg.JWT.Keys) > 0 {
+		var keys [][]byte
+		for _, key := range cfg.JWT.Keys {
+			keys = append(keys, []byte(key))
+		}
+		opts = append(opts, JWTOption(keys, cfg.JWT.Issuer, cfg.JWT.Audience))
+	}
+
+	if cfg.TLS != nil {
+		tlsConfig, err := parsing.ParseServerTLSConfig(cfg.TLS)
+		if err != nil {
+			return nil, err
+		}
+		if tlsConfig != nil {
+			opts = append(opts, TLSConfigOption(tlsConfig))
+		}
+	}
+
+	if len(cfg.Roles) > 0 {
+		roles := make(map[string][]string)
+		for _, r := range cfg.Roles {
+			if r == nil || r.Name == "" {
+				continue
+			}
+			roles[r.Name] = append(roles[r.Name], r.Permissions...)
+		}
+		opts = append(opts, RolesOption(roles))
+	}
+
+	if len(cfg.Users) > 0 {
+		users := make(map[string][]string)
+		for _, u := range cfg.Users {
+			if u == nil || u.Name == "" {
+				continue
+			}
+			users[u.Name] = append(users[u.Name], u.Roles...)
+		}
+		opts = append(opts, UserRolesOption(users))
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-gost/x/config"
+)
+
+func TestParseOptions(t *testing.T) {
+	cfg := &config.APIConfig{
+		PathPrefix: "/api",
+		AccessLog:  true,
+		Auth:       &config.AuthConfig{Username: "admin", Password: "pass"},
+		Tokens: []*config.APITokenConfig{
+			{Name: "ci", Token: "s3cret", Roles: []string{"deployer"}},
+			// the empty token is skipped.
+			{Name: "empty"},
+		},
+		JWT: &config.APIJWTConfig{Keys: []string{"key"}, Issuer: "gost", Audience: "api"},
+		Roles: []*config.APIRoleConfig{
+			{Name: "deployer", Permissions: []string{PermissionRead, PermissionWrite + "services"}},
+			{Name: "admin", Permissions: []string{PermissionAll}},
+		},
+		Users: []*config.APIUserConfig{
+			{Name: "admin", Roles: []string{"admin"}},
+		},
+	}
+
+	opts, err := ParseOptions(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got options
+	for _, opt := range opts {
+		opt(&got)
+	}
+
+	if got.pathPrefix != "/api" || !got.accessLog {
+		t.Errorf("got path prefix %q and access log %v", got.pathPrefix, got.accessLog)
+	}
+	if got.auther == nil {
+		t.Error("no auther")
+	} else if !got.auther.Authenticate("admin", "pass") {
+		t.Error("the user of auth is not authenticated")
+	}
+	if want := []Token{{Token: "s3cret", Name: "ci", Roles: []string{"deployer"}}}; !reflect.DeepEqual(got.tokens, want) {
+		t.Errorf("got tokens %v, want %v", got.tokens, want)
+	}
+	if !reflect.DeepEqual(got.jwtKeys, [][]byte{[]byte("key")}) || got.jwtIssuer != "gost" || got.jwtAudience != "api" {
+		t.Errorf("got JWT keys %q, issuer %q and audience %q", got.jwtKeys, got.jwtIssuer, got.jwtAudience)
+	}
+	if want := map[string][]string{
+		"deployer": {PermissionRead, PermissionWrite + "services"},
+		"admin":    {PermissionAll},
+	}; !reflect.DeepEqual(got.roles, want) {
+		t.Errorf("got roles %v, want %v", got.roles, want)
+	}
+	if want := map[string][]string{"admin": {"admin"}}; !reflect.DeepEqual(got.userRoles, want) {
+		t.Errorf("got user roles %v, want %v", got.userRoles, want)
+	}
+	if got.tlsConfig != nil {
+		t.Error("got TLS config without the TLS settings")
+	}
+}
+
+func TestParseOptionsTLSError(t *testing.T) {
+	_, err := ParseOptions(&config.APIConfig{
+		TLS: &config.TLSConfig{CertFile: "nonexistent.crt", KeyFile: "nonexistent.key"},
+	})
+	if err == nil {
+		t.Error("no error for the missing certificate files")
+	}
+}
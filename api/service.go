@@ -1,6 +1,8 @@
 package api
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"net"
 	"net/http"
 
@@ -11,9 +13,16 @@ import (
 )
 
 type options struct {
-	accessLog  bool
-	pathPrefix string
-	auther     auth.Authenticator
+	accessLog   bool
+	pathPrefix  string
+	auther      auth.Authenticator
+	tokens      []Token
+	jwtKeys     [][]byte
+	jwtIssuer   string
+	jwtAudience string
+	tlsConfig   *tls.Config
+	roles       map[string][]string
+	userRoles   map[string][]string
 }
 
 type Option func(*options)
@@ -36,6 +45,54 @@ func AutherOption(auther auth.Authenticator) Option {
 	}
 }
 
+// Token is a static bearer token.
+type Token struct {
+	Token string
+	// Name is the name of the caller identified by the token.
+	Name  string
+	Roles []string
+}
+
+func TokensOption(tokens ...Token) Option {
+	return func(o *options) {
+		o.tokens = tokens
+	}
+}
+
+// JWTOption sets the HMAC keys for the bearer tokens of JWT,
+// the issuer and audience of the token are checked if not empty.
+// The caller is identified by the sub claim and the roles are taken from the roles claim.
+func JWTOption(keys [][]byte, issuer, audience string) Option {
+	return func(o *options) {
+		o.jwtKeys = keys
+		o.jwtIssuer = issuer
+		o.jwtAudience = audience
+	}
+}
+
+// TLSConfigOption serves the API over TLS,
+// the client is authenticated by the certificate if the client CAs are set.
+func TLSConfigOption(tlsConfig *tls.Config) Option {
+	return func(o *options) {
+		o.tlsConfig = tlsConfig
+	}
+}
+
+// RolesOption sets the permissions of the roles,
+// the permissions of the caller are not checked if no role is set.
+func RolesOption(roles map[string][]string) Option {
+	return func(o *options) {
+		o.roles = roles
+	}
+}
+
+// UserRolesOption sets the roles of the callers authenticated by client certificate or basic auth.
+func UserRolesOption(users map[string][]string) Option {
+	return func(o *options) {
+		o.userRoles = users
+	}
+}
+
 type server struct {
 	s  *http.Server
 	ln net.Listener
@@ -52,6 +109,26 @@ func NewService(addr string, opts ...Option) (service.Service, error) {
 		opt(&options)
 	}
 
+	if options.tlsConfig != nil {
+		tlsConfig := options.tlsConfig.Clone()
+		if tlsConfig.ClientCAs != nil {
+			// the clients without certificate are authenticated by the other methods.
+			tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+			if verify := tlsConfig.VerifyPeerCertificate; verify != nil {
+				// the certificate is verified by the verifier with the reloaded CAs instead of ClientCAs.
+				tlsConfig.ClientAuth = tls.RequestClientCert
+				tlsConfig.VerifyPeerCertificate = func(rawCerts [][]byte, chains [][]*x509.Certificate) error {
+					if len(rawCerts) == 0 {
+						return nil
+					}
+					return verify(rawCerts, chains)
+				}
+			}
+		}
+		options.tlsConfig = tlsConfig
+		ln = tls.NewListener(ln, tlsConfig)
+	}
+
 	gin.SetMode(gin.ReleaseMode)
 
 	r := gin.New()
@@ -75,9 +152,22 @@ func NewService(addr string, opts ...Option) (service.Service, error) {
 	router.StaticFS("/docs", http.FS(swaggerDoc))
 
 	config := router.Group("/config")
-	config.Use(mwBasicAuth(options.auther))
+	config.Use(
+		mwAudit(),
+		mwAuth(&options),
+		mwPermission(options.roles),
+		mwConfigLock(),
+	)
 	registerConfig(config)
 
+	conns := router.Group("")
+	conns.Use(
+		mwAudit(),
+		mwAuth(&options),
+		mwPermission(options.roles),
+	)
+	registerConnection(conns)
+
 	return &server{
 		s: &http.Server{
 			Handler: r,
@@ -101,48 +191,84 @@ func (s *server) Close() error {
 func registerConfig(config *gin.RouterGroup) {
 	config.GET("", getConfig)
 	config.POST("", saveConfig)
+	config.POST("/reload", reloadConfig)
+	config.POST("/validate", validateConfig)
 
+	config.GET("/services", getServiceList)
+	config.GET("/services/:service", getService)
 	config.POST("/services", createService)
 	config.PUT("/services/:service", updateService)
 	config.DELETE("/services/:service", deleteService)
+	config.PUT("/services/:service/recorders", updateServiceRecorders)
 
+	config.GET("/chains", getChainList)
+	config.GET("/chains/:chain", getChain)
 	config.POST("/chains", createChain)
 	config.PUT("/chains/:chain", updateChain)
 	config.DELETE("/chains/:chain", deleteChain)
 
+	config.GET("/hops", getHopList)
+	config.GET("/hops/:hop", getHop)
 	config.POST("/hops", createHop)
 	config.PUT("/hops/:hop", updateHop)
 	config.DELETE("/hops/:hop", deleteHop)
 
+	config.GET("/authers", getAutherList)
+	config.GET("/authers/:auther", getAuther)
 	config.POST("/authers", createAuther)
 	config.PUT("/authers/:auther", updateAuther)
 	config.DELETE("/authers/:auther", deleteAuther)
 
+	config.GET("/admissions", getAdmissionList)
+	config.GET("/admissions/:admission", getAdmission)
 	config.POST("/admissions", createAdmission)
 	config.PUT("/admissions/:admission", updateAdmission)
 	config.DELETE("/admissions/:admission", deleteAdmission)
 
+	config.GET("/bypasses", getBypassList)
+	config.GET("/bypasses/:bypass", getBypass)
 	config.POST("/bypasses", createBypass)
 	config.PUT("/bypasses/:bypass", updateBypass)
 	config.DELETE("/bypasses/:bypass", deleteBypass)
 
+	config.GET("/resolvers", getResolverList)
+	config.GET("/resolvers/:resolver", getResolver)
 	config.POST("/resolvers", createResolver)
 	config.PUT("/resolvers/:resolver", updateResolver)
 	config.DELETE("/resolvers/:resolver", deleteResolver)
 
+	config.GET("/hosts", getHostsList)
+	config.GET("/hosts/:hosts", getHosts)
 	config.POST("/hosts", createHosts)
 	config.PUT("/hosts/:hosts", updateHosts)
 	config.DELETE("/hosts/:hosts", deleteHosts)
 
+	config.GET("/limiters", getLimiterList)
+	config.GET("/limiters/:limiter", getLimiter)
 	config.POST("/limiters", createLimiter)
 	config.PUT("/limiters/:limiter", updateLimiter)
 	config.DELETE("/limiters/:limiter", deleteLimiter)
 
+	config.GET("/climiters", getConnLimiterList)
+	config.GET("/climiters/:limiter", getConnLimiter)
 	config.POST("/climiters", createConnLimiter)
 	config.PUT("/climiters/:limiter", updateConnLimiter)
 	config.DELETE("/climiters/:limiter", deleteConnLimiter)
 
+	config.GET("/rlimiters", getRateLimiterList)
+	config.GET("/rlimiters/:limiter", getRateLimiter)
 	config.POST("/rlimiters", createRateLimiter)
 	config.PUT("/rlimiters/:limiter", updateRateLimiter)
 	config.DELETE("/rlimiters/:limiter", deleteRateLimiter)
+
+	config.GET("/recorders", getRecorderList)
+	config.GET("/recorders/:recorder", getRecorder)
+	config.POST("/recorders", createRecorder)
+	config.PUT("/recorders/:recorder", updateRecorder)
+	config.DELETE("/recorders/:recorder", deleteRecorder)
+}
+
+func registerConnection(router *gin.RouterGroup) {
+	router.GET("/services/:service/connections", getConnectionList)
+	router.DELETE("/connections/:id", deleteConnection)
 }
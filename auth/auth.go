@@ -60,6 +60,14 @@ func LoggerOption(logger logger.Logger) Option {
 	}
 }
 
+// Lister is implemented by the Authenticators which can list their users.
+// It is used to match the authenticated clients with the users of the user routes,
+// and required by the protocols sending only a hash of the password, such as Trojan.
+type Lister interface {
+	// Users returns the user-password pairs.
+	Users() map[string]string
+}
+
 // authenticator is an Authenticator that authenticates client by key-value pairs.
 type authenticator struct {
 	kvs        map[string]string
@@ -105,6 +113,22 @@ func (p *authenticator) Authenticate(user, password string) bool {
 	return ok && (v == "" || password == v)
 }
 
+// Users implements Lister.
+func (p *authenticator) Users() map[string]string {
+	if p == nil {
+		return nil
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	m := make(map[string]string, len(p.kvs))
+	for k, v := range p.kvs {
+		m[k] = v
+	}
+	return m
+}
+
 func (p *authenticator) periodReload(ctx context.Context) error {
 	period := p.options.period
 	if period < time.Second {
@@ -243,3 +267,42 @@ func (p *authenticator) Close() error {
 	}
 	return nil
 }
+
+type authenticatorGroup struct {
+	authers []auth.Authenticator
+}
+
+// AuthenticatorGroup is the same as auth.AuthenticatorGroup,
+// and it also lists the users of the authers implementing Lister.
+func AuthenticatorGroup(authers ...auth.Authenticator) auth.Authenticator {
+	return &authenticatorGroup{
+		authers: authers,
+	}
+}
+
+func (p *authenticatorGroup) Authenticate(user, password string) bool {
+	if len(p.authers) == 0 {
+		return true
+	}
+	for _, auther := range p.authers {
+		if auther != nil && auther.Authenticate(user, password) {
+			return true
+		}
+	}
+	return false
+}
+
+// Users implements Lister, the former auther wins for the duplicate users.
+func (p *authenticatorGroup) Users() map[string]string {
+	m := make(map[string]string)
+	for i := len(p.authers) - 1; i >= 0; i-- {
+		lister, ok := p.authers[i].(Lister)
+		if !ok {
+			continue
+		}
+		for k, v := range lister.Users() {
+			m[k] = v
+		}
+	}
+	return m
+}
@@ -15,6 +15,20 @@ import (
 	"github.com/go-gost/x/internal/matcher"
 )
 
+// ValidateMatcher checks the matcher pattern of the bypass,
+// which is a host pattern, a host:port pattern or an extended rule.
+func ValidateMatcher(pattern string) error {
+	if isRule(pattern) {
+		_, err := parseRule(pattern)
+		return err
+	}
+	if host, port, ok := matcher.SplitHostPort(pattern); ok {
+		_, err := hostPortMatcher(host, port)
+		return err
+	}
+	return checkWildcards([]string{pattern}, false)
+}
+
 type options struct {
 	whitelist   bool
 	matchers    []string
@@ -69,11 +83,20 @@ func LoggerOption(logger logger.Logger) Option {
 	}
 }
 
+// Lister is implemented by a Bypass which can report its patterns in use.
+type Lister interface {
+	// Patterns returns the current patterns and whether they are used as a whitelist.
+	Patterns() (patterns []string, whitelist bool)
+}
+
 type bypass struct {
+	patterns        []string
 	ipMatcher       matcher.Matcher
 	cidrMatcher     matcher.Matcher
 	domainMatcher   matcher.Matcher
 	wildcardMatcher matcher.Matcher
+	portMatchers    []matcher.Matcher
+	rules           []*rule
 	cancelFunc      context.CancelFunc
 	options         options
 	mu              sync.RWMutex
@@ -130,13 +153,33 @@ func (bp *bypass) reload(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
-	patterns := append(bp.options.matchers, v...)
+	patterns := append(bp.options.matchers[:len(bp.options.matchers):len(bp.options.matchers)], v...)
 
 	var ips []net.IP
 	var inets []*net.IPNet
 	var domains []string
 	var wildcards []string
+	var portMatchers []matcher.Matcher
+	var rules []*rule
 	for _, pattern := range patterns {
+		if isRule(pattern) {
+			r, err := parseRule(pattern)
+			if err != nil {
+				bp.options.logger.Warn(err)
+				continue
+			}
+			rules = append(rules, r)
+			continue
+		}
+		if host, port, ok := matcher.SplitHostPort(pattern); ok {
+			m, err := hostPortMatcher(host, port)
+			if err != nil {
+				bp.options.logger.Warnf("%s: %v", pattern, err)
+				continue
+			}
+			portMatchers = append(portMatchers, m)
+			continue
+		}
 		if ip := net.ParseIP(pattern); ip != nil {
 			ips = append(ips, ip)
 			continue
@@ -146,6 +189,10 @@ func (bp *bypass) reload(ctx context.Context) error {
 			continue
 		}
 		if strings.ContainsAny(pattern, "*?") {
+			if err := checkWildcards([]string{pattern}, false); err != nil {
+				bp.options.logger.Warn(err)
+				continue
+			}
 			wildcards = append(wildcards, pattern)
 			continue
 		}
@@ -155,10 +202,13 @@ func (bp *bypass) reload(ctx context.Context) error {
 	bp.mu.Lock()
 	defer bp.mu.Unlock()
 
+	bp.patterns = patterns
 	bp.ipMatcher = matcher.IPMatcher(ips)
 	bp.cidrMatcher = matcher.CIDRMatcher(inets)
 	bp.domainMatcher = matcher.DomainMatcher(domains)
 	bp.wildcardMatcher = matcher.WildcardMatcher(wildcards)
+	bp.portMatchers = portMatchers
+	bp.rules = rules
 
 	return nil
 }
@@ -233,25 +283,41 @@ func (bp *bypass) parsePatterns(r io.Reader) (patterns []string, err error) {
 }
 
 func (bp *bypass) Contains(addr string) bool {
-	if addr == "" || bp == nil {
+	return bp.ContainsRequest(&Request{Addr: addr})
+}
+
+// ContainsRequest implements RequestBypass.
+func (bp *bypass) ContainsRequest(req *Request) bool {
+	if bp == nil || req == nil || req.Addr == "" {
 		return false
 	}
 
+	addr := req.Addr
 	// try to strip the port
 	if host, _, _ := net.SplitHostPort(addr); host != "" {
 		addr = host
 	}
 
-	matched := bp.matched(addr)
+	matched := bp.matched(addr) ||
+		bp.matchedPorts(req.Addr) ||
+		bp.matchedRules(req)
 
 	b := !bp.options.whitelist && matched ||
 		bp.options.whitelist && !matched
 	if b {
-		bp.options.logger.Debugf("bypass: %s", addr)
+		bp.options.logger.Debugf("bypass: %s", req.Addr)
 	}
 	return b
 }
 
+// Patterns implements Lister.
+func (bp *bypass) Patterns() ([]string, bool) {
+	bp.mu.RLock()
+	defer bp.mu.RUnlock()
+
+	return bp.patterns, bp.options.whitelist
+}
+
 func (bp *bypass) parseLine(s string) string {
 	if n := strings.IndexByte(s, '#'); n >= 0 {
 		s = s[:n]
@@ -272,6 +338,30 @@ func (bp *bypass) matched(addr string) bool {
 		bp.wildcardMatcher.Match(addr)
 }
 
+func (bp *bypass) matchedPorts(addr string) bool {
+	bp.mu.RLock()
+	defer bp.mu.RUnlock()
+
+	for _, m := range bp.portMatchers {
+		if m.Match(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func (bp *bypass) matchedRules(req *Request) bool {
+	bp.mu.RLock()
+	defer bp.mu.RUnlock()
+
+	for _, r := range bp.rules {
+		if r.Match(req) {
+			return true
+		}
+	}
+	return false
+}
+
 func (bp *bypass) Close() error {
 	bp.cancelFunc()
 	if bp.options.fileLoader != nil {
@@ -0,0 +1,54 @@
+package bypass
+
+import (
+	bypass_pkg "github.com/go-gost/core/bypass"
+)
+
+type bypassGroup struct {
+	bypasses []bypass_pkg.Bypass
+}
+
+// BypassGroup is the same as the bypass group in core,
+// but it also keeps the members which can be iterated by Bypasses.
+func BypassGroup(bypasses ...bypass_pkg.Bypass) bypass_pkg.Bypass {
+	return &bypassGroup{
+		bypasses: bypasses,
+	}
+}
+
+func (p *bypassGroup) Contains(addr string) bool {
+	for _, bypass := range p.bypasses {
+		if bypass != nil && bypass.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *bypassGroup) ContainsRequest(req *Request) bool {
+	for _, bypass := range p.bypasses {
+		if bypass != nil && Contains(bypass, req) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *bypassGroup) Bypasses() []bypass_pkg.Bypass {
+	return p.bypasses
+}
+
+// Bypasses returns the members of bp if it is a group, otherwise bp itself.
+func Bypasses(bp bypass_pkg.Bypass) []bypass_pkg.Bypass {
+	if bp == nil {
+		return nil
+	}
+	if g, ok := bp.(interface{ Bypasses() []bypass_pkg.Bypass }); ok {
+		var bypasses []bypass_pkg.Bypass
+		for _, v := range g.Bypasses() {
+			bypasses = append(bypasses, Bypasses(v)...)
+		}
+		return bypasses
+	}
+	return []bypass_pkg.Bypass{bp}
+}
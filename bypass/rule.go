@@ -0,0 +1,285 @@
+package bypass
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	bypass_pkg "github.com/go-gost/core/bypass"
+	"github.com/go-gost/x/internal/matcher"
+)
+
+// Request is the extended input of the bypass matching.
+type Request struct {
+	// Network is the network of the destination, tcp or udp.
+	Network string
+	// Addr is the destination address in the form of host:port or host.
+	Addr string
+	// ClientIP is the IP address of the client.
+	ClientIP string
+	// User is the authenticated user of the client.
+	User string
+	// Method and Path are the method and URL path of the HTTP request.
+	Method string
+	Path   string
+}
+
+// RequestBypass is implemented by a Bypass which supports the extended matching.
+type RequestBypass interface {
+	ContainsRequest(req *Request) bool
+}
+
+// Contains reports whether bp includes the destination described by req.
+// The extended matching is used if bp supports it, otherwise only req.Addr is checked.
+func Contains(bp bypass_pkg.Bypass, req *Request) bool {
+	if bp == nil || req == nil {
+		return false
+	}
+	if rb, ok := bp.(RequestBypass); ok {
+		return rb.ContainsRequest(req)
+	}
+	return bp.Contains(req.Addr)
+}
+
+const (
+	ruleKeyNetwork = "network"
+	ruleKeyPort    = "port"
+	ruleKeyClient  = "client"
+	ruleKeyUser    = "user"
+	ruleKeyMethod  = "method"
+	ruleKeyPath    = "path"
+)
+
+// rule is an extended bypass pattern in the form of:
+//
+//	[!]host[:port] [!]key=value[,value...] ...
+//
+// The key is one of network, port, client, user, method and path.
+// The rule matches if the host and all the conditions match,
+// a leading '!' negates the host or the condition.
+// The host '*' matches any host.
+type rule struct {
+	host    matcher.Matcher
+	hostNot bool
+	conds   []ruleCond
+}
+
+type ruleCond struct {
+	key     string
+	not     bool
+	matcher matcher.Matcher
+}
+
+// isRule reports whether the pattern is an extended rule rather than a plain host pattern.
+func isRule(pattern string) bool {
+	return strings.HasPrefix(pattern, "!") || len(strings.Fields(pattern)) > 1
+}
+
+func parseRule(pattern string) (*rule, error) {
+	fields := strings.Fields(pattern)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty rule")
+	}
+
+	r := &rule{}
+	host := fields[0]
+	if strings.HasPrefix(host, "!") {
+		r.hostNot = true
+		host = host[1:]
+	}
+	if h, port, ok := matcher.SplitHostPort(host); ok {
+		m, err := matcher.PortMatcher(strings.Split(port, ","))
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %v", pattern, err)
+		}
+		r.conds = append(r.conds, ruleCond{key: ruleKeyPort, matcher: m})
+		host = h
+	}
+	if host != "*" && host != "" {
+		if err := checkWildcards([]string{host}, false); err != nil {
+			return nil, fmt.Errorf("rule %s: %v", pattern, err)
+		}
+		r.host = hostMatcher([]string{host})
+	}
+
+	for _, field := range fields[1:] {
+		k, v, ok := strings.Cut(field, "=")
+		if !ok || v == "" {
+			return nil, fmt.Errorf("invalid condition %s in rule %s", field, pattern)
+		}
+		cond := ruleCond{key: k}
+		if strings.HasPrefix(k, "!") {
+			cond.not = true
+			cond.key = k[1:]
+		}
+		values := strings.Split(v, ",")
+		switch cond.key {
+		case ruleKeyNetwork, ruleKeyUser:
+			cond.matcher = listMatcher(values, false)
+		case ruleKeyMethod:
+			cond.matcher = listMatcher(values, true)
+		case ruleKeyPort:
+			m, err := matcher.PortMatcher(values)
+			if err != nil {
+				return nil, fmt.Errorf("rule %s: %v", pattern, err)
+			}
+			cond.matcher = m
+		case ruleKeyClient:
+			if err := checkWildcards(values, false); err != nil {
+				return nil, fmt.Errorf("rule %s: %v", pattern, err)
+			}
+			cond.matcher = hostMatcher(values)
+		case ruleKeyPath:
+			if err := checkWildcards(values, true); err != nil {
+				return nil, fmt.Errorf("rule %s: %v", pattern, err)
+			}
+			cond.matcher = matcher.WildcardMatcher(values)
+		default:
+			return nil, fmt.Errorf("unknown condition %s in rule %s", cond.key, pattern)
+		}
+		r.conds = append(r.conds, cond)
+	}
+
+	return r, nil
+}
+
+func (r *rule) Match(req *Request) bool {
+	host, port := req.Addr, ""
+	if h, p, _ := net.SplitHostPort(req.Addr); h != "" {
+		host, port = h, p
+	}
+
+	if r.host != nil && r.host.Match(host) == r.hostNot {
+		return false
+	}
+
+	for _, cond := range r.conds {
+		var v string
+		switch cond.key {
+		case ruleKeyNetwork:
+			v = req.Network
+			// tcp4, udp6 etc.
+			if len(v) > 3 {
+				v = v[:3]
+			}
+		case ruleKeyPort:
+			v = port
+		case ruleKeyClient:
+			v = req.ClientIP
+		case ruleKeyUser:
+			v = req.User
+		case ruleKeyMethod:
+			v = strings.ToUpper(req.Method)
+		case ruleKeyPath:
+			v = req.Path
+		}
+		if (v != "" && cond.matcher.Match(v)) == cond.not {
+			return false
+		}
+	}
+	return true
+}
+
+// hostPortMatcher creates a Matcher for the host:port pattern split by matcher.SplitHostPort.
+func hostPortMatcher(host, port string) (matcher.Matcher, error) {
+	pm, err := matcher.PortMatcher(strings.Split(port, ","))
+	if err != nil {
+		return nil, err
+	}
+	var hm matcher.Matcher
+	if host != "" && host != "*" {
+		if err := checkWildcards([]string{host}, false); err != nil {
+			return nil, err
+		}
+		hm = hostMatcher([]string{host})
+	}
+	return matcher.HostPortMatcher(hm, pm), nil
+}
+
+type hostsMatcher struct {
+	ipMatcher       matcher.Matcher
+	cidrMatcher     matcher.Matcher
+	domainMatcher   matcher.Matcher
+	wildcardMatcher matcher.Matcher
+}
+
+// checkWildcards checks the wildcard patterns in the list,
+// a pattern is a wildcard if all is true or it contains '*' or '?'.
+func checkWildcards(patterns []string, all bool) error {
+	for _, pattern := range patterns {
+		if !all && !strings.ContainsAny(pattern, "*?") {
+			continue
+		}
+		if err := matcher.CheckWildcard(pattern); err != nil {
+			return fmt.Errorf("invalid wildcard %s: %v", pattern, err)
+		}
+	}
+	return nil
+}
+
+// hostMatcher creates a Matcher for a list of IP, CIDR, domain and wildcard patterns.
+func hostMatcher(patterns []string) matcher.Matcher {
+	var ips []net.IP
+	var inets []*net.IPNet
+	var domains []string
+	var wildcards []string
+	for _, pattern := range patterns {
+		if ip := net.ParseIP(pattern); ip != nil {
+			ips = append(ips, ip)
+			continue
+		}
+		if _, inet, err := net.ParseCIDR(pattern); err == nil {
+			inets = append(inets, inet)
+			continue
+		}
+		if strings.ContainsAny(pattern, "*?") {
+			wildcards = append(wildcards, pattern)
+			continue
+		}
+		domains = append(domains, pattern)
+	}
+
+	return &hostsMatcher{
+		ipMatcher:       matcher.IPMatcher(ips),
+		cidrMatcher:     matcher.CIDRMatcher(inets),
+		domainMatcher:   matcher.DomainMatcher(domains),
+		wildcardMatcher: matcher.WildcardMatcher(wildcards),
+	}
+}
+
+func (m *hostsMatcher) Match(host string) bool {
+	if ip := net.ParseIP(host); ip != nil {
+		return m.ipMatcher.Match(host) ||
+			m.cidrMatcher.Match(host)
+	}
+
+	return m.domainMatcher.Match(host) ||
+		m.wildcardMatcher.Match(host)
+}
+
+type stringListMatcher struct {
+	values     map[string]struct{}
+	ignoreCase bool
+}
+
+func listMatcher(values []string, ignoreCase bool) matcher.Matcher {
+	m := &stringListMatcher{
+		values:     make(map[string]struct{}),
+		ignoreCase: ignoreCase,
+	}
+	for _, v := range values {
+		if ignoreCase {
+			v = strings.ToUpper(v)
+		}
+		m.values[v] = struct{}{}
+	}
+	return m
+}
+
+func (m *stringListMatcher) Match(v string) bool {
+	if m.ignoreCase {
+		v = strings.ToUpper(v)
+	}
+	_, ok := m.values[v]
+	return ok
+}
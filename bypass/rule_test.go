@@ -0,0 +1,205 @@
+package bypass
+
+import (
+	"testing"
+
+	xlogger "github.com/go-gost/x/logger"
+)
+
+func TestValidateMatcher(t *testing.T) {
+	tests := []struct {
+		pattern string
+		valid   bool
+	}{
+		{pattern: "example.com", valid: true},
+		{pattern: "*.example.com", valid: true},
+		{pattern: "10.0.0.0/8", valid: true},
+		{pattern: "2001:db8::1", valid: true},
+		{pattern: "*.example.com:8000-9000", valid: true},
+		{pattern: "[2001:db8::/32]:22,80", valid: true},
+		{pattern: ":25", valid: true},
+		{pattern: "example.com:9000-8000"},
+		{pattern: "*.[a-"},
+		{pattern: "* network=udp", valid: true},
+		{pattern: "!*.example.com user=alice,bob", valid: true},
+		{pattern: "example.com:443 !client=10.0.0.0/8 method=GET path=/api/*", valid: true},
+		{pattern: "* port=53,5000-6000", valid: true},
+		{pattern: "* port=http"},
+		{pattern: "* user"},
+		{pattern: "* user="},
+		{pattern: "* country=cn"},
+		{pattern: "* path=[a-"},
+		{pattern: "* client=*.[a-"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			if err := ValidateMatcher(tt.pattern); (err == nil) != tt.valid {
+				t.Errorf("got error %v, want valid %v", err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestRuleMatch(t *testing.T) {
+	tests := []struct {
+		name string
+		rule string
+		req  Request
+		want bool
+	}{
+		{
+			name: "any host",
+			rule: "* network=udp",
+			req:  Request{Network: "udp", Addr: "example.com:53"},
+			want: true,
+		},
+		{
+			name: "network with the IP version",
+			rule: "* network=udp",
+			req:  Request{Network: "udp6", Addr: "example.com:53"},
+			want: true,
+		},
+		{
+			name: "network not matched",
+			rule: "* network=udp",
+			req:  Request{Network: "tcp", Addr: "example.com:53"},
+		},
+		{
+			name: "host and port",
+			rule: "*.example.com:80,443 user=alice",
+			req:  Request{Addr: "www.example.com:443", User: "alice"},
+			want: true,
+		},
+		{
+			name: "port not matched",
+			rule: "*.example.com:80,443 user=alice",
+			req:  Request{Addr: "www.example.com:8080", User: "alice"},
+		},
+		{
+			name: "negated host",
+			rule: "!*.example.com user=alice",
+			req:  Request{Addr: "example.org:80", User: "alice"},
+			want: true,
+		},
+		{
+			name: "negated host matched",
+			rule: "!*.example.com user=alice",
+			req:  Request{Addr: "www.example.com:80", User: "alice"},
+		},
+		{
+			name: "negated condition",
+			rule: "* !client=10.0.0.0/8",
+			req:  Request{Addr: "example.com:80", ClientIP: "192.168.1.1"},
+			want: true,
+		},
+		{
+			name: "negated condition matched",
+			rule: "* !client=10.0.0.0/8",
+			req:  Request{Addr: "example.com:80", ClientIP: "10.1.2.3"},
+		},
+		{
+			name: "missing value",
+			rule: "* user=alice",
+			req:  Request{Addr: "example.com:80"},
+		},
+		{
+			name: "negated condition with missing value",
+			rule: "* !user=alice",
+			req:  Request{Addr: "example.com:80"},
+			want: true,
+		},
+		{
+			name: "method ignores case",
+			rule: "* method=get,head path=/api/*",
+			req:  Request{Addr: "example.com:80", Method: "GET", Path: "/api/v1"},
+			want: true,
+		},
+		{
+			name: "path not matched",
+			rule: "* method=get,head path=/api/*",
+			req:  Request{Addr: "example.com:80", Method: "GET", Path: "/static/app.js"},
+		},
+		{
+			name: "port range",
+			rule: "* port=5000-6000",
+			req:  Request{Addr: "1.2.3.4:5353"},
+			want: true,
+		},
+		{
+			name: "address without port",
+			rule: "example.com port=80",
+			req:  Request{Addr: "example.com"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := parseRule(tt.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := r.Match(&tt.req); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContainsRequest(t *testing.T) {
+	bp := NewBypass(
+		MatchersOption([]string{
+			"example.com",
+			"*.example.org:443",
+			"* network=udp port=53",
+			"* user=guest",
+			// the invalid patterns are skipped.
+			"* country=cn",
+			"example.net:9000-8000",
+		}),
+		LoggerOption(xlogger.Nop()),
+	)
+
+	tests := []struct {
+		name string
+		req  *Request
+		want bool
+	}{
+		{name: "nil request"},
+		{name: "host", req: &Request{Addr: "example.com:80"}, want: true},
+		{name: "host and port", req: &Request{Addr: "www.example.org:443"}, want: true},
+		{name: "port not matched", req: &Request{Addr: "www.example.org:80"}},
+		{name: "rule", req: &Request{Network: "udp", Addr: "1.1.1.1:53"}, want: true},
+		{name: "rule not matched", req: &Request{Network: "tcp", Addr: "1.1.1.1:53"}},
+		{name: "user", req: &Request{Addr: "example.net:80", User: "guest"}, want: true},
+		{name: "invalid pattern", req: &Request{Addr: "example.net:8500"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Contains(bp, tt.req); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// only the address is checked by Contains.
+	if bp.Contains("1.1.1.1:53") {
+		t.Error("the rule with the network is matched without the network")
+	}
+}
+
+func TestContainsRequestWhitelist(t *testing.T) {
+	bp := NewBypass(
+		MatchersOption([]string{"*.example.com", "* user=admin"}),
+		WhitelistOption(true),
+		LoggerOption(xlogger.Nop()),
+	)
+
+	if Contains(bp, &Request{Addr: "www.example.com:80"}) {
+		t.Error("the whitelisted host is bypassed")
+	}
+	if Contains(bp, &Request{Addr: "example.org:80", User: "admin"}) {
+		t.Error("the whitelisted user is bypassed")
+	}
+	if !Contains(bp, &Request{Addr: "example.org:80", User: "guest"}) {
+		t.Error("the host not whitelisted is not bypassed")
+	}
+}
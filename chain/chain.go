@@ -7,6 +7,7 @@ import (
 	"github.com/go-gost/core/logger"
 	"github.com/go-gost/core/metadata"
 	"github.com/go-gost/core/selector"
+	xctx "github.com/go-gost/x/ctx"
 )
 
 var (
@@ -83,11 +84,17 @@ func (c *Chain) Route(ctx context.Context, network, address string) chain.Route
 		return nil
 	}
 
+	info := xctx.ConnInfoFromContext(ctx)
+	var nodes []string
+
 	rt := NewRoute(ChainRouteOption(c))
 	for _, hop := range c.hops {
 		node := hop.Select(ctx, chain.AddrSelectOption(address))
 		if node == nil {
-			return rt
+			break
+		}
+		if info != nil {
+			nodes = append(nodes, node.Name+"@"+node.Addr)
 		}
 		if node.Options().Transport.Multiplex() {
 			tr := node.Options().Transport.Copy()
@@ -99,6 +106,10 @@ func (c *Chain) Route(ctx context.Context, network, address string) chain.Route
 
 		rt.addNode(node)
 	}
+
+	if info != nil {
+		info.SetRoute(c.name, nodes)
+	}
 	return rt
 }
 
@@ -117,6 +128,10 @@ func (p *chainGroup) WithSelector(s selector.Selector[chain.Chainer]) *chainGrou
 }
 
 func (p *chainGroup) Route(ctx context.Context, network, address string) chain.Route {
+	if info := xctx.ConnInfoFromContext(ctx); info != nil && address != "" {
+		info.SetDst(network, address, string(xctx.ClientIDFromContext(ctx)))
+	}
+
 	if chain := p.next(ctx); chain != nil {
 		return chain.Route(ctx, network, address)
 	}
@@ -0,0 +1,80 @@
+package chain
+
+import (
+	"context"
+	"net"
+
+	"github.com/go-gost/core/chain"
+	"github.com/go-gost/core/common/net/dialer"
+	"github.com/go-gost/core/logger"
+	xctx "github.com/go-gost/x/ctx"
+	xnet "github.com/go-gost/x/internal/net"
+	metrics "github.com/go-gost/x/metrics/wrapper"
+)
+
+func init() {
+	chain.DefaultRoute = &directRoute{Route: chain.DefaultRoute}
+}
+
+// directRoute is the route without nodes,
+// it dials all the addresses of the domain name with the Happy Eyeballs algorithm.
+type directRoute struct {
+	chain.Route
+}
+
+func (rt *directRoute) Dial(ctx context.Context, network, address string, opts ...chain.DialOption) (net.Conn, error) {
+	var options chain.DialOptions
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&options)
+		}
+	}
+
+	netd := dialer.NetDialer{
+		Timeout:   options.Timeout,
+		Interface: options.Interface,
+		Logger:    options.Logger,
+	}
+	if netd.Timeout <= 0 {
+		// NetDialer.Dial sets the default timeout, which races in the parallel attempts.
+		netd.Timeout = dialer.DefaultTimeout
+	}
+	if options.SockOpts != nil {
+		netd.Mark = options.SockOpts.Mark
+	}
+
+	log := options.Logger
+	if log == nil {
+		log = logger.Default()
+	}
+
+	conn, err := rt.dial(ctx, network, address, netd.Dial, log)
+	if err != nil {
+		return nil, err
+	}
+	return metrics.WrapClientConn(xctx.ConnInfoFromContext(ctx), conn), nil
+}
+
+// dial resolves the address by the resolver of the service in ctx,
+// or by the system resolver if the service has no resolver.
+func (*directRoute) dial(ctx context.Context, network, address string, dial xnet.DialFunc, log logger.Logger) (net.Conn, error) {
+	r := xctx.ResolverFromContext(ctx)
+	if r == nil {
+		return xnet.DialHappyEyeballs(ctx, network, address, dial)
+	}
+
+	ipNetwork := xnet.IPNetwork(network)
+	if ipNetwork == "" {
+		addr, err := chain.Resolve(ctx, "ip", address, r, nil, log)
+		if err != nil {
+			return nil, err
+		}
+		return dial(ctx, network, addr)
+	}
+
+	addrs, err := resolveAll(ctx, ipNetwork, []string{address}, r, nil, log)
+	if err != nil {
+		return nil, err
+	}
+	return xnet.DialParallel(ctx, network, addrs, dial)
+}
@@ -0,0 +1,105 @@
+package chain
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/go-gost/core/chain"
+	"github.com/go-gost/core/resolver"
+	xctx "github.com/go-gost/x/ctx"
+)
+
+type fakeResolver struct {
+	ips []net.IP
+	err error
+}
+
+func (r *fakeResolver) Resolve(ctx context.Context, network, host string) ([]net.IP, error) {
+	return r.ips, r.err
+}
+
+func TestDirectRouteDial(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	_, port, _ := net.SplitHostPort(ln.Addr().String())
+
+	tests := []struct {
+		name     string
+		resolver *fakeResolver
+		addr     string
+		ok       bool
+	}{
+		{
+			name:     "fallback to the second address",
+			resolver: &fakeResolver{ips: []net.IP{net.ParseIP("127.0.0.2"), net.ParseIP("127.0.0.1")}},
+			addr:     "example.com:" + port,
+			ok:       true,
+		},
+		{
+			name:     "no address",
+			resolver: &fakeResolver{},
+			addr:     "example.com:" + port,
+		},
+		{
+			name:     "invalid resolver",
+			resolver: &fakeResolver{err: resolver.ErrInvalid},
+			addr:     "127.0.0.1:" + port,
+			ok:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := xctx.ContextWithResolver(context.Background(), tt.resolver)
+			conn, err := chain.DefaultRoute.Dial(ctx, "tcp", tt.addr)
+			if tt.ok != (err == nil) {
+				t.Fatalf("got error %v, want ok %v", err, tt.ok)
+			}
+			if conn != nil {
+				if got := conn.RemoteAddr().String(); got != ln.Addr().String() {
+					t.Errorf("got connection to %s, want %s", got, ln.Addr())
+				}
+				conn.Close()
+			}
+		})
+	}
+}
+
+func TestNodeAddrs(t *testing.T) {
+	tests := []struct {
+		name  string
+		addr  string
+		addrs []string
+		want  []string
+	}{
+		{name: "no fallback", addr: "a:1", want: []string{"a:1"}},
+		{name: "fallbacks", addr: "a:1", addrs: []string{"b:2", "c:3"}, want: []string{"a:1", "b:2", "c:3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := chain.NewNode("node", tt.addr,
+				chain.MetadataNodeOption(FallbackAddrsMetadata(nil, tt.addrs...)))
+			got := nodeAddrs(node)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
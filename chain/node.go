@@ -0,0 +1,37 @@
+package chain
+
+import (
+	"github.com/go-gost/core/chain"
+	"github.com/go-gost/core/metadata"
+	mdx "github.com/go-gost/x/metadata"
+)
+
+// addrsMetadata is the metadata of the node carrying the fallback addresses of the node.
+type addrsMetadata struct {
+	metadata.Metadata
+	addrs []string
+}
+
+// FallbackAddrsMetadata attaches the fallback addresses to the metadata of the node,
+// they are dialed after the node address with the Happy Eyeballs algorithm.
+func FallbackAddrsMetadata(md metadata.Metadata, addrs ...string) metadata.Metadata {
+	if len(addrs) == 0 {
+		return md
+	}
+	if md == nil {
+		md = mdx.NewMetadata(map[string]any{})
+	}
+	return &addrsMetadata{
+		Metadata: md,
+		addrs:    addrs,
+	}
+}
+
+// nodeAddrs returns the address of the node followed by its fallback addresses.
+func nodeAddrs(node *chain.Node) []string {
+	addrs := []string{node.Addr}
+	if md, _ := node.Metadata().(*addrsMetadata); md != nil {
+		addrs = append(addrs, md.addrs...)
+	}
+	return addrs
+}
@@ -0,0 +1,76 @@
+package chain
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/go-gost/core/hosts"
+	"github.com/go-gost/core/logger"
+	"github.com/go-gost/core/resolver"
+	xnet "github.com/go-gost/x/internal/net"
+)
+
+// resolveAll resolves the addresses to all their addresses.
+// The resolved addresses of each address are interleaved by family as RFC 8305,
+// and follow the ones of the previous address in order.
+func resolveAll(ctx context.Context, network string, entries []string, r resolver.Resolver, hosts hosts.HostMapper, log logger.Logger) ([]string, error) {
+	var addrs []string
+	var firstErr error
+	for _, entry := range entries {
+		ips, err := resolveEntry(ctx, network, entry, r, hosts, log)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		addrs = append(addrs, xnet.SortAddrs(ips)...)
+	}
+	if len(addrs) == 0 {
+		return nil, firstErr
+	}
+	return addrs, nil
+}
+
+func resolveEntry(ctx context.Context, network, addr string, r resolver.Resolver, hosts hosts.HostMapper, log logger.Logger) ([]string, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	if host == "" || net.ParseIP(host) != nil {
+		return []string{addr}, nil
+	}
+
+	if hosts != nil {
+		if ips, _ := hosts.Lookup(network, host); len(ips) > 0 {
+			log.Debugf("hit host mapper: %s -> %s", host, ips)
+			return joinHostPort(ips, port), nil
+		}
+	}
+
+	if r != nil {
+		ips, err := r.Resolve(ctx, network, host)
+		if err != nil {
+			if err == resolver.ErrInvalid {
+				return []string{addr}, nil
+			}
+			log.Error(err)
+		}
+		if len(ips) == 0 {
+			return nil, fmt.Errorf("resolver: domain %s does not exist", host)
+		}
+		return joinHostPort(ips, port), nil
+	}
+
+	// the domain name is resolved by the dialer.
+	return []string{addr}, nil
+}
+
+func joinHostPort(ips []net.IP, port string) []string {
+	addrs := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		addrs = append(addrs, net.JoinHostPort(ip.String(), port))
+	}
+	return addrs
+}
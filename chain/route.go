@@ -10,7 +10,10 @@ import (
 	"github.com/go-gost/core/logger"
 	"github.com/go-gost/core/metrics"
 	"github.com/go-gost/core/selector"
+	xctx "github.com/go-gost/x/ctx"
+	xnet "github.com/go-gost/x/internal/net"
 	xmetrics "github.com/go-gost/x/metrics"
+	metrics_wrapper "github.com/go-gost/x/metrics/wrapper"
 )
 
 type RouteOptions struct {
@@ -58,6 +61,12 @@ func (r *route) Dial(ctx context.Context, network, address string, opts ...chain
 			opt(&options)
 		}
 	}
+	// the destination address is resolved by the resolver of the service, as the router does not.
+	address, err := chain.Resolve(ctx, "ip", address, xctx.ResolverFromContext(ctx), nil, options.Logger)
+	if err != nil {
+		return nil, err
+	}
+
 	conn, err := r.connect(ctx, options.Logger)
 	if err != nil {
 		return nil, err
@@ -70,7 +79,7 @@ func (r *route) Dial(ctx context.Context, network, address string, opts ...chain
 		}
 		return nil, err
 	}
-	return cc, nil
+	return metrics_wrapper.WrapClientConn(xctx.ConnInfoFromContext(ctx), cc), nil
 }
 
 func (r *route) Bind(ctx context.Context, network, address string, opts ...chain.BindOption) (net.Listener, error) {
@@ -137,7 +146,7 @@ func (r *route) connect(ctx context.Context, logger logger.Logger) (conn net.Con
 		}
 	}()
 
-	addr, err := chain.Resolve(ctx, network, node.Addr, node.Options().Resolver, node.Options().HostMapper, logger)
+	addrs, err := resolveAll(ctx, network, nodeAddrs(node), node.Options().Resolver, node.Options().HostMapper, logger)
 	marker := node.Marker()
 	if err != nil {
 		if marker != nil {
@@ -147,7 +156,9 @@ func (r *route) connect(ctx context.Context, logger logger.Logger) (conn net.Con
 	}
 
 	start := time.Now()
-	cc, err := node.Options().Transport.Dial(ctx, addr)
+	cc, err := xnet.DialParallel(ctx, "", addrs, func(ctx context.Context, _, addr string) (net.Conn, error) {
+		return node.Options().Transport.Dial(ctx, addr)
+	})
 	if err != nil {
 		if marker != nil {
 			marker.Mark()
@@ -181,6 +192,7 @@ func (r *route) connect(ctx context.Context, logger logger.Logger) (conn net.Con
 	preNode := node
 	for _, node := range r.nodes[1:] {
 		marker := node.Marker()
+		var addr string
 		addr, err = chain.Resolve(ctx, network, node.Addr, node.Options().Resolver, node.Options().HostMapper, logger)
 		if err != nil {
 			cn.Close()
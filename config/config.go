@@ -1,8 +1,10 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
 	"io"
+	"path/filepath"
 	"sync"
 	"time"
 
@@ -42,6 +44,11 @@ func SetGlobal(c *Config) {
 	global = c
 }
 
+// File returns the path of the config file read by Load or ReadFile.
+func File() string {
+	return v.ConfigFileUsed()
+}
+
 type LogConfig struct {
 	Output string `yaml:",omitempty" json:"output,omitempty"`
 	Level  string `yaml:",omitempty" json:"level,omitempty"`
@@ -58,6 +65,42 @@ type APIConfig struct {
 	AccessLog  bool        `yaml:"accesslog,omitempty" json:"accesslog,omitempty"`
 	Auth       *AuthConfig `yaml:",omitempty" json:"auth,omitempty"`
 	Auther     string      `yaml:",omitempty" json:"auther,omitempty"`
+	// static bearer tokens.
+	Tokens []*APITokenConfig `yaml:",omitempty" json:"tokens,omitempty"`
+	// bearer tokens of HMAC-signed JWT.
+	JWT *APIJWTConfig `yaml:"jwt,omitempty" json:"jwt,omitempty"`
+	// the client certificates are verified by the CA file if specified.
+	TLS *TLSConfig `yaml:",omitempty" json:"tls,omitempty"`
+	// the permissions are not checked if no role is specified.
+	Roles []*APIRoleConfig `yaml:",omitempty" json:"roles,omitempty"`
+	// roles of the users authenticated by basic auth or client certificate.
+	Users []*APIUserConfig `yaml:",omitempty" json:"users,omitempty"`
+}
+
+type APITokenConfig struct {
+	// name of the caller identified by the token.
+	Name  string   `json:"name"`
+	Token string   `json:"token"`
+	Roles []string `yaml:",omitempty" json:"roles,omitempty"`
+}
+
+type APIJWTConfig struct {
+	// HMAC keys, the token signed by any of them is accepted.
+	Keys     []string `json:"keys"`
+	Issuer   string   `yaml:",omitempty" json:"issuer,omitempty"`
+	Audience string   `yaml:",omitempty" json:"audience,omitempty"`
+}
+
+type APIRoleConfig struct {
+	Name string `json:"name"`
+	// permissions of the role: read, save, reload, write:<object type> such as write:services, write:* and *.
+	Permissions []string `json:"permissions"`
+}
+
+type APIUserConfig struct {
+	// username of basic auth or common name of client certificate.
+	Name  string   `json:"name"`
+	Roles []string `json:"roles"`
 }
 
 type MetricsConfig struct {
@@ -72,12 +115,34 @@ type TLSConfig struct {
 	Secure     bool   `yaml:",omitempty" json:"secure,omitempty"`
 	ServerName string `yaml:"serverName,omitempty" json:"serverName,omitempty"`
 
+	// additional certificates for server, selected by SNI.
+	Certificates []*TLSCertificateConfig `yaml:",omitempty" json:"certificates,omitempty"`
+	// period for checking the changes of the certificate files.
+	Reload time.Duration `yaml:",omitempty" json:"reload,omitempty"`
+	// obtain the certificates from ACME server if no certificate is specified.
+	ACME *ACMEConfig `yaml:"acme,omitempty" json:"acme,omitempty"`
+
 	// for auto-generated default certificate.
 	Validity     time.Duration `yaml:",omitempty" json:"validity,omitempty"`
 	CommonName   string        `yaml:"commonName,omitempty" json:"commonName,omitempty"`
 	Organization string        `yaml:",omitempty" json:"organization,omitempty"`
 }
 
+type TLSCertificateConfig struct {
+	CertFile string `yaml:"certFile" json:"certFile"`
+	KeyFile  string `yaml:"keyFile" json:"keyFile"`
+}
+
+type ACMEConfig struct {
+	Domains []string `json:"domains"`
+	Email   string   `yaml:",omitempty" json:"email,omitempty"`
+	// ACME directory URL, default is Let's Encrypt.
+	Directory string `yaml:",omitempty" json:"directory,omitempty"`
+	CacheDir  string `yaml:"cacheDir,omitempty" json:"cacheDir,omitempty"`
+	// CA certificate of the ACME server.
+	CAFile string `yaml:"caFile,omitempty" json:"caFile,omitempty"`
+}
+
 type AutherConfig struct {
 	Name   string        `json:"name"`
 	Auths  []*AuthConfig `yaml:",omitempty" json:"auths"`
@@ -139,6 +204,18 @@ type HTTPLoader struct {
 	Timeout time.Duration `yaml:",omitempty" json:"timeout,omitempty"`
 }
 
+// BackendConfig is the remote storage of the whole config, only one of Redis and HTTP is used.
+type BackendConfig struct {
+	// the config is stored in the string value of the key.
+	Redis *RedisLoader `yaml:",omitempty" json:"redis,omitempty"`
+	// the config is got from and put to the URL.
+	HTTP *HTTPLoader `yaml:"http,omitempty" json:"http,omitempty"`
+	// period for polling the changes of the config.
+	Reload time.Duration `yaml:",omitempty" json:"reload,omitempty"`
+	// format of the config saved to the backend: yaml or json, default is yaml.
+	Format string `yaml:",omitempty" json:"format,omitempty"`
+}
+
 type NameserverConfig struct {
 	Addr     string        `json:"addr"`
 	Chain    string        `yaml:",omitempty" json:"chain,omitempty"`
@@ -214,15 +291,28 @@ type ListenerConfig struct {
 }
 
 type HandlerConfig struct {
-	Type       string            `json:"type"`
-	Retries    int               `yaml:",omitempty" json:"retries,omitempty"`
+	Type       string             `json:"type"`
+	Retries    int                `yaml:",omitempty" json:"retries,omitempty"`
+	Chain      string             `yaml:",omitempty" json:"chain,omitempty"`
+	ChainGroup *ChainGroupConfig  `yaml:"chainGroup,omitempty" json:"chainGroup,omitempty"`
+	Auther     string             `yaml:",omitempty" json:"auther,omitempty"`
+	Authers    []string           `yaml:",omitempty" json:"authers,omitempty"`
+	Auth       *AuthConfig        `yaml:",omitempty" json:"auth,omitempty"`
+	TLS        *TLSConfig         `yaml:",omitempty" json:"tls,omitempty"`
+	Routes     []*UserRouteConfig `yaml:",omitempty" json:"routes,omitempty"`
+	Metadata   map[string]any     `yaml:",omitempty" json:"metadata,omitempty"`
+}
+
+// UserRouteConfig maps the authenticated clients to routing objects.
+// A client matches the route if its username is in Users or it is authenticated by Auther.
+type UserRouteConfig struct {
+	Users      []string          `yaml:",omitempty" json:"users,omitempty"`
+	Auther     string            `yaml:",omitempty" json:"auther,omitempty"`
 	Chain      string            `yaml:",omitempty" json:"chain,omitempty"`
 	ChainGroup *ChainGroupConfig `yaml:"chainGroup,omitempty" json:"chainGroup,omitempty"`
-	Auther     string            `yaml:",omitempty" json:"auther,omitempty"`
-	Authers    []string          `yaml:",omitempty" json:"authers,omitempty"`
-	Auth       *AuthConfig       `yaml:",omitempty" json:"auth,omitempty"`
-	TLS        *TLSConfig        `yaml:",omitempty" json:"tls,omitempty"`
-	Metadata   map[string]any    `yaml:",omitempty" json:"metadata,omitempty"`
+	Bypass     string            `yaml:",omitempty" json:"bypass,omitempty"`
+	Bypasses   []string          `yaml:",omitempty" json:"bypasses,omitempty"`
+	RLimiter   string            `yaml:"rlimiter,omitempty" json:"rlimiter,omitempty"`
 }
 
 type ForwarderConfig struct {
@@ -329,6 +419,8 @@ type NodeConfig struct {
 }
 
 type Config struct {
+	// files or globs of the configs merged into this one, relative to the directory of the config file.
+	Include    []string           `yaml:",omitempty" json:"include,omitempty"`
 	Services   []*ServiceConfig   `json:"services"`
 	Chains     []*ChainConfig     `yaml:",omitempty" json:"chains,omitempty"`
 	Hops       []*HopConfig       `yaml:",omitempty" json:"hops,omitempty"`
@@ -346,22 +438,36 @@ type Config struct {
 	Profiling  *ProfilingConfig   `yaml:",omitempty" json:"profiling,omitempty"`
 	API        *APIConfig         `yaml:",omitempty" json:"api,omitempty"`
 	Metrics    *MetricsConfig     `yaml:",omitempty" json:"metrics,omitempty"`
+	Backend    *BackendConfig     `yaml:",omitempty" json:"backend,omitempty"`
+
+	// the objects merged from the included files by kind and name, they are not written.
+	included map[string]bool
 }
 
+// Load reads the config file found in the config paths, the included files are merged.
+// The variables in the string fields are kept, they are expanded by Expanded for parsing.
 func (c *Config) Load() error {
 	if err := v.ReadInConfig(); err != nil {
 		return err
 	}
 
-	return v.Unmarshal(c)
+	if err := v.Unmarshal(c); err != nil {
+		return err
+	}
+	file := v.ConfigFileUsed()
+	return c.resolve(filepath.Dir(file), file)
 }
 
+// Read reads the config from r, the included files are relative to the working directory.
 func (c *Config) Read(r io.Reader) error {
 	if err := v.ReadConfig(r); err != nil {
 		return err
 	}
 
-	return v.Unmarshal(c)
+	if err := v.Unmarshal(c); err != nil {
+		return err
+	}
+	return c.resolve(".", "")
 }
 
 func (c *Config) ReadFile(file string) error {
@@ -369,10 +475,32 @@ func (c *Config) ReadFile(file string) error {
 	if err := v.ReadInConfig(); err != nil {
 		return err
 	}
-	return v.Unmarshal(c)
+	if err := v.Unmarshal(c); err != nil {
+		return err
+	}
+	return c.resolve(filepath.Dir(file), file)
+}
+
+// ReadData reads the config in YAML or JSON from data by a new viper instance,
+// the included files are relative to the working directory.
+func (c *Config) ReadData(data []byte) error {
+	vi := viper.New()
+	// JSON is a subset of YAML.
+	vi.SetConfigType("yaml")
+	if err := vi.ReadConfig(bytes.NewReader(data)); err != nil {
+		return err
+	}
+	if err := vi.Unmarshal(c); err != nil {
+		return err
+	}
+	return c.resolve(".", "")
 }
 
+// Write writes the config as it is read: the objects merged from the included files
+// are written by the include directive, and the variables are not expanded.
 func (c *Config) Write(w io.Writer, format string) error {
+	c = c.source()
+
 	switch format {
 	case "json":
 		enc := json.NewEncoder(w)
@@ -0,0 +1,184 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+const (
+	// fileVarPrefix is the prefix of the variable referring to a file, such as ${file:/run/secrets/password}.
+	fileVarPrefix = "file:"
+)
+
+// Expand replaces ${NAME} in s with the value of the environment variable NAME,
+// and ${file:PATH} with the content of the file PATH without the trailing newlines.
+// $${ is the escape of the literal ${.
+// It is an error if the environment variable is not set or the file can not be read.
+func Expand(s string) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+
+	var b strings.Builder
+	for {
+		i := strings.Index(s, "${")
+		if i < 0 {
+			b.WriteString(s)
+			break
+		}
+		if i > 0 && s[i-1] == '$' {
+			// escaped
+			b.WriteString(s[:i])
+			b.WriteString("{")
+			s = s[i+2:]
+			continue
+		}
+
+		b.WriteString(s[:i])
+		s = s[i+2:]
+		j := strings.IndexByte(s, '}')
+		if j < 0 {
+			return "", fmt.Errorf("config: unterminated variable ${%s", s)
+		}
+		name := s[:j]
+		s = s[j+1:]
+
+		v, err := lookupVar(name)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(v)
+	}
+
+	return b.String(), nil
+}
+
+func lookupVar(name string) (string, error) {
+	if path, ok := strings.CutPrefix(name, fileVarPrefix); ok {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("config: variable ${%s}: %w", name, err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+
+	if name == "" {
+		return "", fmt.Errorf("config: empty variable ${}")
+	}
+	v, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("config: variable ${%s}: environment variable not set", name)
+	}
+	return v, nil
+}
+
+// Expanded returns a copy of the config object c with the variables in all the string fields expanded by Expand,
+// including the metadata. The object c is not changed, so that the variables are kept for writing.
+func Expanded[T any](c *T) (*T, error) {
+	if c == nil {
+		return nil, nil
+	}
+	v, err := expandValue(reflect.ValueOf(c))
+	if err != nil {
+		return nil, err
+	}
+	return v.Interface().(*T), nil
+}
+
+// expandValue returns the copy of v with the strings expanded.
+func expandValue(v reflect.Value) (reflect.Value, error) {
+	switch v.Kind() {
+	case reflect.String:
+		s, err := Expand(v.String())
+		if err != nil {
+			return v, err
+		}
+		e := reflect.New(v.Type()).Elem()
+		e.SetString(s)
+		return e, nil
+
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v, nil
+		}
+		e, err := expandValue(v.Elem())
+		if err != nil {
+			return v, err
+		}
+		p := reflect.New(v.Type().Elem())
+		p.Elem().Set(e)
+		return p, nil
+
+	case reflect.Interface:
+		if v.IsNil() {
+			return v, nil
+		}
+		e, err := expandValue(v.Elem())
+		if err != nil {
+			return v, err
+		}
+		i := reflect.New(v.Type()).Elem()
+		i.Set(e)
+		return i, nil
+
+	case reflect.Struct:
+		s := reflect.New(v.Type()).Elem()
+		// the unexported fields are copied as they are.
+		s.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if !s.Field(i).CanSet() {
+				continue
+			}
+			f, err := expandValue(v.Field(i))
+			if err != nil {
+				return v, err
+			}
+			s.Field(i).Set(f)
+		}
+		return s, nil
+
+	case reflect.Slice:
+		if v.IsNil() {
+			return v, nil
+		}
+		l := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			e, err := expandValue(v.Index(i))
+			if err != nil {
+				return v, err
+			}
+			l.Index(i).Set(e)
+		}
+		return l, nil
+
+	case reflect.Array:
+		a := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			e, err := expandValue(v.Index(i))
+			if err != nil {
+				return v, err
+			}
+			a.Index(i).Set(e)
+		}
+		return a, nil
+
+	case reflect.Map:
+		if v.IsNil() {
+			return v, nil
+		}
+		m := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			e, err := expandValue(iter.Value())
+			if err != nil {
+				return v, err
+			}
+			m.SetMapIndex(iter.Key(), e)
+		}
+		return m, nil
+	}
+
+	return v, nil
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExpand(t *testing.T) {
+	t.Setenv("GOST_TEST_USER", "admin")
+	t.Setenv("GOST_TEST_EMPTY", "")
+
+	secret := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(secret, []byte("s3cret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		s    string
+		want string
+		err  bool
+	}{
+		{s: "plain", want: "plain"},
+		{s: "${GOST_TEST_USER}", want: "admin"},
+		{s: "user=${GOST_TEST_USER}, empty=${GOST_TEST_EMPTY}.", want: "user=admin, empty=."},
+		{s: "${file:" + secret + "}", want: "s3cret"},
+		{s: "$${GOST_TEST_USER}", want: "${GOST_TEST_USER}"},
+		{s: "$$x ${GOST_TEST_USER}", want: "$$x admin"},
+		{s: "${GOST_TEST_NOT_SET}", err: true},
+		{s: "${}", err: true},
+		{s: "${GOST_TEST_USER", err: true},
+		{s: "${file:" + secret + ".missing}", err: true},
+	}
+	for _, tt := range tests {
+		got, err := Expand(tt.s)
+		if (err != nil) != tt.err {
+			t.Errorf("%q: got error %v, want error %v", tt.s, err, tt.err)
+			continue
+		}
+		if err == nil && got != tt.want {
+			t.Errorf("%q: got %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestExpanded(t *testing.T) {
+	t.Setenv("GOST_TEST_PASSWORD", "s3cret")
+
+	c := &Config{
+		Services: []*ServiceConfig{{
+			Name: "svc",
+			Handler: &HandlerConfig{
+				Type:     "http",
+				Auth:     &AuthConfig{Username: "admin", Password: "${GOST_TEST_PASSWORD}"},
+				Metadata: map[string]any{"key": "${GOST_TEST_PASSWORD}", "list": []any{"${GOST_TEST_PASSWORD}", 1}},
+			},
+		}},
+		Authers: []*AutherConfig{{
+			Name:  "auther",
+			Auths: []*AuthConfig{{Username: "user", Password: "${GOST_TEST_PASSWORD}"}},
+		}},
+	}
+
+	ec, err := Expanded(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := ec.Services[0].Handler
+	if h.Auth.Password != "s3cret" {
+		t.Errorf("got password %q, want expanded", h.Auth.Password)
+	}
+	if h.Metadata["key"] != "s3cret" {
+		t.Errorf("got metadata %q, want expanded", h.Metadata["key"])
+	}
+	if l := h.Metadata["list"].([]any); l[0] != "s3cret" || l[1] != 1 {
+		t.Errorf("got metadata list %v, want expanded", l)
+	}
+	if ec.Authers[0].Auths[0].Password != "s3cret" {
+		t.Errorf("got auther password %q, want expanded", ec.Authers[0].Auths[0].Password)
+	}
+
+	// the source config keeps the variables.
+	h = c.Services[0].Handler
+	if h.Auth.Password != "${GOST_TEST_PASSWORD}" || h.Metadata["key"] != "${GOST_TEST_PASSWORD}" ||
+		h.Metadata["list"].([]any)[0] != "${GOST_TEST_PASSWORD}" ||
+		c.Authers[0].Auths[0].Password != "${GOST_TEST_PASSWORD}" {
+		t.Error("the source config is expanded")
+	}
+
+	if _, err := Expanded(&Config{Services: []*ServiceConfig{{Name: "${GOST_TEST_NOT_SET}"}}}); err == nil {
+		t.Error("the variable not set is expanded")
+	}
+}
@@ -0,0 +1,185 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+
+	"github.com/spf13/viper"
+)
+
+// resolve merges the included files into the config,
+// the relative include paths are resolved against the directory dir.
+func (c *Config) resolve(dir string, file string) error {
+	visited := make(map[string]bool)
+	if file != "" {
+		if abs, err := filepath.Abs(file); err == nil {
+			visited[abs] = true
+		}
+	}
+	return c.include(dir, visited)
+}
+
+// include merges the files included by the config recursively.
+// The include directive is kept, and the objects merged are recorded so that they are not written.
+func (c *Config) include(dir string, visited map[string]bool) error {
+	for _, pattern := range c.Include {
+		pattern, err := Expand(pattern)
+		if err != nil {
+			return err
+		}
+		if pattern == "" {
+			continue
+		}
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(dir, pattern)
+		}
+		files, err := filepath.Glob(pattern)
+		if err != nil {
+			return fmt.Errorf("config: include %s: %w", pattern, err)
+		}
+		if len(files) == 0 && !hasMeta(pattern) {
+			return fmt.Errorf("config: include %s: file not found", pattern)
+		}
+		sort.Strings(files)
+
+		for _, file := range files {
+			abs, err := filepath.Abs(file)
+			if err != nil {
+				return err
+			}
+			if visited[abs] {
+				return fmt.Errorf("config: include %s: circular include", file)
+			}
+			visited[abs] = true
+
+			inc := &Config{}
+			vi := viper.New()
+			vi.SetConfigFile(file)
+			if err := vi.ReadInConfig(); err != nil {
+				return fmt.Errorf("config: include %s: %w", file, err)
+			}
+			if err := vi.Unmarshal(inc); err != nil {
+				return fmt.Errorf("config: include %s: %w", file, err)
+			}
+			if err := inc.include(filepath.Dir(abs), visited); err != nil {
+				return err
+			}
+			c.merge(inc)
+
+			delete(visited, abs)
+		}
+	}
+
+	return nil
+}
+
+// merge appends the objects of the included config to the lists,
+// the other settings are taken only if they are not set in c.
+func (c *Config) merge(inc *Config) {
+	c.Services = mergeObjects(c, "services", c.Services, inc.Services)
+	c.Chains = mergeObjects(c, "chains", c.Chains, inc.Chains)
+	c.Hops = mergeObjects(c, "hops", c.Hops, inc.Hops)
+	c.Authers = mergeObjects(c, "authers", c.Authers, inc.Authers)
+	c.Admissions = mergeObjects(c, "admissions", c.Admissions, inc.Admissions)
+	c.Bypasses = mergeObjects(c, "bypasses", c.Bypasses, inc.Bypasses)
+	c.Resolvers = mergeObjects(c, "resolvers", c.Resolvers, inc.Resolvers)
+	c.Hosts = mergeObjects(c, "hosts", c.Hosts, inc.Hosts)
+	c.Recorders = mergeObjects(c, "recorders", c.Recorders, inc.Recorders)
+	c.Limiters = mergeObjects(c, "limiters", c.Limiters, inc.Limiters)
+	c.CLimiters = mergeObjects(c, "climiters", c.CLimiters, inc.CLimiters)
+	c.RLimiters = mergeObjects(c, "rlimiters", c.RLimiters, inc.RLimiters)
+
+	// the settings taken from the included config are written inline.
+	if c.TLS == nil {
+		c.TLS = inc.TLS
+	}
+	if c.Log == nil {
+		c.Log = inc.Log
+	}
+	if c.Profiling == nil {
+		c.Profiling = inc.Profiling
+	}
+	if c.API == nil {
+		c.API = inc.API
+	}
+	if c.Metrics == nil {
+		c.Metrics = inc.Metrics
+	}
+	if c.Backend == nil {
+		c.Backend = inc.Backend
+	}
+}
+
+func mergeObjects[T any](c *Config, kind string, objects, incs []*T) []*T {
+	for _, o := range incs {
+		if o == nil {
+			continue
+		}
+		if c.included == nil {
+			c.included = make(map[string]bool)
+		}
+		c.included[kind+"/"+objectName(o)] = true
+		objects = append(objects, o)
+	}
+	return objects
+}
+
+// source returns the copy of the config without the objects merged from the included files.
+func (c *Config) source() *Config {
+	if len(c.included) == 0 {
+		return c
+	}
+
+	cp := &Config{}
+	*cp = *c
+	cp.Services = excludeObjects(c, "services", c.Services)
+	cp.Chains = excludeObjects(c, "chains", c.Chains)
+	cp.Hops = excludeObjects(c, "hops", c.Hops)
+	cp.Authers = excludeObjects(c, "authers", c.Authers)
+	cp.Admissions = excludeObjects(c, "admissions", c.Admissions)
+	cp.Bypasses = excludeObjects(c, "bypasses", c.Bypasses)
+	cp.Resolvers = excludeObjects(c, "resolvers", c.Resolvers)
+	cp.Hosts = excludeObjects(c, "hosts", c.Hosts)
+	cp.Recorders = excludeObjects(c, "recorders", c.Recorders)
+	cp.Limiters = excludeObjects(c, "limiters", c.Limiters)
+	cp.CLimiters = excludeObjects(c, "climiters", c.CLimiters)
+	cp.RLimiters = excludeObjects(c, "rlimiters", c.RLimiters)
+	return cp
+}
+
+// excludeObjects returns the objects not merged from the included files.
+// The objects are matched by name, so an included object replaced at runtime is not written either.
+func excludeObjects[T any](c *Config, kind string, objects []*T) []*T {
+	var l []*T
+	for _, o := range objects {
+		if o != nil && c.included[kind+"/"+objectName(o)] {
+			continue
+		}
+		l = append(l, o)
+	}
+	return l
+}
+
+// objectName returns the Name field of the config object.
+func objectName[T any](o *T) string {
+	v := reflect.ValueOf(o).Elem()
+	if v.Kind() != reflect.Struct {
+		return ""
+	}
+	if f := v.FieldByName("Name"); f.Kind() == reflect.String {
+		return f.String()
+	}
+	return ""
+}
+
+func hasMeta(pattern string) bool {
+	for _, c := range pattern {
+		switch c {
+		case '*', '?', '[':
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,165 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	file := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func names[T any](objects []*T) (l []string) {
+	for _, o := range objects {
+		l = append(l, objectName(o))
+	}
+	return
+}
+
+func TestInclude(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		services []string
+		hops     []string
+		err      string
+	}{
+		{
+			name: "glob",
+			files: map[string]string{
+				"gost.yaml":          "include: [conf.d/*.yaml]\nservices:\n- name: main\n",
+				"conf.d/b.yaml":      "services:\n- name: b\n",
+				"conf.d/a.yaml":      "services:\n- name: a\nhops:\n- name: hop\n",
+				"conf.d/ignored.yml": "services:\n- name: ignored\n",
+			},
+			services: []string{"main", "a", "b"},
+			hops:     []string{"hop"},
+		},
+		{
+			name: "nested",
+			files: map[string]string{
+				"gost.yaml":     "include: [sub/a.yaml]\n",
+				"sub/a.yaml":    "include: [b.yaml]\nservices:\n- name: a\n",
+				"sub/b.yaml":    "services:\n- name: b\n",
+				"unused/c.yaml": "services:\n- name: c\n",
+			},
+			services: []string{"a", "b"},
+		},
+		{
+			name: "empty glob",
+			files: map[string]string{
+				"gost.yaml": "include: [conf.d/*.yaml]\nservices:\n- name: main\n",
+			},
+			services: []string{"main"},
+		},
+		{
+			name: "missing file",
+			files: map[string]string{
+				"gost.yaml": "include: [missing.yaml]\n",
+			},
+			err: "file not found",
+		},
+		{
+			name: "circular",
+			files: map[string]string{
+				"gost.yaml": "include: [a.yaml]\n",
+				"a.yaml":    "include: [gost.yaml]\n",
+			},
+			err: "circular include",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				writeFile(t, dir, name, content)
+			}
+
+			c := &Config{}
+			err := c.ReadFile(filepath.Join(dir, "gost.yaml"))
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("got error %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := strings.Join(names(c.Services), ","); got != strings.Join(tt.services, ",") {
+				t.Errorf("got services %s, want %s", got, strings.Join(tt.services, ","))
+			}
+			if got := strings.Join(names(c.Hops), ","); got != strings.Join(tt.hops, ",") {
+				t.Errorf("got hops %s, want %s", got, strings.Join(tt.hops, ","))
+			}
+		})
+	}
+}
+
+func TestWriteSource(t *testing.T) {
+	t.Setenv("GOST_TEST_PASSWORD", "s3cret")
+
+	dir := t.TempDir()
+	writeFile(t, dir, "conf.d/a.yaml", "services:\n- name: included\n")
+	file := writeFile(t, dir, "gost.yaml", `include: [conf.d/*.yaml]
+services:
+- name: main
+  handler:
+    type: http
+    auth:
+      username: admin
+      password: ${GOST_TEST_PASSWORD}
+`)
+
+	c := &Config{}
+	if err := c.ReadFile(file); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(names(c.Services), ","); got != "main,included" {
+		t.Fatalf("got services %s, want main,included", got)
+	}
+	// an object added at runtime is written.
+	c.Services = append(c.Services, &ServiceConfig{Name: "added"})
+
+	for _, format := range []string{"yaml", "json"} {
+		var buf bytes.Buffer
+		if err := c.Write(&buf, format); err != nil {
+			t.Fatal(err)
+		}
+		out := buf.String()
+		for _, s := range []string{"conf.d/*.yaml", "${GOST_TEST_PASSWORD}", "main", "added"} {
+			if !strings.Contains(out, s) {
+				t.Errorf("%s: %q is not written:\n%s", format, s, out)
+			}
+		}
+		for _, s := range []string{"s3cret", "included"} {
+			if strings.Contains(out, s) {
+				t.Errorf("%s: %q is written:\n%s", format, s, out)
+			}
+		}
+	}
+
+	// the config written is read back to the same objects.
+	var buf bytes.Buffer
+	c.Write(&buf, "yaml")
+	file = writeFile(t, dir, "gost.yaml", buf.String())
+	rc := &Config{}
+	if err := rc.ReadFile(file); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(names(rc.Services), ","); got != "main,added,included" {
+		t.Errorf("got services %s, want main,added,included", got)
+	}
+}
@@ -0,0 +1,367 @@
+package loader
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-gost/core/logger"
+	"github.com/go-gost/x/config"
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	// DefaultBackendKey is the default redis key of the config.
+	DefaultBackendKey = "gost"
+)
+
+var (
+	ErrNoBackend = errors.New("loader: no config backend")
+	// ErrConflict is returned by Backend.Save if the config in the backend has been changed by others.
+	ErrConflict = errors.New("loader: config in backend changed")
+)
+
+// Backend is the remote storage of the whole config.
+type Backend interface {
+	// Load returns the config data and the version of it,
+	// the data is nil if the version is not changed from the version specified or the backend has no config.
+	Load(ctx context.Context, version string) (data []byte, newVersion string, err error)
+	// Save saves the config data and returns the version of it,
+	// only if the config in the backend is still of the version, which is empty if the backend has no config.
+	// It returns ErrConflict otherwise.
+	Save(ctx context.Context, data []byte, version string) (newVersion string, err error)
+	Close() error
+}
+
+// NewBackend creates the backend by the config, it returns nil if no backend is specified.
+func NewBackend(cfg *config.BackendConfig) Backend {
+	if cfg == nil {
+		return nil
+	}
+	if cfg.Redis != nil && cfg.Redis.Addr != "" {
+		return RedisBackend(cfg.Redis)
+	}
+	if cfg.HTTP != nil && cfg.HTTP.URL != "" {
+		return HTTPBackend(cfg.HTTP)
+	}
+	return nil
+}
+
+type redisBackend struct {
+	client *redis.Client
+	key    string
+}
+
+// RedisBackend stores the config in the string value of a redis key.
+func RedisBackend(cfg *config.RedisLoader) Backend {
+	key := cfg.Key
+	if key == "" {
+		key = DefaultBackendKey
+	}
+	return &redisBackend{
+		client: redis.NewClient(&redis.Options{
+			Addr:     cfg.Addr,
+			Password: cfg.Password,
+			DB:       cfg.DB,
+		}),
+		key: key,
+	}
+}
+
+func (b *redisBackend) Load(ctx context.Context, version string) ([]byte, string, error) {
+	data, err := b.client.Get(ctx, b.key).Bytes()
+	if err == redis.Nil {
+		// the key does not exist.
+		return nil, version, nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	// redis has no version of the value, the hash of the data is used instead.
+	v := hash(data)
+	if v == version {
+		return nil, v, nil
+	}
+	return data, v, nil
+}
+
+func (b *redisBackend) Save(ctx context.Context, data []byte, version string) (string, error) {
+	// the key is set in a transaction which fails if the key is changed after it is checked.
+	err := b.client.Watch(ctx, func(tx *redis.Tx) error {
+		cur, err := tx.Get(ctx, b.key).Bytes()
+		if err != nil && err != redis.Nil {
+			return err
+		}
+		if (err == redis.Nil && version != "") || (err == nil && hash(cur) != version) {
+			return ErrConflict
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, b.key, data, 0)
+			return nil
+		})
+		return err
+	}, b.key)
+	if err == redis.TxFailedErr {
+		err = ErrConflict
+	}
+	if err != nil {
+		return "", err
+	}
+	return hash(data), nil
+}
+
+func (b *redisBackend) Close() error {
+	return b.client.Close()
+}
+
+type httpBackend struct {
+	url        string
+	httpClient *http.Client
+}
+
+// HTTPBackend gets the config from the URL and puts the config to it.
+// The ETag of the response is used as the version and sent in the If-None-Match header,
+// the hash of the data is used if the server sends no ETag.
+func HTTPBackend(cfg *config.HTTPLoader) Backend {
+	return &httpBackend{
+		url: cfg.URL,
+		httpClient: &http.Client{
+			Timeout: cfg.Timeout,
+		},
+	}
+}
+
+func (b *httpBackend) Load(ctx context.Context, version string) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, b.url, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	if version != "" {
+		req.Header.Set("If-None-Match", version)
+	}
+
+	resp, err := b.httpClient.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified || resp.StatusCode == http.StatusNotFound {
+		return nil, version, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+
+	v := resp.Header.Get("ETag")
+	if v == "" {
+		v = hash(data)
+	}
+	if v == version {
+		return nil, v, nil
+	}
+	return data, v, nil
+}
+
+func (b *httpBackend) Save(ctx context.Context, data []byte, version string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, b.url, bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case version == "":
+		req.Header.Set("If-None-Match", "*")
+	case isETag(version):
+		req.Header.Set("If-Match", version)
+	default:
+		// the server sends no ETag, the config is checked before it is put.
+		cur, _, err := b.Load(ctx, version)
+		if err != nil {
+			return "", err
+		}
+		if cur != nil {
+			return "", ErrConflict
+		}
+	}
+
+	resp, err := b.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusPreconditionFailed {
+		return "", ErrConflict
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	v := resp.Header.Get("ETag")
+	if v == "" {
+		v = hash(data)
+	}
+	return v, nil
+}
+
+func (b *httpBackend) Close() error {
+	return nil
+}
+
+// isETag reports whether the version v is an entity tag rather than the hash of the data.
+func isETag(v string) bool {
+	return strings.HasPrefix(v, `"`) || strings.HasPrefix(v, `W/"`)
+}
+
+func hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+var (
+	backend        Backend
+	backendFormat  string
+	backendVersion string
+	backendMux     sync.Mutex
+)
+
+// SetBackend sets the backend used by LoadBackend, ReloadBackend and SaveBackend,
+// the config is saved in the format yaml or json.
+func SetBackend(b Backend, format string) {
+	backendMux.Lock()
+	defer backendMux.Unlock()
+
+	backend = b
+	backendFormat = format
+	backendVersion = ""
+}
+
+// GetBackend returns the backend set by SetBackend.
+func GetBackend() Backend {
+	backendMux.Lock()
+	defer backendMux.Unlock()
+
+	return backend
+}
+
+// LoadBackend loads the config from the backend for the startup,
+// it returns nil if the backend has no config.
+func LoadBackend(ctx context.Context) (*config.Config, error) {
+	backendMux.Lock()
+	defer backendMux.Unlock()
+
+	if backend == nil {
+		return nil, ErrNoBackend
+	}
+
+	data, version, err := backend.Load(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, nil
+	}
+	cfg := &config.Config{}
+	if err := cfg.ReadData(data); err != nil {
+		return nil, err
+	}
+	backendVersion = version
+
+	return cfg, nil
+}
+
+// ReloadBackend loads the config from the backend and applies it by Reload if it is changed.
+func ReloadBackend(ctx context.Context) error {
+	backendMux.Lock()
+	defer backendMux.Unlock()
+
+	if backend == nil {
+		return ErrNoBackend
+	}
+
+	data, version, err := backend.Load(ctx, backendVersion)
+	if err != nil {
+		return err
+	}
+	if data == nil {
+		return nil
+	}
+
+	cfg := &config.Config{}
+	if err := cfg.ReadData(data); err != nil {
+		return err
+	}
+	// the version is updated even if some objects fail, they are not retried until the config is changed.
+	backendVersion = version
+
+	return Reload(cfg)
+}
+
+// SaveBackend saves the global config to the backend,
+// it returns ErrConflict if the config in the backend has been changed since it was loaded or saved.
+// The API and backend settings are not saved, as they are not reloaded and contain the credentials.
+func SaveBackend(ctx context.Context) error {
+	backendMux.Lock()
+	defer backendMux.Unlock()
+
+	if backend == nil {
+		return ErrNoBackend
+	}
+
+	cfg := config.Global()
+	cfg.API = nil
+	cfg.Backend = nil
+
+	var buf bytes.Buffer
+	if err := cfg.Write(&buf, backendFormat); err != nil {
+		return err
+	}
+	version, err := backend.Save(ctx, buf.Bytes(), backendVersion)
+	if err != nil {
+		return err
+	}
+	// the config saved is not reloaded.
+	backendVersion = version
+
+	return nil
+}
+
+// WatchBackend reloads the config by ReloadBackend every period until ctx is done.
+func WatchBackend(ctx context.Context, period time.Duration) {
+	if period <= 0 {
+		return
+	}
+
+	log := logger.Default().WithFields(map[string]any{
+		"kind": "reload",
+	})
+
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := ReloadBackend(ctx); err != nil {
+				log.Error(err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,443 @@
+package loader
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-gost/x/config"
+)
+
+// fakeRedis serves the GET and SET commands of a single key,
+// and the transactions of them watching the key.
+type fakeRedis struct {
+	ln    net.Listener
+	mu    sync.Mutex
+	value []byte
+	// rev is increased when the value is changed.
+	rev int
+	// onGet is called after the value is got, it is called with the lock held.
+	onGet func()
+}
+
+func newFakeRedis(t *testing.T) *fakeRedis {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &fakeRedis{ln: ln}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go r.serve(conn)
+		}
+	}()
+	t.Cleanup(func() { ln.Close() })
+	return r
+}
+
+// set changes the value as another client.
+func (r *fakeRedis) set(value string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.value = []byte(value)
+	r.rev++
+}
+
+func (r *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+
+	// the revision watched, -1 if the key is not watched.
+	watched := -1
+	var queued [][]string
+	multi := false
+
+	br := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(br)
+		if err != nil {
+			return
+		}
+
+		r.mu.Lock()
+		cmd := strings.ToUpper(args[0])
+		switch {
+		case multi && cmd != "EXEC":
+			queued = append(queued, args)
+			io.WriteString(conn, "+QUEUED\r\n")
+		case cmd == "GET":
+			if r.value == nil {
+				io.WriteString(conn, "$-1\r\n")
+			} else {
+				fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(r.value), r.value)
+			}
+			if r.onGet != nil {
+				r.onGet()
+			}
+		case cmd == "SET":
+			r.value = []byte(args[2])
+			r.rev++
+			io.WriteString(conn, "+OK\r\n")
+		case cmd == "WATCH":
+			watched = r.rev
+			io.WriteString(conn, "+OK\r\n")
+		case cmd == "UNWATCH":
+			watched = -1
+			io.WriteString(conn, "+OK\r\n")
+		case cmd == "MULTI":
+			multi = true
+			io.WriteString(conn, "+OK\r\n")
+		case cmd == "EXEC":
+			if watched >= 0 && watched != r.rev {
+				// the transaction is aborted as the key watched is changed.
+				io.WriteString(conn, "*-1\r\n")
+			} else {
+				fmt.Fprintf(conn, "*%d\r\n", len(queued))
+				for _, args := range queued {
+					r.value = []byte(args[2])
+					r.rev++
+					io.WriteString(conn, "+OK\r\n")
+				}
+			}
+			multi, queued, watched = false, nil, -1
+		default:
+			io.WriteString(conn, "-ERR unknown command\r\n")
+		}
+		r.mu.Unlock()
+	}
+}
+
+func readCommand(br *bufio.Reader) ([]string, error) {
+	line, err := br.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+		if err != nil {
+			return nil, err
+		}
+		b := make([]byte, size+2)
+		if _, err := io.ReadFull(br, b); err != nil {
+			return nil, err
+		}
+		args[i] = string(b[:size])
+	}
+	return args, nil
+}
+
+func TestRedisBackend(t *testing.T) {
+	r := newFakeRedis(t)
+	b := RedisBackend(&config.RedisLoader{Addr: r.ln.Addr().String()})
+	defer b.Close()
+	ctx := context.Background()
+
+	// the key not existing is no config.
+	data, version, err := b.Load(ctx, "")
+	if err != nil || data != nil {
+		t.Fatalf("got %q, %v, want no config", data, err)
+	}
+
+	saved, err := b.Save(ctx, []byte("services: []\n"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, version, err = b.Load(ctx, "")
+	if err != nil || string(data) != "services: []\n" {
+		t.Fatalf("got %q, %v, want the config saved", data, err)
+	}
+	if version != saved {
+		t.Errorf("got version %s, want the version saved %s", version, saved)
+	}
+
+	if data, _, err = b.Load(ctx, version); err != nil || data != nil {
+		t.Errorf("got %q, %v, want not changed", data, err)
+	}
+
+	// the config is saved over the version loaded.
+	if saved, err = b.Save(ctx, []byte("hops: []\n"), version); err != nil {
+		t.Fatal(err)
+	}
+
+	// the config changed by others is not overwritten.
+	if _, err := b.Save(ctx, []byte("chains: []\n"), version); err != ErrConflict {
+		t.Errorf("got error %v with the old version, want %v", err, ErrConflict)
+	}
+	if _, err := b.Save(ctx, []byte("chains: []\n"), ""); err != ErrConflict {
+		t.Errorf("got error %v without version, want %v", err, ErrConflict)
+	}
+
+	// the config changed after it is checked.
+	r.mu.Lock()
+	r.onGet = func() {
+		r.value = []byte("authers: []\n")
+		r.rev++
+		r.onGet = nil
+	}
+	r.mu.Unlock()
+	if _, err := b.Save(ctx, []byte("chains: []\n"), saved); err != ErrConflict {
+		t.Errorf("got error %v for the config changed in the transaction, want %v", err, ErrConflict)
+	}
+	if data, _, _ := b.Load(ctx, ""); string(data) != "authers: []\n" {
+		t.Errorf("got %q, want the config changed by others", data)
+	}
+}
+
+func TestHTTPBackend(t *testing.T) {
+	var mu sync.Mutex
+	var body []byte
+	etag := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		tag := strconv.Quote(strconv.Itoa(etag))
+		switch r.Method {
+		case http.MethodGet:
+			if body == nil {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			if r.Header.Get("If-None-Match") == tag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", tag)
+			w.Write(body)
+		case http.MethodPut:
+			if m := r.Header.Get("If-Match"); (m != "" && (body == nil || m != tag)) ||
+				(r.Header.Get("If-None-Match") == "*" && body != nil) {
+				w.WriteHeader(http.StatusPreconditionFailed)
+				return
+			}
+			body, _ = io.ReadAll(r.Body)
+			etag++
+			w.Header().Set("ETag", strconv.Quote(strconv.Itoa(etag)))
+		}
+	}))
+	defer srv.Close()
+
+	b := HTTPBackend(&config.HTTPLoader{URL: srv.URL})
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		save    string
+		version string // the version to load with, "saved" for the version of the last save.
+		want    string // the data loaded, empty if not changed or no config.
+		err     bool
+	}{
+		{name: "no config"},
+		{name: "saved", save: "services: []\n", want: "services: []\n"},
+		{name: "not modified", version: "saved"},
+		{name: "changed", save: "hops: []\n", version: `"1"`, want: "hops: []\n"},
+	}
+	var saved string
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.save != "" {
+				v, err := b.Save(ctx, []byte(tt.save), saved)
+				if err != nil {
+					t.Fatal(err)
+				}
+				saved = v
+			}
+			version := tt.version
+			if version == "saved" {
+				version = saved
+			}
+			data, _, err := b.Load(ctx, version)
+			if (err != nil) != tt.err {
+				t.Fatalf("got error %v, want error %v", err, tt.err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("got %q, want %q", data, tt.want)
+			}
+		})
+	}
+
+	// the config changed by others is not overwritten.
+	for _, version := range []string{"", `"1"`} {
+		if _, err := b.Save(ctx, []byte("chains: []\n"), version); err != ErrConflict {
+			t.Errorf("got error %v with version %q, want %v", err, version, ErrConflict)
+		}
+	}
+}
+
+func TestHTTPBackendWithoutETag(t *testing.T) {
+	var mu sync.Mutex
+	body := []byte("services: []\n")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch r.Method {
+		case http.MethodGet:
+			w.Write(body)
+		case http.MethodPut:
+			body, _ = io.ReadAll(r.Body)
+		}
+	}))
+	defer srv.Close()
+
+	b := HTTPBackend(&config.HTTPLoader{URL: srv.URL})
+	ctx := context.Background()
+
+	_, version, err := b.Load(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the hash of the config is checked before it is put.
+	if version, err = b.Save(ctx, []byte("hops: []\n"), version); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	body = []byte("chains: []\n")
+	mu.Unlock()
+	if _, err := b.Save(ctx, []byte("authers: []\n"), version); err != ErrConflict {
+		t.Errorf("got error %v, want %v", err, ErrConflict)
+	}
+}
+
+// countBackend counts the loads returning the config changed.
+type countBackend struct {
+	Backend
+	loads int
+}
+
+func (b *countBackend) Load(ctx context.Context, version string) ([]byte, string, error) {
+	data, v, err := b.Backend.Load(ctx, version)
+	if data != nil {
+		b.loads++
+	}
+	return data, v, err
+}
+
+func TestSaveBackend(t *testing.T) {
+	defer Reload(&config.Config{})
+	defer SetBackend(nil, "")
+
+	r := newFakeRedis(t)
+	b := &countBackend{Backend: RedisBackend(&config.RedisLoader{Addr: r.ln.Addr().String()})}
+	defer b.Close()
+	SetBackend(b, "yaml")
+	ctx := context.Background()
+
+	cfg, err := LoadBackend(ctx)
+	if err != nil || cfg != nil {
+		t.Fatalf("got %v, %v, want no config", cfg, err)
+	}
+
+	if err := Reload(&config.Config{Authers: []*config.AutherConfig{auther("a", "pass")}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveBackend(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// the config saved is not loaded back.
+	if err := ReloadBackend(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if b.loads != 0 {
+		t.Errorf("the config saved is reloaded")
+	}
+
+	// the API and backend settings are not saved.
+	r.mu.Lock()
+	saved := string(r.value)
+	r.mu.Unlock()
+	if !strings.Contains(saved, "name: a") || strings.Contains(saved, "api:") || strings.Contains(saved, "backend:") {
+		t.Errorf("got the config saved %q", saved)
+	}
+
+	// the config changed by others is not overwritten before it is reloaded.
+	r.set("authers:\n- name: b\n  auths:\n  - username: b\n    password: pass\n")
+	if err := SaveBackend(ctx); err != ErrConflict {
+		t.Errorf("got error %v, want %v", err, ErrConflict)
+	}
+
+	// the config changed by others is reloaded.
+	if err := ReloadBackend(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if b.loads != 1 {
+		t.Errorf("got %d loads, want 1", b.loads)
+	}
+	if got := config.Global().Authers; len(got) != 1 || got[0].Name != "b" {
+		t.Errorf("the config in the backend is not applied")
+	}
+	if err := SaveBackend(ctx); err != nil {
+		t.Errorf("the config reloaded is not saved: %v", err)
+	}
+}
+
+func TestStartBackend(t *testing.T) {
+	defer Reload(&config.Config{})
+	defer SetBackend(nil, "")
+
+	tests := []struct {
+		name    string
+		remote  string
+		authers string // the authers applied.
+	}{
+		{name: "no remote config", authers: "local"},
+		{
+			name:    "remote config",
+			remote:  "authers:\n- name: remote\n  auths:\n  - username: remote\n    password: pass\n",
+			authers: "remote",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newFakeRedis(t)
+			if tt.remote != "" {
+				r.value = []byte(tt.remote)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			cfg := &config.Config{
+				Authers: []*config.AutherConfig{auther("local", "pass")},
+				Backend: &config.BackendConfig{
+					Redis: &config.RedisLoader{Addr: r.ln.Addr().String()},
+				},
+			}
+			if err := Start(ctx, cfg); err != nil {
+				t.Fatal(err)
+			}
+			if GetBackend() == nil {
+				t.Fatal("the backend is not set")
+			}
+
+			global := config.Global()
+			if len(global.Authers) != 1 || global.Authers[0].Name != tt.authers {
+				t.Errorf("got authers %v, want %s", global.Authers, tt.authers)
+			}
+			if global.Backend != cfg.Backend {
+				t.Error("the backend setting is not kept")
+			}
+		})
+	}
+}
@@ -0,0 +1,515 @@
+package loader
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"syscall"
+
+	"github.com/go-gost/core/admission"
+	"github.com/go-gost/core/auth"
+	"github.com/go-gost/core/bypass"
+	"github.com/go-gost/core/chain"
+	"github.com/go-gost/core/hosts"
+	"github.com/go-gost/core/limiter/conn"
+	"github.com/go-gost/core/limiter/rate"
+	"github.com/go-gost/core/limiter/traffic"
+	"github.com/go-gost/core/logger"
+	"github.com/go-gost/core/recorder"
+	"github.com/go-gost/core/service"
+	"github.com/go-gost/x/config"
+	"github.com/go-gost/x/config/parsing"
+	"github.com/go-gost/x/registry"
+	xservice "github.com/go-gost/x/service"
+)
+
+var (
+	ErrNoConfigFile = errors.New("loader: no config file")
+)
+
+var (
+	reloadMux sync.Mutex
+)
+
+// Start applies the config cfg read at startup by Reload, and keeps it up to date until ctx is done.
+// If cfg has a backend, the config in the backend is applied instead if there is one,
+// and the config is reloaded from the backend every Backend.Reload.
+// Otherwise the config file is reloaded on SIGHUP and when it is changed.
+// The settings not reloaded by Reload are taken from cfg.
+func Start(ctx context.Context, cfg *config.Config) error {
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+	config.SetGlobal(&config.Config{
+		TLS:       cfg.TLS,
+		Log:       cfg.Log,
+		Profiling: cfg.Profiling,
+		API:       cfg.API,
+		Metrics:   cfg.Metrics,
+		Backend:   cfg.Backend,
+	})
+
+	bc, err := config.Expanded(cfg.Backend)
+	if err != nil {
+		return err
+	}
+	if b := NewBackend(bc); b != nil {
+		SetBackend(b, bc.Format)
+
+		bcfg, err := LoadBackend(ctx)
+		if bcfg != nil {
+			cfg = bcfg
+		}
+		err = errors.Join(err, Reload(cfg))
+
+		go WatchBackend(ctx, bc.Reload)
+		return err
+	}
+
+	err = Reload(cfg)
+	if file := config.File(); file != "" {
+		go WatchSignal(ctx, file)
+		if werr := WatchFile(ctx, file); werr != nil {
+			err = errors.Join(err, werr)
+		}
+	}
+
+	return err
+}
+
+// Lock locks the global config against the reloads and the other changes,
+// the changes of a single object such as by the API hold it from checking the object to setting the global config.
+func Lock() {
+	reloadMux.Lock()
+}
+
+// Unlock unlocks the global config locked by Lock.
+func Unlock() {
+	reloadMux.Unlock()
+}
+
+// ReloadFile reads the config file and reloads it by Reload.
+// The file loaded at startup is used if file is empty.
+func ReloadFile(file string) error {
+	if file == "" {
+		file = config.File()
+	}
+	if file == "" {
+		return ErrNoConfigFile
+	}
+
+	cfg := &config.Config{}
+	if err := cfg.ReadFile(file); err != nil {
+		return err
+	}
+	return Reload(cfg)
+}
+
+// Reload applies the config cfg to the running instance by diffing it against the global config.
+// The config is validated by parsing.Validate first, nothing is changed if it is invalid.
+// Only the objects created, changed or removed are created, replaced or removed in the registries,
+// the services not changed keep their listeners and connections.
+// The objects failed to be parsed are skipped and the old ones are kept,
+// the errors of them are joined in the returned error.
+// The log, profiling, API, metrics, backend and TLS settings are not reloaded.
+func Reload(cfg *config.Config) error {
+	reloadMux.Lock()
+	defer reloadMux.Unlock()
+
+	log := logger.Default().WithFields(map[string]any{
+		"kind": "reload",
+	})
+
+	old := config.Global()
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+
+	// the config is kept with the variables for writing, only the copies parsed are expanded.
+	expanded, err := config.Expanded(cfg)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, err := range parsing.Validate(expanded) {
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	merge := func(err error) {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	// the removed services are closed first so that they do not use the objects being removed.
+	// The dependencies are created or replaced before the objects using them.
+	removeServices(old.Services, cfg.Services, log)
+
+	cfg.Authers, err = reload("auther", registry.AutherRegistry(), old.Authers, cfg.Authers,
+		func(c *config.AutherConfig) string { return c.Name },
+		func(c *config.AutherConfig) (auth.Authenticator, error) { return parsing.ParseAuther(c), nil },
+		log)
+	merge(err)
+
+	cfg.Admissions, err = reload("admission", registry.AdmissionRegistry(), old.Admissions, cfg.Admissions,
+		func(c *config.AdmissionConfig) string { return c.Name },
+		func(c *config.AdmissionConfig) (admission.Admission, error) { return parsing.ParseAdmission(c), nil },
+		log)
+	merge(err)
+
+	cfg.Bypasses, err = reload("bypass", registry.BypassRegistry(), old.Bypasses, cfg.Bypasses,
+		func(c *config.BypassConfig) string { return c.Name },
+		func(c *config.BypassConfig) (bypass.Bypass, error) { return parsing.ParseBypass(c), nil },
+		log)
+	merge(err)
+
+	cfg.Resolvers, err = reload("resolver", registry.ResolverRegistry(), old.Resolvers, cfg.Resolvers,
+		func(c *config.ResolverConfig) string { return c.Name },
+		parsing.ParseResolver,
+		log)
+	merge(err)
+
+	cfg.Hosts, err = reload("hosts", registry.HostsRegistry(), old.Hosts, cfg.Hosts,
+		func(c *config.HostsConfig) string { return c.Name },
+		func(c *config.HostsConfig) (hosts.HostMapper, error) { return parsing.ParseHosts(c), nil },
+		log)
+	merge(err)
+
+	cfg.Recorders, err = reload("recorder", registry.RecorderRegistry(), old.Recorders, cfg.Recorders,
+		func(c *config.RecorderConfig) string { return c.Name },
+		func(c *config.RecorderConfig) (recorder.Recorder, error) { return parsing.ParseRecorder(c), nil },
+		log)
+	merge(err)
+
+	cfg.Limiters, err = reload("limiter", registry.TrafficLimiterRegistry(), old.Limiters, cfg.Limiters,
+		func(c *config.LimiterConfig) string { return c.Name },
+		func(c *config.LimiterConfig) (traffic.TrafficLimiter, error) {
+			return parsing.ParseTrafficLimiter(c), nil
+		},
+		log)
+	merge(err)
+
+	cfg.CLimiters, err = reload("climiter", registry.ConnLimiterRegistry(), old.CLimiters, cfg.CLimiters,
+		func(c *config.LimiterConfig) string { return c.Name },
+		func(c *config.LimiterConfig) (conn.ConnLimiter, error) { return parsing.ParseConnLimiter(c), nil },
+		log)
+	merge(err)
+
+	cfg.RLimiters, err = reload("rlimiter", registry.RateLimiterRegistry(), old.RLimiters, cfg.RLimiters,
+		func(c *config.LimiterConfig) string { return c.Name },
+		func(c *config.LimiterConfig) (rate.RateLimiter, error) { return parsing.ParseRateLimiter(c), nil },
+		log)
+	merge(err)
+
+	cfg.Hops, err = reload("hop", registry.HopRegistry(), old.Hops, cfg.Hops,
+		func(c *config.HopConfig) string { return c.Name },
+		parsing.ParseHop,
+		log)
+	merge(err)
+
+	cfg.Chains, err = reload("chain", registry.ChainRegistry(), old.Chains, cfg.Chains,
+		func(c *config.ChainConfig) string { return c.Name },
+		func(c *config.ChainConfig) (chain.Chainer, error) { return parsing.ParseChain(c) },
+		log)
+	merge(err)
+
+	cfg.Services, err = reloadServices(old.Services, cfg.Services, log)
+	merge(err)
+
+	cfg.TLS = old.TLS
+	cfg.Log = old.Log
+	cfg.Profiling = old.Profiling
+	cfg.API = old.API
+	cfg.Metrics = old.Metrics
+	cfg.Backend = old.Backend
+	config.SetGlobal(cfg)
+
+	return errors.Join(errs...)
+}
+
+// reload creates, replaces or removes the objects of the kind in the registry reg,
+// and returns the configs of the objects applied.
+// The old object is kept if the new config of it fails to be parsed.
+func reload[C any, T any](kind string, reg registry.Registry[T], olds, news []*C,
+	name func(*C) string, parse func(*C) (T, error), log logger.Logger) (applied []*C, err error) {
+
+	oldm := make(map[string]*C)
+	for _, c := range olds {
+		if c != nil && name(c) != "" {
+			oldm[name(c)] = c
+		}
+	}
+	newm := make(map[string]bool)
+	for _, c := range news {
+		if c != nil && name(c) != "" {
+			newm[name(c)] = true
+		}
+	}
+
+	for n := range oldm {
+		if !newm[n] {
+			reg.Unregister(n)
+			log.Infof("%s %s removed", kind, n)
+		}
+	}
+
+	var errs []error
+	for _, c := range news {
+		if c == nil || name(c) == "" {
+			continue
+		}
+
+		n := name(c)
+		old := oldm[n]
+		if old != nil && equal(old, c) {
+			applied = append(applied, c)
+			continue
+		}
+
+		v, err := parseExpanded(c, parse)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s %s: %w", kind, n, err))
+			if old != nil {
+				applied = append(applied, old)
+			}
+			continue
+		}
+
+		reg.Unregister(n)
+		if err := reg.Register(n, v); err != nil {
+			errs = append(errs, fmt.Errorf("%s %s: %w", kind, n, err))
+			continue
+		}
+		applied = append(applied, c)
+
+		if old != nil {
+			log.Infof("%s %s replaced", kind, n)
+		} else {
+			log.Infof("%s %s created", kind, n)
+		}
+	}
+
+	return applied, errors.Join(errs...)
+}
+
+// parseExpanded parses the copy of the config c with the variables expanded.
+func parseExpanded[C any, T any](c *C, parse func(*C) (T, error)) (v T, err error) {
+	ec, err := config.Expanded(c)
+	if err != nil {
+		return
+	}
+	return parse(ec)
+}
+
+// removeServices closes the services removed from the config.
+func removeServices(olds, news []*config.ServiceConfig, log logger.Logger) {
+	newm := make(map[string]bool)
+	for _, c := range news {
+		if c != nil && c.Name != "" {
+			newm[c.Name] = true
+		}
+	}
+
+	for _, c := range olds {
+		if c == nil || c.Name == "" || newm[c.Name] {
+			continue
+		}
+		registry.ServiceRegistry().Unregister(c.Name)
+		log.Infof("service %s removed", c.Name)
+	}
+}
+
+// reloadServices creates or replaces the services created or changed, and returns the configs of the services applied.
+// The new service is created before the old one is closed, and replaces it only if it is created successfully.
+// If the listening address is still held by the old service, the old one is closed and the new one is created again,
+// the old one is restored if the new one still fails.
+func reloadServices(olds, news []*config.ServiceConfig, log logger.Logger) (applied []*config.ServiceConfig, err error) {
+	oldm := make(map[string]*config.ServiceConfig)
+	for _, c := range olds {
+		if c != nil && c.Name != "" {
+			oldm[c.Name] = c
+		}
+	}
+
+	var errs []error
+	for _, c := range news {
+		if c == nil || c.Name == "" {
+			continue
+		}
+
+		old := oldm[c.Name]
+		if old != nil && equal(old, c) {
+			applied = append(applied, c)
+			continue
+		}
+
+		svc, err := parseExpanded(c, parsing.ParseService)
+		if err != nil && old != nil && errors.Is(err, syscall.EADDRINUSE) {
+			registry.ServiceRegistry().Unregister(c.Name)
+			if svc, err = parseExpanded(c, parsing.ParseService); err != nil {
+				if restoreService(old, log) {
+					applied = append(applied, old)
+				}
+				errs = append(errs, fmt.Errorf("service %s: %w", c.Name, err))
+				continue
+			}
+		}
+		if err != nil {
+			if old != nil {
+				applied = append(applied, old)
+			}
+			errs = append(errs, fmt.Errorf("service %s: %w", c.Name, err))
+			continue
+		}
+
+		registry.ServiceRegistry().Unregister(c.Name)
+		if err := registry.ServiceRegistry().Register(c.Name, svc); err != nil {
+			svc.Close()
+			errs = append(errs, fmt.Errorf("service %s: %w", c.Name, err))
+			continue
+		}
+		go svc.Serve()
+		applied = append(applied, c)
+
+		if old != nil {
+			log.Infof("service %s replaced", c.Name)
+		} else {
+			log.Infof("service %s created", c.Name)
+		}
+	}
+
+	return applied, errors.Join(errs...)
+}
+
+// ReplaceService creates the service of the config c and replaces the running service of the config old with it,
+// the old service is kept if the new one fails to be created, as it is in Reload.
+// The caller must hold the lock by Lock.
+func ReplaceService(old, c *config.ServiceConfig) error {
+	log := logger.Default().WithFields(map[string]any{
+		"kind": "service",
+	})
+
+	var olds []*config.ServiceConfig
+	if old != nil {
+		olds = append(olds, old)
+	}
+	_, err := reloadServices(olds, []*config.ServiceConfig{c}, log)
+	return err
+}
+
+// restoreService creates the service closed for the failed replacement again.
+func restoreService(c *config.ServiceConfig, log logger.Logger) bool {
+	svc, err := parseExpanded(c, parsing.ParseService)
+	if err != nil {
+		log.Errorf("service %s: restore: %v", c.Name, err)
+		return false
+	}
+	if err := registry.ServiceRegistry().Register(c.Name, svc); err != nil {
+		svc.Close()
+		log.Errorf("service %s: restore: %v", c.Name, err)
+		return false
+	}
+	go svc.Serve()
+	return true
+}
+
+// Shutdown shuts down the services of the global config concurrently and removes them from the registry.
+// Each service stops accepting connections and waits for the active ones up to its grace period or until ctx is done.
+func Shutdown(ctx context.Context) error {
+	reloadMux.Lock()
+	defer reloadMux.Unlock()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var errs []error
+	for _, c := range config.Global().Services {
+		if c == nil || c.Name == "" {
+			continue
+		}
+		svc := registry.ServiceRegistry().Get(c.Name)
+		if svc == nil {
+			continue
+		}
+
+		wg.Add(1)
+		go func(name string, svc service.Service) {
+			defer wg.Done()
+
+			var err error
+			if ds, ok := svc.(xservice.DrainService); ok {
+				err = ds.Shutdown(ctx)
+			} else {
+				err = svc.Close()
+			}
+			registry.ServiceRegistry().Unregister(name)
+
+			if err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("service %s: %w", name, err))
+				mu.Unlock()
+			}
+		}(c.Name, svc)
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// equal reports whether the configs are the same,
+// the configs are compared in JSON to ignore the differences of the numeric types in metadata.
+func equal(a, b any) bool {
+	ja, err := json.Marshal(normalize(a))
+	if err != nil {
+		return false
+	}
+	jb, err := json.Marshal(normalize(b))
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(ja, jb)
+}
+
+// normalize returns a copy of the config with the defaults set as the parsing does,
+// so that the config read from file is comparable with the parsed one.
+func normalize(c any) any {
+	switch v := c.(type) {
+	case *config.ServiceConfig:
+		if cp := clone(v); cp != nil {
+			parsing.SetServiceDefaults(cp)
+			return cp
+		}
+	case *config.HopConfig:
+		if cp := clone(v); cp != nil {
+			parsing.SetHopDefaults(cp)
+			return cp
+		}
+	case *config.ChainConfig:
+		if cp := clone(v); cp != nil {
+			for _, hop := range cp.Hops {
+				if hop != nil {
+					parsing.SetHopDefaults(hop)
+				}
+			}
+			return cp
+		}
+	}
+	return c
+}
+
+func clone[C any](c *C) *C {
+	b, err := json.Marshal(c)
+	if err != nil {
+		return nil
+	}
+	v := new(C)
+	if err := json.Unmarshal(b, v); err != nil {
+		return nil
+	}
+	return v
+}
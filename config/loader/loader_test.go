@@ -0,0 +1,287 @@
+package loader
+
+import (
+	"net"
+	"os"
+	"testing"
+
+	"github.com/go-gost/core/logger"
+	"github.com/go-gost/core/service"
+	"github.com/go-gost/x/config"
+	xlogger "github.com/go-gost/x/logger"
+	"github.com/go-gost/x/registry"
+
+	_ "github.com/go-gost/x/handler/http"
+	_ "github.com/go-gost/x/listener/tcp"
+)
+
+func TestMain(m *testing.M) {
+	logger.SetDefault(xlogger.NewLogger(xlogger.LevelLoggerOption(logger.FatalLevel)))
+	os.Exit(m.Run())
+}
+
+func auther(name, password string) *config.AutherConfig {
+	return &config.AutherConfig{
+		Name:  name,
+		Auths: []*config.AuthConfig{{Username: name, Password: password}},
+	}
+}
+
+func TestReloadDiff(t *testing.T) {
+	defer Reload(&config.Config{})
+
+	tests := []struct {
+		name    string
+		authers []*config.AutherConfig
+		removed []string
+	}{
+		{
+			name:    "create",
+			authers: []*config.AutherConfig{auther("a", "pass"), auther("b", "pass")},
+		},
+		{
+			name:    "unchanged",
+			authers: []*config.AutherConfig{auther("a", "pass"), auther("b", "pass")},
+		},
+		{
+			name:    "change one",
+			authers: []*config.AutherConfig{auther("a", "pass"), auther("b", "changed")},
+		},
+		{
+			name:    "add and remove",
+			authers: []*config.AutherConfig{auther("a", "pass"), auther("c", "pass")},
+			removed: []string{"b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Reload(&config.Config{Authers: tt.authers}); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, c := range tt.authers {
+				if !registry.AutherRegistry().IsRegistered(c.Name) {
+					t.Fatalf("auther %s is not registered", c.Name)
+				}
+				// the auther registered is the one of the current config.
+				if !registry.AutherRegistry().Get(c.Name).Authenticate(c.Name, c.Auths[0].Password) {
+					t.Errorf("auther %s is not applied", c.Name)
+				}
+			}
+			for _, name := range tt.removed {
+				if registry.AutherRegistry().IsRegistered(name) {
+					t.Errorf("auther %s is registered, want removed", name)
+				}
+			}
+			if got := len(config.Global().Authers); got != len(tt.authers) {
+				t.Errorf("got %d authers in the global config, want %d", got, len(tt.authers))
+			}
+		})
+	}
+}
+
+func TestReloadInvalid(t *testing.T) {
+	defer Reload(&config.Config{})
+
+	if err := Reload(&config.Config{Authers: []*config.AutherConfig{auther("a", "pass")}}); err != nil {
+		t.Fatal(err)
+	}
+
+	err := Reload(&config.Config{
+		Authers: []*config.AutherConfig{auther("a", "changed"), auther("b", "pass")},
+		Services: []*config.ServiceConfig{{
+			Name:     "svc",
+			Addr:     "127.0.0.1:0",
+			Handler:  &config.HandlerConfig{Type: "http", Chain: "missing"},
+			Listener: &config.ListenerConfig{Type: "tcp"},
+		}},
+	})
+	if err == nil {
+		t.Fatal("the invalid config is applied")
+	}
+	if !registry.AutherRegistry().Get("a").Authenticate("a", "pass") {
+		t.Error("auther a is changed by the invalid config")
+	}
+	if registry.AutherRegistry().IsRegistered("b") {
+		t.Error("auther b is registered by the invalid config")
+	}
+	if got := config.Global().Authers; len(got) != 1 || got[0].Name != "a" {
+		t.Error("the global config is changed by the invalid config")
+	}
+}
+
+func TestReloadServices(t *testing.T) {
+	defer Reload(&config.Config{})
+
+	svcConfig := func(addr string, md map[string]any) *config.ServiceConfig {
+		return &config.ServiceConfig{
+			Name:     "svc",
+			Addr:     addr,
+			Handler:  &config.HandlerConfig{Type: "http", Metadata: md},
+			Listener: &config.ListenerConfig{Type: "tcp"},
+		}
+	}
+
+	if err := Reload(&config.Config{Services: []*config.ServiceConfig{svcConfig("127.0.0.1:0", nil)}}); err != nil {
+		t.Fatal(err)
+	}
+	svc := registry.ServiceRegistry().Get("svc")
+	if svc == nil {
+		t.Fatal("service is not created")
+	}
+	addr := svc.Addr().String()
+
+	// the service not changed keeps its listener.
+	if err := Reload(&config.Config{Services: []*config.ServiceConfig{svcConfig("127.0.0.1:0", nil)}}); err != nil {
+		t.Fatal(err)
+	}
+	if registry.ServiceRegistry().Get("svc") != svc {
+		t.Fatal("the unchanged service is replaced")
+	}
+
+	// the changed service is replaced on the same address.
+	if err := Reload(&config.Config{Services: []*config.ServiceConfig{svcConfig(addr, map[string]any{"keepalive": true})}}); err != nil {
+		t.Fatal(err)
+	}
+	replaced := registry.ServiceRegistry().Get("svc")
+	if replaced == nil || replaced == svc {
+		t.Fatal("the changed service is not replaced")
+	}
+	if got := replaced.Addr().String(); got != addr {
+		t.Errorf("got service address %s, want %s", got, addr)
+	}
+
+	// the service failing to listen is not applied, and the old one is kept.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	if err := Reload(&config.Config{Services: []*config.ServiceConfig{svcConfig(ln.Addr().String(), nil)}}); err == nil {
+		t.Fatal("the service listening on the address in use is created")
+	}
+	var cur service.Service
+	if cur = registry.ServiceRegistry().Get("svc"); cur == nil {
+		t.Fatal("the old service is removed")
+	}
+	if got := cur.Addr().String(); got != addr {
+		t.Errorf("got service address %s, want %s", got, addr)
+	}
+	if got := config.Global().Services; len(got) != 1 || got[0].Addr != addr {
+		t.Errorf("got services %v in the global config, want the old one", got)
+	}
+
+	// the removed service is closed.
+	if err := Reload(&config.Config{}); err != nil {
+		t.Fatal(err)
+	}
+	if registry.ServiceRegistry().IsRegistered("svc") {
+		t.Error("the removed service is registered")
+	}
+}
+
+func TestEqual(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b any
+		want bool
+	}{
+		{
+			name: "numeric types in metadata",
+			a:    &config.ServiceConfig{Name: "s", Metadata: map[string]any{"n": 1}},
+			b:    &config.ServiceConfig{Name: "s", Metadata: map[string]any{"n": 1.0}},
+			want: true,
+		},
+		{
+			name: "different",
+			a:    &config.AutherConfig{Name: "a", Auths: []*config.AuthConfig{{Username: "u"}}},
+			b:    &config.AutherConfig{Name: "a", Auths: []*config.AuthConfig{{Username: "v"}}},
+			want: false,
+		},
+		{
+			name: "service defaults",
+			a:    &config.ServiceConfig{Name: "s", Addr: ":8080"},
+			b: &config.ServiceConfig{
+				Name:     "s",
+				Addr:     ":8080",
+				Handler:  &config.HandlerConfig{Type: "auto"},
+				Listener: &config.ListenerConfig{Type: "tcp"},
+			},
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := equal(tt.a, tt.b); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReloadExpand(t *testing.T) {
+	defer Reload(&config.Config{})
+	t.Setenv("GOST_TEST_PASSWORD", "s3cret")
+
+	if err := Reload(&config.Config{Authers: []*config.AutherConfig{auther("a", "${GOST_TEST_PASSWORD}")}}); err != nil {
+		t.Fatal(err)
+	}
+	if !registry.AutherRegistry().Get("a").Authenticate("a", "s3cret") {
+		t.Error("the auther is not parsed from the expanded config")
+	}
+	if got := config.Global().Authers[0].Auths[0].Password; got != "${GOST_TEST_PASSWORD}" {
+		t.Errorf("got password %q in the global config, want the variable", got)
+	}
+
+	if err := Reload(&config.Config{Authers: []*config.AutherConfig{auther("a", "${GOST_TEST_NOT_SET}")}}); err == nil {
+		t.Error("the config with the variable not set is applied")
+	}
+	if !registry.AutherRegistry().Get("a").Authenticate("a", "s3cret") {
+		t.Error("the auther is changed by the config failed to be expanded")
+	}
+}
+
+func TestReplaceService(t *testing.T) {
+	defer Reload(&config.Config{})
+
+	old := &config.ServiceConfig{
+		Name:     "svc",
+		Addr:     "127.0.0.1:0",
+		Handler:  &config.HandlerConfig{Type: "http"},
+		Listener: &config.ListenerConfig{Type: "tcp"},
+	}
+	if err := Reload(&config.Config{Services: []*config.ServiceConfig{old}}); err != nil {
+		t.Fatal(err)
+	}
+	svc := registry.ServiceRegistry().Get("svc")
+
+	// the old service keeps running if the new one fails to be created.
+	c := *old
+	c.Handler = &config.HandlerConfig{Type: "unknown"}
+	if err := ReplaceService(old, &c); err == nil {
+		t.Fatal("the service with unknown handler is created")
+	}
+	if registry.ServiceRegistry().Get("svc") != svc {
+		t.Fatal("the old service is replaced")
+	}
+	conn, err := net.Dial("tcp", svc.Addr().String())
+	if err != nil {
+		t.Fatalf("the old service is closed: %v", err)
+	}
+	conn.Close()
+
+	// the new service takes the address of the old one.
+	c = *old
+	c.Addr = svc.Addr().String()
+	c.Handler = &config.HandlerConfig{Type: "http", Metadata: map[string]any{"keepalive": true}}
+	if err := ReplaceService(old, &c); err != nil {
+		t.Fatal(err)
+	}
+	replaced := registry.ServiceRegistry().Get("svc")
+	if replaced == nil || replaced == svc {
+		t.Fatal("the service is not replaced")
+	}
+	if got := replaced.Addr().String(); got != c.Addr {
+		t.Errorf("got service address %s, want %s", got, c.Addr)
+	}
+}
@@ -0,0 +1,103 @@
+package loader
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/go-gost/core/logger"
+)
+
+const (
+	// watchDelay is the delay of reloading after the file is changed,
+	// as a file may be written by several operations.
+	watchDelay = time.Second
+)
+
+// WatchSignal reloads the config file by ReloadFile on SIGHUP until ctx is done.
+func WatchSignal(ctx context.Context, file string) {
+	log := logger.Default().WithFields(map[string]any{
+		"kind": "reload",
+	})
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGHUP)
+	defer signal.Stop(sigs)
+
+	for {
+		select {
+		case <-sigs:
+			log.Info("SIGHUP received, reloading")
+			if err := ReloadFile(file); err != nil {
+				log.Error(err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// WatchFile reloads the config file by ReloadFile when it is changed until ctx is done.
+// The directory of the file is watched so that the file replaced by the editors is also detected.
+func WatchFile(ctx context.Context, file string) error {
+	log := logger.Default().WithFields(map[string]any{
+		"kind": "reload",
+	})
+
+	file, err := filepath.Abs(file)
+	if err != nil {
+		return err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	if err := watcher.Add(filepath.Dir(file)); err != nil {
+		watcher.Close()
+		return err
+	}
+
+	go func() {
+		defer watcher.Close()
+
+		timer := time.NewTimer(0)
+		<-timer.C
+		defer timer.Stop()
+
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) != file ||
+					event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+					continue
+				}
+				timer.Reset(watchDelay)
+
+			case <-timer.C:
+				log.Infof("%s changed, reloading", file)
+				if err := ReloadFile(file); err != nil {
+					log.Error(err)
+				}
+
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Error(err)
+
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return nil
+}
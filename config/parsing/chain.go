@@ -2,6 +2,7 @@ package parsing
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/go-gost/core/bypass"
@@ -57,6 +58,38 @@ func ParseChain(cfg *config.ChainConfig) (chain.Chainer, error) {
 	return c, nil
 }
 
+// SetHopDefaults sets the default connector and dialer of the nodes,
+// and the settings of the nodes inherited from the hop.
+func SetHopDefaults(cfg *config.HopConfig) {
+	for _, v := range cfg.Nodes {
+		if v == nil {
+			continue
+		}
+
+		if v.Connector == nil {
+			v.Connector = &config.ConnectorConfig{
+				Type: "http",
+			}
+			v.Dialer = &config.DialerConfig{
+				Type: "tcp",
+			}
+		}
+
+		if v.Resolver == "" {
+			v.Resolver = cfg.Resolver
+		}
+		if v.Hosts == "" {
+			v.Hosts = cfg.Hosts
+		}
+		if v.Interface == "" {
+			v.Interface = cfg.Interface
+		}
+		if v.SockOpts == nil {
+			v.SockOpts = cfg.SockOpts
+		}
+	}
+}
+
 func ParseHop(cfg *config.HopConfig) (chain.Hop, error) {
 	if cfg == nil {
 		return nil, nil
@@ -67,21 +100,14 @@ func ParseHop(cfg *config.HopConfig) (chain.Hop, error) {
 		"hop":  cfg.Name,
 	})
 
+	SetHopDefaults(cfg)
+
 	var nodes []*chain.Node
 	for _, v := range cfg.Nodes {
 		if v == nil {
 			continue
 		}
 
-		if v.Connector == nil {
-			v.Connector = &config.ConnectorConfig{
-				Type: "http",
-			}
-			v.Dialer = &config.DialerConfig{
-				Type: "tcp",
-			}
-		}
-
 		nodeLogger := hopLogger.WithFields(map[string]any{
 			"kind":      "node",
 			"node":      v.Name,
@@ -169,19 +195,6 @@ func ParseHop(cfg *config.HopConfig) (chain.Hop, error) {
 			return nil, err
 		}
 
-		if v.Resolver == "" {
-			v.Resolver = cfg.Resolver
-		}
-		if v.Hosts == "" {
-			v.Hosts = cfg.Hosts
-		}
-		if v.Interface == "" {
-			v.Interface = cfg.Interface
-		}
-		if v.SockOpts == nil {
-			v.SockOpts = cfg.SockOpts
-		}
-
 		var sockOpts *chain.SockOpts
 		if v.SockOpts != nil {
 			sockOpts = &chain.SockOpts{
@@ -189,19 +202,20 @@ func ParseHop(cfg *config.HopConfig) (chain.Hop, error) {
 			}
 		}
 
+		addr, fallbacks := parseNodeAddr(v.Addr)
 		tr := chain.NewTransport(d, cr,
-			chain.AddrTransportOption(v.Addr),
+			chain.AddrTransportOption(addr),
 			chain.InterfaceTransportOption(v.Interface),
 			chain.SockOptsTransportOption(sockOpts),
 			chain.TimeoutTransportOption(10*time.Second),
 		)
 
-		node := chain.NewNode(v.Name, v.Addr,
+		node := chain.NewNode(v.Name, addr,
 			chain.TransportNodeOption(tr),
 			chain.BypassNodeOption(bypass.BypassGroup(bypassList(v.Bypass, v.Bypasses...)...)),
 			chain.ResoloverNodeOption(registry.ResolverRegistry().Get(v.Resolver)),
 			chain.HostMapperNodeOption(registry.HostsRegistry().Get(v.Hosts)),
-			chain.MetadataNodeOption(nm),
+			chain.MetadataNodeOption(xchain.FallbackAddrsMetadata(nm, fallbacks...)),
 		)
 		nodes = append(nodes, node)
 	}
@@ -216,3 +230,19 @@ func ParseHop(cfg *config.HopConfig) (chain.Hop, error) {
 		xchain.LoggerHopOption(hopLogger),
 	), nil
 }
+
+// parseNodeAddr parses the node address which may be a comma separated list,
+// the first one is the address of the node and the others are its fallback addresses.
+func parseNodeAddr(s string) (addr string, fallbacks []string) {
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v == "" {
+			continue
+		}
+		if addr == "" {
+			addr = v
+		} else {
+			fallbacks = append(fallbacks, v)
+		}
+	}
+	return
+}
@@ -0,0 +1,26 @@
+package parsing
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseNodeAddr(t *testing.T) {
+	tests := []struct {
+		s         string
+		addr      string
+		fallbacks []string
+	}{
+		{s: "", addr: ""},
+		{s: "a:1", addr: "a:1"},
+		{s: "a:1,b:2", addr: "a:1", fallbacks: []string{"b:2"}},
+		{s: " a:1 , b:2 ,, c:3 ", addr: "a:1", fallbacks: []string{"b:2", "c:3"}},
+		{s: ",a:1", addr: "a:1"},
+	}
+	for _, tt := range tests {
+		addr, fallbacks := parseNodeAddr(tt.s)
+		if addr != tt.addr || !reflect.DeepEqual(fallbacks, tt.fallbacks) {
+			t.Errorf("%q: got %q %v, want %q %v", tt.s, addr, fallbacks, tt.addr, tt.fallbacks)
+		}
+	}
+}
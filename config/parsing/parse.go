@@ -36,6 +36,7 @@ const (
 	mdKeyInterface     = "interface"
 	mdKeySoMark        = "so_mark"
 	mdKeyHash          = "hash"
+	mdKeyGracePeriod   = "gracePeriod"
 )
 
 func ParseAuther(cfg *config.AutherConfig) auth.Authenticator {
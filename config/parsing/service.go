@@ -5,6 +5,7 @@ import (
 	"net"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-gost/core/admission"
 	"github.com/go-gost/core/auth"
@@ -18,15 +19,19 @@ import (
 	"github.com/go-gost/core/selector"
 	"github.com/go-gost/core/service"
 	"github.com/go-gost/core/sniff/stun"
+	xauth "github.com/go-gost/x/auth"
+	xbypass "github.com/go-gost/x/bypass"
 	xchain "github.com/go-gost/x/chain"
 	"github.com/go-gost/x/config"
-	tls_util "github.com/go-gost/x/internal/util/tls"
+	xhandler "github.com/go-gost/x/handler"
 	"github.com/go-gost/x/metadata"
+	xrecorder "github.com/go-gost/x/recorder"
 	"github.com/go-gost/x/registry"
 	xservice "github.com/go-gost/x/service"
 )
 
-func ParseService(cfg *config.ServiceConfig) (service.Service, error) {
+// SetServiceDefaults sets the default listener and handler of the service.
+func SetServiceDefaults(cfg *config.ServiceConfig) {
 	if cfg.Listener == nil {
 		cfg.Listener = &config.ListenerConfig{
 			Type: "tcp",
@@ -37,6 +42,10 @@ func ParseService(cfg *config.ServiceConfig) (service.Service, error) {
 			Type: "auto",
 		}
 	}
+}
+
+func ParseService(cfg *config.ServiceConfig) (service.Service, error) {
+	SetServiceDefaults(cfg)
 	serviceLogger := logger.Default().WithFields(map[string]any{
 		"kind":     "service",
 		"service":  cfg.Name,
@@ -48,12 +57,7 @@ func ParseService(cfg *config.ServiceConfig) (service.Service, error) {
 		"kind": "listener",
 	})
 
-	tlsCfg := cfg.Listener.TLS
-	if tlsCfg == nil {
-		tlsCfg = &config.TLSConfig{}
-	}
-	tlsConfig, err := tls_util.LoadServerConfig(
-		tlsCfg.CertFile, tlsCfg.KeyFile, tlsCfg.CAFile)
+	tlsConfig, err := loadServerTLSConfig(cfg.Listener.TLS, listenerLogger)
 	if err != nil {
 		listenerLogger.Error(err)
 		return nil, err
@@ -70,7 +74,7 @@ func ParseService(cfg *config.ServiceConfig) (service.Service, error) {
 	}
 	var auther auth.Authenticator
 	if len(authers) > 0 {
-		auther = auth.AuthenticatorGroup(authers...)
+		auther = xauth.AuthenticatorGroup(authers...)
 	}
 
 	admissions := admissionList(cfg.Admission, cfg.Admissions...)
@@ -84,10 +88,12 @@ func ParseService(cfg *config.ServiceConfig) (service.Service, error) {
 
 	var STUN *stun.Spoof
 	var ppv int
+	var gracePeriod time.Duration
 	ifce := cfg.Interface
 	if cfg.Metadata != nil {
 		md := metadata.NewMetadata(cfg.Metadata)
 		ppv = mdutil.GetInt(md, mdKeyProxyProtocol)
+		gracePeriod = mdutil.GetDuration(md, mdKeyGracePeriod)
 		if v := mdutil.GetString(md, mdKeyInterface); v != "" {
 			ifce = v
 		}
@@ -152,12 +158,7 @@ func ParseService(cfg *config.ServiceConfig) (service.Service, error) {
 		"kind": "handler",
 	})
 
-	tlsCfg = cfg.Handler.TLS
-	if tlsCfg == nil {
-		tlsCfg = &config.TLSConfig{}
-	}
-	tlsConfig, err = tls_util.LoadServerConfig(
-		tlsCfg.CertFile, tlsCfg.KeyFile, tlsCfg.CAFile)
+	tlsConfig, err = loadServerTLSConfig(cfg.Handler.TLS, handlerLogger)
 	if err != nil {
 		handlerLogger.Error(err)
 		return nil, err
@@ -175,26 +176,24 @@ func ParseService(cfg *config.ServiceConfig) (service.Service, error) {
 
 	auther = nil
 	if len(authers) > 0 {
-		auther = auth.AuthenticatorGroup(authers...)
+		auther = xauth.AuthenticatorGroup(authers...)
 	}
 
-	var recorders []recorder.RecorderObject
-	for _, r := range cfg.Recorders {
-		recorders = append(recorders, recorder.RecorderObject{
-			Recorder: registry.RecorderRegistry().Get(r.Name),
-			Record:   r.Record,
-		})
-	}
-	router := chain.NewRouter(
+	// the recorders can be changed at runtime by the recorder group.
+	recorders := xrecorder.NewGroup(ParseRecorderObjects(cfg.Recorders)...)
+	routerOpts := []chain.RouterOption{
 		chain.RetriesRouterOption(cfg.Handler.Retries),
 		// chain.TimeoutRouterOption(10*time.Second),
 		chain.InterfaceRouterOption(ifce),
 		chain.SockOptsRouterOption(sockOpts),
-		chain.ChainRouterOption(chainGroup(cfg.Handler.Chain, cfg.Handler.ChainGroup)),
-		chain.ResolverRouterOption(registry.ResolverRegistry().Get(cfg.Resolver)),
+		// the resolver of the service is passed to the routes by the service,
+		// so that the destination address is resolved to all its addresses.
 		chain.HostMapperRouterOption(registry.HostsRegistry().Get(cfg.Hosts)),
-		chain.RecordersRouterOption(recorders...),
+		chain.RecordersRouterOption(recorders.Objects()...),
 		chain.LoggerRouterOption(handlerLogger),
+	}
+	router := chain.NewRouter(append(routerOpts,
+		chain.ChainRouterOption(chainGroup(cfg.Handler.Chain, cfg.Handler.ChainGroup)))...,
 	)
 
 	var h handler.Handler
@@ -203,7 +202,7 @@ func ParseService(cfg *config.ServiceConfig) (service.Service, error) {
 			handler.RouterOption(router),
 			handler.AutherOption(auther),
 			handler.AuthOption(parseAuth(cfg.Handler.Auth)),
-			handler.BypassOption(bypass.BypassGroup(bypassList(cfg.Bypass, cfg.Bypasses...)...)),
+			handler.BypassOption(xbypass.BypassGroup(bypassList(cfg.Bypass, cfg.Bypasses...)...)),
 			handler.TLSConfigOption(tlsConfig),
 			handler.RateLimiterOption(registry.RateLimiterRegistry().Get(cfg.RLimiter)),
 			handler.LoggerOption(handlerLogger),
@@ -213,6 +212,10 @@ func ParseService(cfg *config.ServiceConfig) (service.Service, error) {
 		return nil, fmt.Errorf("unregistered handler: %s", cfg.Handler.Type)
 	}
 
+	if ur, ok := h.(xhandler.UserRouter); ok && len(cfg.Handler.Routes) > 0 {
+		ur.RouteUsers(parseUserRoutes(cfg.Handler.Routes, routerOpts)...)
+	}
+
 	if forwarder, ok := h.(handler.Forwarder); ok {
 		hop, err := parseForwarder(cfg.Forwarder)
 		if err != nil {
@@ -234,6 +237,10 @@ func ParseService(cfg *config.ServiceConfig) (service.Service, error) {
 	}
 	s := xservice.NewService(cfg.Name, ln, h, *STUN,
 		xservice.AdmissionOption(admission.AdmissionGroup(admissions...)),
+		xservice.RecordersOption(recorders.Objects()...),
+		xservice.RecorderGroupOption(recorders),
+		xservice.GracePeriodOption(gracePeriod),
+		xservice.ResolverOption(registry.ResolverRegistry().Get(cfg.Resolver)),
 		xservice.LoggerOption(serviceLogger),
 	)
 
@@ -241,6 +248,21 @@ func ParseService(cfg *config.ServiceConfig) (service.Service, error) {
 	return s, nil
 }
 
+// ParseRecorderObjects parses the recorder objects referring to the recorders in the registry.
+func ParseRecorderObjects(cfgs []*config.RecorderObject) []recorder.RecorderObject {
+	var objects []recorder.RecorderObject
+	for _, r := range cfgs {
+		if r == nil {
+			continue
+		}
+		objects = append(objects, recorder.RecorderObject{
+			Recorder: registry.RecorderRegistry().Get(r.Name),
+			Record:   r.Record,
+		})
+	}
+	return objects
+}
+
 func parseForwarder(cfg *config.ForwarderConfig) (chain.Hop, error) {
 	if cfg == nil {
 		return nil, nil
@@ -282,6 +304,30 @@ func parseForwarder(cfg *config.ForwarderConfig) (chain.Hop, error) {
 	return registry.HopRegistry().Get(hc.Name), nil
 }
 
+func parseUserRoutes(cfgs []*config.UserRouteConfig, routerOpts []chain.RouterOption) (routes []*xhandler.UserRoute) {
+	for _, cfg := range cfgs {
+		if cfg == nil {
+			continue
+		}
+
+		route := &xhandler.UserRoute{
+			Users:       cfg.Users,
+			Auther:      registry.AutherRegistry().Get(cfg.Auther),
+			RateLimiter: registry.RateLimiterRegistry().Get(cfg.RLimiter),
+		}
+		if cfg.Chain != "" || cfg.ChainGroup != nil {
+			route.Router = chain.NewRouter(append(routerOpts[:len(routerOpts):len(routerOpts)],
+				chain.ChainRouterOption(chainGroup(cfg.Chain, cfg.ChainGroup)))...,
+			)
+		}
+		if bypasses := bypassList(cfg.Bypass, cfg.Bypasses...); len(bypasses) > 0 {
+			route.Bypass = xbypass.BypassGroup(bypasses...)
+		}
+		routes = append(routes, route)
+	}
+	return
+}
+
 func bypassList(name string, names ...string) []bypass.Bypass {
 	var bypasses []bypass.Bypass
 	if bp := registry.BypassRegistry().Get(name); bp != nil {
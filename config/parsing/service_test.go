@@ -0,0 +1,55 @@
+package parsing
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-gost/core/chain"
+	"github.com/go-gost/x/config"
+	xlogger "github.com/go-gost/x/logger"
+)
+
+func TestParseUserRoutes(t *testing.T) {
+	routerOpts := make([]chain.RouterOption, 2, 3)
+	routerOpts[0] = chain.RetriesRouterOption(3)
+	routerOpts[1] = chain.LoggerRouterOption(xlogger.Nop())
+
+	routes := parseUserRoutes([]*config.UserRouteConfig{
+		{Users: []string{"alice", "bob"}, Chain: "chain-0", Bypasses: []string{"bypass-0"}},
+		nil,
+		{Auther: "auther-0", RLimiter: "limiter-0"},
+	}, routerOpts)
+	if len(routes) != 2 {
+		t.Fatalf("got %d routes, want 2", len(routes))
+	}
+
+	r := routes[0]
+	if !reflect.DeepEqual(r.Users, []string{"alice", "bob"}) {
+		t.Errorf("got users %v", r.Users)
+	}
+	if r.Router == nil || r.Router.Options().Chain == nil {
+		t.Error("the route with chain has no router of the chain")
+	} else if r.Router.Options().Retries != 3 {
+		t.Errorf("got retries %d, want the one of the handler", r.Router.Options().Retries)
+	}
+	if r.Bypass == nil {
+		t.Error("the route with bypass has no bypass")
+	}
+	if r.Auther != nil || r.RateLimiter != nil {
+		t.Error("got auther or rate limiter not configured")
+	}
+
+	r = routes[1]
+	if r.Auther == nil || r.RateLimiter == nil {
+		t.Error("the auther or rate limiter is not set")
+	}
+	// the default router and bypass of the handler are used.
+	if r.Router != nil || r.Bypass != nil {
+		t.Error("got router or bypass not configured")
+	}
+
+	// the router options of the handler are not changed by the routes.
+	if len(routerOpts) != 2 || routerOpts[:3][2] != nil {
+		t.Error("the router options of the handler are changed")
+	}
+}
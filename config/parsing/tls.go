@@ -12,6 +12,7 @@ import (
 
 	"github.com/go-gost/core/logger"
 	"github.com/go-gost/x/config"
+	tls_util "github.com/go-gost/x/internal/util/tls"
 )
 
 var (
@@ -28,7 +29,15 @@ func BuildDefaultTLSConfig(cfg *config.TLSConfig) {
 		}
 	}
 
-	tlsConfig, err := loadConfig(cfg.CertFile, cfg.KeyFile)
+	var tlsConfig *tls.Config
+	var err error
+	if cfg.ACME != nil {
+		tlsConfig, err = tls_util.NewACMEConfig(acmeOptions(cfg.ACME), "", log)
+	} else {
+		tlsConfig, err = tls_util.NewServerConfig(
+			[]tls_util.CertPair{{CertFile: cfg.CertFile, KeyFile: cfg.KeyFile}},
+			"", cfg.Reload, log)
+	}
 	if err != nil {
 		// generate random self-signed certificate.
 		cert, err := genCertificate(cfg.Validity, cfg.Organization, cfg.CommonName)
@@ -45,17 +54,52 @@ func BuildDefaultTLSConfig(cfg *config.TLSConfig) {
 	defaultTLSConfig = tlsConfig
 }
 
-func loadConfig(certFile, keyFile string) (*tls.Config, error) {
-	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
-	if err != nil {
-		return nil, err
+// ParseServerTLSConfig loads the server TLS config such as the one of the web API,
+// it returns nil if no certificate is specified.
+func ParseServerTLSConfig(cfg *config.TLSConfig) (*tls.Config, error) {
+	return loadServerTLSConfig(cfg, logger.Default())
+}
+
+// loadServerTLSConfig loads the server TLS config with the certificates in cfg,
+// or obtains the certificates from the ACME server if no certificate is specified.
+// It returns nil if neither is specified.
+func loadServerTLSConfig(cfg *config.TLSConfig, log logger.Logger) (*tls.Config, error) {
+	if cfg == nil {
+		return nil, nil
 	}
 
-	cfg := &tls.Config{
-		Certificates: []tls.Certificate{cert},
+	var pairs []tls_util.CertPair
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		pairs = append(pairs, tls_util.CertPair{
+			CertFile: cfg.CertFile,
+			KeyFile:  cfg.KeyFile,
+		})
+	}
+	for _, c := range cfg.Certificates {
+		if c == nil {
+			continue
+		}
+		pairs = append(pairs, tls_util.CertPair{
+			CertFile: c.CertFile,
+			KeyFile:  c.KeyFile,
+		})
+	}
+
+	if len(pairs) == 0 && cfg.ACME != nil {
+		return tls_util.NewACMEConfig(acmeOptions(cfg.ACME), cfg.CAFile, log)
 	}
 
-	return cfg, nil
+	return tls_util.NewServerConfig(pairs, cfg.CAFile, cfg.Reload, log)
+}
+
+func acmeOptions(cfg *config.ACMEConfig) tls_util.ACMEOptions {
+	return tls_util.ACMEOptions{
+		Domains:   cfg.Domains,
+		Email:     cfg.Email,
+		Directory: cfg.Directory,
+		CacheDir:  cfg.CacheDir,
+		CAFile:    cfg.CAFile,
+	}
 }
 
 func genCertificate(validity time.Duration, org string, cn string) (cert tls.Certificate, err error) {
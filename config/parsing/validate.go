@@ -0,0 +1,500 @@
+package parsing
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-gost/x/admission"
+	"github.com/go-gost/x/bypass"
+	"github.com/go-gost/x/config"
+	tls_util "github.com/go-gost/x/internal/util/tls"
+	xconn "github.com/go-gost/x/limiter/conn"
+	xrate "github.com/go-gost/x/limiter/rate"
+	xtraffic "github.com/go-gost/x/limiter/traffic"
+	"github.com/go-gost/x/registry"
+)
+
+// ValidationError is a problem of the config found by Validate.
+type ValidationError struct {
+	// Path is the path of the invalid field in the config, such as services[2].handler.chain.
+	Path string
+	Err  error
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Path, e.Err)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// Validate checks the config and returns all the problems found, it returns nil if the config is valid.
+// The references to the named objects are resolved in the config itself,
+// no object is created and the registries are not changed.
+func Validate(cfg *config.Config) []*ValidationError {
+	if cfg == nil {
+		return nil
+	}
+
+	v := &validator{
+		names: make(map[string]map[string]bool),
+	}
+
+	collect(v, "chains", "chain", cfg.Chains, func(c *config.ChainConfig) string { return c.Name })
+	collect(v, "hops", "hop", cfg.Hops, func(c *config.HopConfig) string { return c.Name })
+	collect(v, "authers", "auther", cfg.Authers, func(c *config.AutherConfig) string { return c.Name })
+	collect(v, "admissions", "admission", cfg.Admissions, func(c *config.AdmissionConfig) string { return c.Name })
+	collect(v, "bypasses", "bypass", cfg.Bypasses, func(c *config.BypassConfig) string { return c.Name })
+	collect(v, "resolvers", "resolver", cfg.Resolvers, func(c *config.ResolverConfig) string { return c.Name })
+	collect(v, "hosts", "hosts", cfg.Hosts, func(c *config.HostsConfig) string { return c.Name })
+	collect(v, "recorders", "recorder", cfg.Recorders, func(c *config.RecorderConfig) string { return c.Name })
+	collect(v, "limiters", "limiter", cfg.Limiters, func(c *config.LimiterConfig) string { return c.Name })
+	collect(v, "climiters", "climiter", cfg.CLimiters, func(c *config.LimiterConfig) string { return c.Name })
+	collect(v, "rlimiters", "rlimiter", cfg.RLimiters, func(c *config.LimiterConfig) string { return c.Name })
+	collect(v, "services", "service", cfg.Services, func(c *config.ServiceConfig) string { return c.Name })
+
+	for i, c := range cfg.Services {
+		if c != nil {
+			v.service(fmt.Sprintf("services[%d]", i), c)
+		}
+	}
+	for i, c := range cfg.Chains {
+		if c == nil {
+			continue
+		}
+		for j, hop := range c.Hops {
+			if hop == nil {
+				continue
+			}
+			path := fmt.Sprintf("chains[%d].hops[%d]", i, j)
+			if len(hop.Nodes) > 0 {
+				v.hop(path, hop)
+			} else {
+				v.ref(path+".name", "hop", hop.Name)
+			}
+		}
+	}
+	for i, c := range cfg.Hops {
+		if c != nil {
+			v.hop(fmt.Sprintf("hops[%d]", i), c)
+		}
+	}
+	for i, c := range cfg.Authers {
+		if c != nil {
+			v.loaders(fmt.Sprintf("authers[%d]", i), c.Reload, c.HTTP)
+		}
+	}
+	for i, c := range cfg.Admissions {
+		if c == nil {
+			continue
+		}
+		path := fmt.Sprintf("admissions[%d]", i)
+		for j, m := range c.Matchers {
+			v.check(fmt.Sprintf("%s.matchers[%d]", path, j), admission.ValidateMatcher(m))
+		}
+		v.loaders(path, c.Reload, c.HTTP)
+	}
+	for i, c := range cfg.Bypasses {
+		if c == nil {
+			continue
+		}
+		path := fmt.Sprintf("bypasses[%d]", i)
+		for j, m := range c.Matchers {
+			v.check(fmt.Sprintf("%s.matchers[%d]", path, j), bypass.ValidateMatcher(m))
+		}
+		v.loaders(path, c.Reload, c.HTTP)
+	}
+	for i, c := range cfg.Resolvers {
+		if c != nil {
+			v.resolver(fmt.Sprintf("resolvers[%d]", i), c)
+		}
+	}
+	for i, c := range cfg.Hosts {
+		if c == nil {
+			continue
+		}
+		path := fmt.Sprintf("hosts[%d]", i)
+		for j, m := range c.Mappings {
+			if m == nil {
+				continue
+			}
+			if m.Hostname == "" {
+				v.errorf(fmt.Sprintf("%s.mappings[%d].hostname", path, j), "empty hostname")
+			}
+			if net.ParseIP(m.IP) == nil {
+				v.errorf(fmt.Sprintf("%s.mappings[%d].ip", path, j), "invalid IP address %q", m.IP)
+			}
+		}
+		v.loaders(path, c.Reload, c.HTTP)
+	}
+	for i, c := range cfg.Recorders {
+		if c == nil {
+			continue
+		}
+		if (c.File == nil || c.File.Path == "") &&
+			(c.Redis == nil || c.Redis.Addr == "" || c.Redis.Key == "") {
+			v.errorf(fmt.Sprintf("recorders[%d]", i), "no file or redis recorder")
+		}
+	}
+	v.limiters("limiters", cfg.Limiters, xtraffic.ValidateLimit)
+	v.limiters("climiters", cfg.CLimiters, xconn.ValidateLimit)
+	v.limiters("rlimiters", cfg.RLimiters, xrate.ValidateLimit)
+
+	if cfg.TLS != nil && (cfg.TLS.CertFile != "" || cfg.TLS.KeyFile != "" || len(cfg.TLS.Certificates) > 0) {
+		v.serverTLS("tls", cfg.TLS)
+	}
+	if cfg.API != nil {
+		v.api("api", cfg.API)
+	}
+	if cfg.Backend != nil {
+		v.backend("backend", cfg.Backend)
+	}
+
+	return v.errs
+}
+
+type validator struct {
+	// names of the objects by kind.
+	names map[string]map[string]bool
+	errs  []*ValidationError
+}
+
+func (v *validator) errorf(path string, format string, args ...any) {
+	v.errs = append(v.errs, &ValidationError{
+		Path: path,
+		Err:  fmt.Errorf(format, args...),
+	})
+}
+
+func (v *validator) check(path string, err error) {
+	if err != nil {
+		v.errs = append(v.errs, &ValidationError{
+			Path: path,
+			Err:  err,
+		})
+	}
+}
+
+// collect records the names of the objects of the kind, the names must be unique and not empty.
+func collect[C any](v *validator, path string, kind string, cfgs []*C, name func(*C) string) {
+	names := make(map[string]bool)
+	v.names[kind] = names
+	for i, c := range cfgs {
+		if c == nil {
+			continue
+		}
+		s := name(c)
+		if s == "" {
+			v.errorf(fmt.Sprintf("%s[%d].name", path, i), "empty name")
+			continue
+		}
+		if names[s] {
+			v.errorf(fmt.Sprintf("%s[%d].name", path, i), "duplicate %s %q", kind, s)
+			continue
+		}
+		names[s] = true
+	}
+}
+
+// ref checks the reference to the object of the kind, the empty name refers to nothing.
+func (v *validator) ref(path string, kind string, name string) {
+	if name != "" && !v.names[kind][name] {
+		v.errorf(path, "unknown %s %q", kind, name)
+	}
+}
+
+func (v *validator) refs(path string, kind string, names []string) {
+	for i, name := range names {
+		v.ref(fmt.Sprintf("%s[%d]", path, i), kind, name)
+	}
+}
+
+func (v *validator) chainGroup(path string, cfg *config.ChainGroupConfig) {
+	if cfg == nil {
+		return
+	}
+	v.refs(path+".chains", "chain", cfg.Chains)
+	v.selector(path+".selector", cfg.Selector)
+}
+
+func (v *validator) selector(path string, cfg *config.SelectorConfig) {
+	if cfg == nil {
+		return
+	}
+	switch cfg.Strategy {
+	case "", "round", "rr", "random", "rand", "fifo", "ha", "hash":
+	default:
+		v.errorf(path+".strategy", "unknown strategy %q", cfg.Strategy)
+	}
+	if cfg.MaxFails < 0 {
+		v.errorf(path+".maxFails", "negative value %d", cfg.MaxFails)
+	}
+	v.duration(path+".failTimeout", cfg.FailTimeout)
+}
+
+func (v *validator) duration(path string, d time.Duration) {
+	if d < 0 {
+		v.errorf(path, "negative duration %v", d)
+	}
+}
+
+func (v *validator) loaders(path string, reload time.Duration, http *config.HTTPLoader) {
+	v.duration(path+".reload", reload)
+	if http != nil {
+		v.duration(path+".http.timeout", http.Timeout)
+	}
+}
+
+func (v *validator) limiters(path string, cfgs []*config.LimiterConfig, validate func(string) error) {
+	for i, c := range cfgs {
+		if c == nil {
+			continue
+		}
+		for j, limit := range c.Limits {
+			v.check(fmt.Sprintf("%s[%d].limits[%d]", path, i, j), validate(limit))
+		}
+		v.loaders(fmt.Sprintf("%s[%d]", path, i), c.Reload, c.HTTP)
+	}
+}
+
+func (v *validator) service(path string, cfg *config.ServiceConfig) {
+	v.ref(path+".admission", "admission", cfg.Admission)
+	v.refs(path+".admissions", "admission", cfg.Admissions)
+	v.ref(path+".bypass", "bypass", cfg.Bypass)
+	v.refs(path+".bypasses", "bypass", cfg.Bypasses)
+	v.ref(path+".resolver", "resolver", cfg.Resolver)
+	v.ref(path+".hosts", "hosts", cfg.Hosts)
+	v.ref(path+".limiter", "limiter", cfg.Limiter)
+	v.ref(path+".climiter", "climiter", cfg.CLimiter)
+	v.ref(path+".rlimiter", "rlimiter", cfg.RLimiter)
+	for i, r := range cfg.Recorders {
+		if r != nil {
+			v.ref(fmt.Sprintf("%s.recorders[%d].name", path, i), "recorder", r.Name)
+		}
+	}
+
+	if ln := cfg.Listener; ln != nil {
+		if !registry.ListenerRegistry().IsRegistered(ln.Type) {
+			v.errorf(path+".listener.type", "unregistered listener %q", ln.Type)
+		}
+		v.ref(path+".listener.chain", "chain", ln.Chain)
+		v.chainGroup(path+".listener.chainGroup", ln.ChainGroup)
+		v.ref(path+".listener.auther", "auther", ln.Auther)
+		v.refs(path+".listener.authers", "auther", ln.Authers)
+		v.serverTLS(path+".listener.tls", ln.TLS)
+	}
+
+	if h := cfg.Handler; h != nil {
+		if !registry.HandlerRegistry().IsRegistered(h.Type) {
+			v.errorf(path+".handler.type", "unregistered handler %q", h.Type)
+		}
+		v.ref(path+".handler.chain", "chain", h.Chain)
+		v.chainGroup(path+".handler.chainGroup", h.ChainGroup)
+		v.ref(path+".handler.auther", "auther", h.Auther)
+		v.refs(path+".handler.authers", "auther", h.Authers)
+		v.serverTLS(path+".handler.tls", h.TLS)
+		for i, r := range h.Routes {
+			if r == nil {
+				continue
+			}
+			rpath := fmt.Sprintf("%s.handler.routes[%d]", path, i)
+			v.ref(rpath+".auther", "auther", r.Auther)
+			v.ref(rpath+".chain", "chain", r.Chain)
+			v.chainGroup(rpath+".chainGroup", r.ChainGroup)
+			v.ref(rpath+".bypass", "bypass", r.Bypass)
+			v.refs(rpath+".bypasses", "bypass", r.Bypasses)
+			v.ref(rpath+".rlimiter", "rlimiter", r.RLimiter)
+		}
+	}
+
+	if f := cfg.Forwarder; f != nil {
+		v.selector(path+".forwarder.selector", f.Selector)
+		for i, node := range f.Nodes {
+			if node == nil {
+				continue
+			}
+			npath := fmt.Sprintf("%s.forwarder.nodes[%d]", path, i)
+			v.ref(npath+".bypass", "bypass", node.Bypass)
+			v.refs(npath+".bypasses", "bypass", node.Bypasses)
+		}
+		if len(f.Nodes) == 0 && len(f.Targets) == 0 {
+			v.ref(path+".forwarder.name", "hop", f.Name)
+		}
+	}
+}
+
+func (v *validator) hop(path string, cfg *config.HopConfig) {
+	v.selector(path+".selector", cfg.Selector)
+	v.ref(path+".bypass", "bypass", cfg.Bypass)
+	v.refs(path+".bypasses", "bypass", cfg.Bypasses)
+	v.ref(path+".resolver", "resolver", cfg.Resolver)
+	v.ref(path+".hosts", "hosts", cfg.Hosts)
+
+	for i, node := range cfg.Nodes {
+		if node == nil {
+			continue
+		}
+		npath := fmt.Sprintf("%s.nodes[%d]", path, i)
+		v.ref(npath+".bypass", "bypass", node.Bypass)
+		v.refs(npath+".bypasses", "bypass", node.Bypasses)
+		v.ref(npath+".resolver", "resolver", node.Resolver)
+		v.ref(npath+".hosts", "hosts", node.Hosts)
+
+		// the default connector and dialer are set if the connector is not specified.
+		if node.Connector == nil {
+			continue
+		}
+		if !registry.ConnectorRegistry().IsRegistered(node.Connector.Type) {
+			v.errorf(npath+".connector.type", "unregistered connector %q", node.Connector.Type)
+		}
+		v.clientTLS(npath+".connector.tls", node.Connector.TLS)
+		if node.Dialer == nil {
+			v.errorf(npath+".dialer", "no dialer")
+			continue
+		}
+		if !registry.DialerRegistry().IsRegistered(node.Dialer.Type) {
+			v.errorf(npath+".dialer.type", "unregistered dialer %q", node.Dialer.Type)
+		}
+		v.clientTLS(npath+".dialer.tls", node.Dialer.TLS)
+	}
+}
+
+func (v *validator) resolver(path string, cfg *config.ResolverConfig) {
+	for i, ns := range cfg.Nameservers {
+		if ns == nil {
+			continue
+		}
+		npath := fmt.Sprintf("%s.nameservers[%d]", path, i)
+		if ns.Addr == "" {
+			v.errorf(npath+".addr", "empty address")
+		}
+		v.ref(npath+".chain", "chain", ns.Chain)
+		switch ns.Prefer {
+		case "", "ipv4", "ipv6":
+		default:
+			v.errorf(npath+".prefer", "unknown prefer %q", ns.Prefer)
+		}
+		if ns.ClientIP != "" && net.ParseIP(ns.ClientIP) == nil {
+			v.errorf(npath+".clientIP", "invalid IP address %q", ns.ClientIP)
+		}
+		v.duration(npath+".ttl", ns.TTL)
+		v.duration(npath+".timeout", ns.Timeout)
+	}
+}
+
+// serverTLS checks the certificate and CA files of the server TLS config by loading them.
+func (v *validator) serverTLS(path string, cfg *config.TLSConfig) {
+	if cfg == nil {
+		return
+	}
+	v.duration(path+".reload", cfg.Reload)
+	v.duration(path+".validity", cfg.Validity)
+
+	var pairs []tls_util.CertPair
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		pairs = append(pairs, tls_util.CertPair{
+			CertFile: cfg.CertFile,
+			KeyFile:  cfg.KeyFile,
+		})
+	}
+	for _, c := range cfg.Certificates {
+		if c != nil {
+			pairs = append(pairs, tls_util.CertPair{
+				CertFile: c.CertFile,
+				KeyFile:  c.KeyFile,
+			})
+		}
+	}
+	if len(pairs) == 0 {
+		if cfg.ACME != nil && len(cfg.ACME.Domains) == 0 {
+			v.errorf(path+".acme.domains", "no domain")
+		}
+		return
+	}
+	_, err := tls_util.NewServerConfig(pairs, cfg.CAFile, 0, nil)
+	v.check(path, err)
+}
+
+func (v *validator) backend(path string, cfg *config.BackendConfig) {
+	if (cfg.Redis == nil || cfg.Redis.Addr == "") && (cfg.HTTP == nil || cfg.HTTP.URL == "") {
+		v.errorf(path, "no redis or http backend")
+	}
+	if cfg.HTTP != nil && cfg.HTTP.URL != "" {
+		if _, err := url.Parse(cfg.HTTP.URL); err != nil {
+			v.errorf(path+".http.url", "%v", err)
+		}
+	}
+	v.duration(path+".reload", cfg.Reload)
+	switch cfg.Format {
+	case "", "yaml", "json":
+	default:
+		v.errorf(path+".format", "unknown format %q", cfg.Format)
+	}
+}
+
+func (v *validator) api(path string, cfg *config.APIConfig) {
+	v.ref(path+".auther", "auther", cfg.Auther)
+	if cfg.TLS != nil {
+		v.serverTLS(path+".tls", cfg.TLS)
+	}
+	if cfg.JWT != nil && len(cfg.JWT.Keys) == 0 {
+		v.errorf(path+".jwt.keys", "no key")
+	}
+
+	roles := make(map[string]bool)
+	for i, c := range cfg.Roles {
+		if c == nil {
+			continue
+		}
+		rpath := fmt.Sprintf("%s.roles[%d]", path, i)
+		if c.Name == "" {
+			v.errorf(rpath+".name", "empty name")
+		} else if roles[c.Name] {
+			v.errorf(rpath+".name", "duplicated role %q", c.Name)
+		}
+		roles[c.Name] = true
+		for j, p := range c.Permissions {
+			switch {
+			case p == "read", p == "save", p == "reload", p == "*":
+			case strings.HasPrefix(p, "write:") && len(p) > len("write:"):
+			default:
+				v.errorf(fmt.Sprintf("%s.permissions[%d]", rpath, j), "unknown permission %q", p)
+			}
+		}
+	}
+
+	checkRoles := func(path string, names []string) {
+		for i, name := range names {
+			if !roles[name] {
+				v.errorf(fmt.Sprintf("%s[%d]", path, i), "unknown role %q", name)
+			}
+		}
+	}
+	for i, c := range cfg.Tokens {
+		if c == nil {
+			continue
+		}
+		if c.Token == "" {
+			v.errorf(fmt.Sprintf("%s.tokens[%d].token", path, i), "empty token")
+		}
+		checkRoles(fmt.Sprintf("%s.tokens[%d].roles", path, i), c.Roles)
+	}
+	for i, c := range cfg.Users {
+		if c != nil {
+			checkRoles(fmt.Sprintf("%s.users[%d].roles", path, i), c.Roles)
+		}
+	}
+}
+
+// clientTLS checks the certificate and CA files of the client TLS config by loading them.
+func (v *validator) clientTLS(path string, cfg *config.TLSConfig) {
+	if cfg == nil {
+		return
+	}
+	_, err := tls_util.LoadClientConfig(cfg.CertFile, cfg.KeyFile, cfg.CAFile, cfg.Secure, cfg.ServerName)
+	v.check(path, err)
+}
@@ -0,0 +1,190 @@
+package parsing
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-gost/core/connector"
+	"github.com/go-gost/core/dialer"
+	"github.com/go-gost/core/handler"
+	"github.com/go-gost/core/listener"
+	"github.com/go-gost/x/config"
+	"github.com/go-gost/x/registry"
+)
+
+func init() {
+	registry.ListenerRegistry().Register("validate", func(...listener.Option) listener.Listener { return nil })
+	registry.HandlerRegistry().Register("validate", func(...handler.Option) handler.Handler { return nil })
+	registry.ConnectorRegistry().Register("validate", func(...connector.Option) connector.Connector { return nil })
+	registry.DialerRegistry().Register("validate", func(...dialer.Option) dialer.Dialer { return nil })
+}
+
+func validService(name string) *config.ServiceConfig {
+	return &config.ServiceConfig{
+		Name:     name,
+		Addr:     ":8080",
+		Listener: &config.ListenerConfig{Type: "validate"},
+		Handler:  &config.HandlerConfig{Type: "validate"},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  *config.Config
+		want []string // the paths of the errors.
+	}{
+		{name: "nil"},
+		{name: "empty", cfg: &config.Config{}},
+		{
+			name: "valid",
+			cfg: &config.Config{
+				Services: []*config.ServiceConfig{validService("service-0")},
+				Chains: []*config.ChainConfig{{
+					Name: "chain-0",
+					Hops: []*config.HopConfig{{Name: "hop-0"}},
+				}},
+				Hops: []*config.HopConfig{{
+					Name: "hop-0",
+					Nodes: []*config.NodeConfig{{
+						Name:      "node-0",
+						Addr:      ":1080",
+						Connector: &config.ConnectorConfig{Type: "validate"},
+						Dialer:    &config.DialerConfig{Type: "validate"},
+					}},
+				}},
+				Bypasses: []*config.BypassConfig{{Name: "bypass-0", Matchers: []string{"*.example.com:443"}}},
+			},
+		},
+		{
+			name: "names",
+			cfg: &config.Config{
+				Services: []*config.ServiceConfig{validService("service-0"), validService("service-0"), validService("")},
+			},
+			want: []string{"services[1].name", "services[2].name"},
+		},
+		{
+			name: "references",
+			cfg: &config.Config{
+				Services: []*config.ServiceConfig{
+					func() *config.ServiceConfig {
+						c := validService("service-0")
+						c.Bypass = "bypass-0"
+						c.Handler.Chain = "chain-0"
+						c.Handler.Routes = []*config.UserRouteConfig{{Auther: "auther-0"}}
+						c.Recorders = []*config.RecorderObject{{Name: "recorder-0"}}
+						return c
+					}(),
+				},
+				Chains: []*config.ChainConfig{{
+					Name: "chain-1",
+					Hops: []*config.HopConfig{{Name: "hop-0"}},
+				}},
+			},
+			want: []string{
+				"services[0].bypass",
+				"services[0].recorders[0].name",
+				"services[0].handler.chain",
+				"services[0].handler.routes[0].auther",
+				"chains[0].hops[0].name",
+			},
+		},
+		{
+			name: "types",
+			cfg: &config.Config{
+				Services: []*config.ServiceConfig{{
+					Name:     "service-0",
+					Listener: &config.ListenerConfig{Type: "unknown"},
+					Handler:  &config.HandlerConfig{Type: "unknown"},
+				}},
+				Hops: []*config.HopConfig{{
+					Name: "hop-0",
+					Nodes: []*config.NodeConfig{
+						{Name: "node-0", Connector: &config.ConnectorConfig{Type: "unknown"}, Dialer: &config.DialerConfig{Type: "unknown"}},
+						{Name: "node-1", Connector: &config.ConnectorConfig{Type: "validate"}},
+					},
+				}},
+			},
+			want: []string{
+				"services[0].listener.type",
+				"services[0].handler.type",
+				"hops[0].nodes[0].connector.type",
+				"hops[0].nodes[0].dialer.type",
+				"hops[0].nodes[1].dialer",
+			},
+		},
+		{
+			name: "values",
+			cfg: &config.Config{
+				Hops: []*config.HopConfig{{
+					Name:     "hop-0",
+					Selector: &config.SelectorConfig{Strategy: "unknown", MaxFails: -1},
+				}},
+				Bypasses: []*config.BypassConfig{{Name: "bypass-0", Matchers: []string{"* port=http"}}},
+				Hosts: []*config.HostsConfig{{
+					Name:     "hosts-0",
+					Mappings: []*config.HostMappingConfig{{IP: "localhost"}},
+				}},
+				Limiters:  []*config.LimiterConfig{{Name: "limiter-0", Limits: []string{"$ 1XB"}}},
+				Recorders: []*config.RecorderConfig{{Name: "recorder-0"}},
+			},
+			want: []string{
+				"hops[0].selector.strategy",
+				"hops[0].selector.maxFails",
+				"bypasses[0].matchers[0]",
+				"hosts[0].mappings[0].hostname",
+				"hosts[0].mappings[0].ip",
+				"recorders[0]",
+				"limiters[0].limits[0]",
+			},
+		},
+		{
+			name: "files",
+			cfg: &config.Config{
+				Services: []*config.ServiceConfig{
+					func() *config.ServiceConfig {
+						c := validService("service-0")
+						c.Listener.TLS = &config.TLSConfig{CertFile: "/nonexistent/cert.pem", KeyFile: "/nonexistent/key.pem"}
+						return c
+					}(),
+				},
+			},
+			want: []string{"services[0].listener.tls"},
+		},
+		{
+			name: "api and backend",
+			cfg: &config.Config{
+				API: &config.APIConfig{
+					Auther: "auther-0",
+					JWT:    &config.APIJWTConfig{},
+					Roles:  []*config.APIRoleConfig{{Name: "reader", Permissions: []string{"read", "write"}}},
+					Tokens: []*config.APITokenConfig{{Roles: []string{"reader", "admin"}}},
+				},
+				Backend: &config.BackendConfig{Format: "toml"},
+			},
+			want: []string{
+				"api.auther",
+				"api.jwt.keys",
+				"api.roles[0].permissions[1]",
+				"api.tokens[0].token",
+				"api.tokens[0].roles[1]",
+				"backend",
+				"backend.format",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, err := range Validate(tt.cfg) {
+				if err.Err == nil || err.Error() == "" {
+					t.Errorf("got no error of %s", err.Path)
+				}
+				got = append(got, err.Path)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got errors of\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
@@ -5,21 +5,46 @@ import (
 
 	mdata "github.com/go-gost/core/metadata"
 	mdutil "github.com/go-gost/core/metadata/util"
+	"github.com/go-gost/x/internal/util/mux"
 )
 
 type metadata struct {
 	connectTimeout time.Duration
 	noDelay        bool
+
+	muxCfg *mux.Config
 }
 
 func (c *relayConnector) parseMetadata(md mdata.Metadata) (err error) {
 	const (
 		connectTimeout = "connectTimeout"
 		noDelay        = "nodelay"
+
+		muxProtocol          = "muxProtocol"
+		muxVersion           = "muxVersion"
+		muxKeepAliveDisabled = "muxKeepAliveDisabled"
+		muxKeepAliveInterval = "muxKeepAliveInterval"
+		muxKeepAliveTimeout  = "muxKeepAliveTimeout"
+		muxMaxFrameSize      = "muxMaxFrameSize"
+		muxMaxReceiveBuffer  = "muxMaxReceiveBuffer"
+		muxMaxStreamBuffer   = "muxMaxStreamBuffer"
+		muxMaxStreams        = "muxMaxStreams"
 	)
 
 	c.md.connectTimeout = mdutil.GetDuration(md, connectTimeout)
 	c.md.noDelay = mdutil.GetBool(md, noDelay)
 
+	c.md.muxCfg = &mux.Config{
+		Protocol:          mdutil.GetString(md, muxProtocol),
+		Version:           mdutil.GetInt(md, muxVersion),
+		KeepAliveDisabled: mdutil.GetBool(md, muxKeepAliveDisabled),
+		KeepAliveInterval: mdutil.GetDuration(md, muxKeepAliveInterval),
+		KeepAliveTimeout:  mdutil.GetDuration(md, muxKeepAliveTimeout),
+		MaxFrameSize:      mdutil.GetInt(md, muxMaxFrameSize),
+		MaxReceiveBuffer:  mdutil.GetInt(md, muxMaxReceiveBuffer),
+		MaxStreamBuffer:   mdutil.GetInt(md, muxMaxStreamBuffer),
+		MaxStreams:        mdutil.GetInt(md, muxMaxStreams),
+	}
+
 	return
 }
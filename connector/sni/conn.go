@@ -8,14 +8,23 @@ import (
 	"hash/crc32"
 	"io"
 	"net"
+	"sort"
 	"strings"
 
 	dissector "github.com/go-gost/tls-dissector"
+	tls_util "github.com/go-gost/x/internal/util/tls"
+)
+
+const (
+	extALPN          uint16 = 0x10
+	extPreSharedKey  uint16 = 0x29
+	extServerNameEnc uint16 = 0xFFFE
 )
 
 type sniClientConn struct {
-	host       string
-	obfuscated bool
+	host        string
+	clientHello *tls_util.ClientHelloOptions
+	obfuscated  bool
 	net.Conn
 }
 
@@ -31,16 +40,15 @@ func (c *sniClientConn) Write(p []byte) (int, error) {
 }
 
 func (c *sniClientConn) obfuscate(p []byte) ([]byte, error) {
-	if c.host == "" {
-		return p, nil
-	}
-
-	if c.obfuscated {
+	if c.obfuscated || len(p) == 0 {
 		return p, nil
 	}
 
 	if p[0] == dissector.Handshake {
-		b, err := readClientHelloRecord(bytes.NewReader(p), c.host)
+		if c.host == "" && c.clientHello.IsZero() {
+			return p, nil
+		}
+		b, err := readClientHelloRecord(bytes.NewReader(p), c.host, c.clientHello)
 		if err != nil {
 			return nil, err
 		}
@@ -48,6 +56,10 @@ func (c *sniClientConn) obfuscate(p []byte) ([]byte, error) {
 		return b, nil
 	}
 
+	if c.host == "" {
+		return p, nil
+	}
+
 	buf := &bytes.Buffer{}
 	br := bufio.NewReader(bytes.NewReader(p))
 	for {
@@ -85,7 +97,7 @@ func (c *sniClientConn) obfuscate(p []byte) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
-func readClientHelloRecord(r io.Reader, host string) ([]byte, error) {
+func readClientHelloRecord(r io.Reader, host string, opts *tls_util.ClientHelloOptions) ([]byte, error) {
 	record, err := dissector.ReadRecord(r)
 	if err != nil {
 		return nil, err
@@ -95,18 +107,61 @@ func readClientHelloRecord(r io.Reader, host string) ([]byte, error) {
 		return nil, err
 	}
 
+	if opts == nil {
+		opts = &tls_util.ClientHelloOptions{}
+	}
+	if opts.SNI != "" {
+		host = opts.SNI
+	}
+
+	var exts []dissector.Extension
+	var hasALPN bool
+	var serverName dissector.Extension
 	for _, ext := range clientHello.Extensions {
-		if ext.Type() == dissector.ExtServerName {
+		switch ext.Type() {
+		case dissector.ExtServerName:
 			snExtension := ext.(*dissector.ServerNameExtension)
-			if host != "" {
-				e, _ := dissector.NewExtension(0xFFFE, []byte(encodeServerName(snExtension.Name)))
-				clientHello.Extensions = append(clientHello.Extensions, e)
+			// the real server name is carried by the private extension.
+			if host != "" || opts.DisableSNI {
+				serverName, _ = dissector.NewExtension(extServerNameEnc, []byte(encodeServerName(snExtension.Name)))
 				snExtension.Name = host
 			}
+			if opts.DisableSNI {
+				continue
+			}
+		case extALPN:
+			hasALPN = true
+			if len(opts.ALPN) > 0 {
+				ext, _ = dissector.NewExtension(extALPN, encodeALPN(opts.ALPN))
+			}
+		}
+		exts = append(exts, ext)
+	}
+	if !hasALPN && len(opts.ALPN) > 0 {
+		e, _ := dissector.NewExtension(extALPN, encodeALPN(opts.ALPN))
+		exts = append(exts, e)
+	}
+	if serverName != nil {
+		exts = append(exts, serverName)
+	}
+	clientHello.Extensions = exts
 
-			break
+	if opts.Fingerprint != "" {
+		ciphers, types, err := tls_util.ClientHelloOrder(opts.Fingerprint)
+		if err != nil {
+			return nil, err
+		}
+		clientHello.CipherSuites = reorder(clientHello.CipherSuites, ciphers, func(v uint16) uint16 { return v })
+		clientHello.Extensions = reorder(clientHello.Extensions, types, dissector.Extension.Type)
+		// the pre_shared_key extension must be the last one.
+		for i, ext := range clientHello.Extensions {
+			if ext.Type() == extPreSharedKey {
+				clientHello.Extensions = append(append(clientHello.Extensions[:i:i], clientHello.Extensions[i+1:]...), ext)
+				break
+			}
 		}
 	}
+
 	record.Opaque, err = clientHello.Encode()
 	if err != nil {
 		return nil, err
@@ -126,3 +181,37 @@ func encodeServerName(name string) string {
 	buf.WriteString(base64.RawURLEncoding.EncodeToString([]byte(name)))
 	return base64.RawURLEncoding.EncodeToString(buf.Bytes())
 }
+
+// encodeALPN encodes the protocols in the body of the ALPN extension.
+func encodeALPN(protos []string) []byte {
+	buf := &bytes.Buffer{}
+	for _, proto := range protos {
+		buf.WriteByte(byte(len(proto)))
+		buf.WriteString(proto)
+	}
+	b := make([]byte, 2, 2+buf.Len())
+	binary.BigEndian.PutUint16(b, uint16(buf.Len()))
+	return append(b, buf.Bytes()...)
+}
+
+// reorder sorts the values in the order of the keys, the values not in the keys are kept at the end.
+func reorder[T any](values []T, keys []uint16, key func(T) uint16) []T {
+	index := make(map[uint16]int, len(keys))
+	for i, k := range keys {
+		if _, ok := index[k]; !ok {
+			index[k] = i
+		}
+	}
+	sort.SliceStable(values, func(i, j int) bool {
+		ki, ok := index[key(values[i])]
+		if !ok {
+			ki = len(keys)
+		}
+		kj, ok := index[key(values[j])]
+		if !ok {
+			kj = len(keys)
+		}
+		return ki < kj
+	})
+	return values
+}
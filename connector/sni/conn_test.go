@@ -0,0 +1,182 @@
+package sni
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"net"
+	"testing"
+
+	dissector "github.com/go-gost/tls-dissector"
+	tls_util "github.com/go-gost/x/internal/util/tls"
+)
+
+// clientHelloRecord returns the ClientHello record sent by crypto/tls.
+func clientHelloRecord(t *testing.T) []byte {
+	t.Helper()
+
+	c1, c2 := net.Pipe()
+	defer c2.Close()
+	go func() {
+		defer c1.Close()
+		tls.Client(c1, &tls.Config{
+			ServerName: "real.example.com",
+			NextProtos: []string{"h2", "http/1.1"},
+		}).Handshake()
+	}()
+
+	b := make([]byte, 16*1024)
+	n, err := c2.Read(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b[:n]
+}
+
+func decodeClientHello(t *testing.T, b []byte) *dissector.ClientHelloMsg {
+	t.Helper()
+
+	record, err := dissector.ReadRecord(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientHello := &dissector.ClientHelloMsg{}
+	if err := clientHello.Decode(record.Opaque); err != nil {
+		t.Fatal(err)
+	}
+	return clientHello
+}
+
+func TestReadClientHelloRecord(t *testing.T) {
+	raw := clientHelloRecord(t)
+
+	tests := []struct {
+		name       string
+		host       string
+		opts       *tls_util.ClientHelloOptions
+		serverName string // the server name in the ClientHello, empty if omitted.
+		encoded    bool   // the real server name is in the private extension.
+		alpn       []byte
+	}{
+		{
+			name:       "none",
+			serverName: "real.example.com",
+		},
+		{
+			name:       "host",
+			host:       "fake.example.com",
+			serverName: "fake.example.com",
+			encoded:    true,
+		},
+		{
+			name:       "sni",
+			host:       "host.example.com",
+			opts:       &tls_util.ClientHelloOptions{SNI: "fake.example.com"},
+			serverName: "fake.example.com",
+			encoded:    true,
+		},
+		{
+			name:    "disable sni",
+			opts:    &tls_util.ClientHelloOptions{DisableSNI: true},
+			encoded: true,
+		},
+		{
+			name:       "alpn",
+			opts:       &tls_util.ClientHelloOptions{ALPN: []string{"h3"}},
+			serverName: "real.example.com",
+			alpn:       []byte{0, 3, 2, 'h', '3'},
+		},
+		{
+			name: "fingerprint",
+			// the extensions of chrome are shuffled for each ClientHello.
+			opts:       &tls_util.ClientHelloOptions{Fingerprint: "firefox"},
+			serverName: "real.example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := readClientHelloRecord(bytes.NewReader(raw), tt.host, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			clientHello := decodeClientHello(t, b)
+
+			var serverName string
+			var encoded bool
+			var alpn []byte
+			var types []uint16
+			for _, ext := range clientHello.Extensions {
+				types = append(types, ext.Type())
+				switch ext.Type() {
+				case dissector.ExtServerName:
+					serverName = ext.(*dissector.ServerNameExtension).Name
+				case extServerNameEnc:
+					v, _ := ext.Encode()
+					b, _ := base64.RawURLEncoding.DecodeString(string(v))
+					name, _ := base64.RawURLEncoding.DecodeString(string(b[4:]))
+					if string(name) != "real.example.com" {
+						t.Errorf("got encoded server name %q, want %q", name, "real.example.com")
+					}
+					encoded = true
+				case extALPN:
+					alpn, _ = ext.Encode()
+				}
+			}
+
+			if serverName != tt.serverName {
+				t.Errorf("got server name %q, want %q", serverName, tt.serverName)
+			}
+			if encoded != tt.encoded {
+				t.Errorf("got encoded server name %v, want %v", encoded, tt.encoded)
+			}
+			if tt.alpn != nil && !bytes.Equal(alpn, tt.alpn) {
+				t.Errorf("got ALPN %v, want %v", alpn, tt.alpn)
+			}
+
+			if tt.opts == nil || tt.opts.Fingerprint == "" {
+				return
+			}
+			ciphers, order, err := tls_util.ClientHelloOrder(tt.opts.Fingerprint)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := clientHello.CipherSuites; !inOrder(got, ciphers) {
+				t.Errorf("got cipher suites %x not in the order %x", got, ciphers)
+			}
+			if !inOrder(types, order) {
+				t.Errorf("got extensions %x not in the order %x", types, order)
+			}
+			if last := types[len(types)-1]; hasType(types, extPreSharedKey) && last != extPreSharedKey {
+				t.Errorf("got last extension %x, want pre_shared_key", last)
+			}
+		})
+	}
+}
+
+// inOrder reports whether the values in the keys are in the order of the keys.
+func inOrder(values, keys []uint16) bool {
+	var last int
+	for _, v := range values {
+		for i, k := range keys {
+			if k != v {
+				continue
+			}
+			if i < last {
+				return false
+			}
+			last = i
+			break
+		}
+	}
+	return true
+}
+
+func hasType(types []uint16, t uint16) bool {
+	for _, v := range types {
+		if v == t {
+			return true
+		}
+	}
+	return false
+}
@@ -42,5 +42,9 @@ func (c *sniConnector) Connect(ctx context.Context, conn net.Conn, network, addr
 	})
 	log.Debugf("connect %s/%s", address, network)
 
-	return &sniClientConn{Conn: conn, host: c.md.host}, nil
+	return &sniClientConn{
+		Conn:        conn,
+		host:        c.md.host,
+		clientHello: &c.md.clientHello,
+	}, nil
 }
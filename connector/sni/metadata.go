@@ -5,21 +5,37 @@ import (
 
 	mdata "github.com/go-gost/core/metadata"
 	mdutil "github.com/go-gost/core/metadata/util"
+	tls_util "github.com/go-gost/x/internal/util/tls"
 )
 
 type metadata struct {
 	host           string
 	connectTimeout time.Duration
+
+	clientHello tls_util.ClientHelloOptions
 }
 
 func (c *sniConnector) parseMetadata(md mdata.Metadata) (err error) {
 	const (
 		host           = "host"
 		connectTimeout = "timeout"
+
+		fingerprint = "fingerprint"
+		alpn        = "alpn"
+		sni         = "sni"
+		disableSNI  = "disableSNI"
 	)
 
 	c.md.host = mdutil.GetString(md, host)
 	c.md.connectTimeout = mdutil.GetDuration(md, connectTimeout)
 
+	c.md.clientHello = tls_util.ClientHelloOptions{
+		Fingerprint: mdutil.GetString(md, fingerprint),
+		ALPN:        mdutil.GetStrings(md, alpn),
+		SNI:         mdutil.GetString(md, sni),
+		DisableSNI:  mdutil.GetBool(md, disableSNI),
+	}
+	err = tls_util.ValidateFingerprint(c.md.clientHello.Fingerprint)
+
 	return
 }
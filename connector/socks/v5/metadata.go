@@ -5,6 +5,7 @@ import (
 
 	mdata "github.com/go-gost/core/metadata"
 	mdutil "github.com/go-gost/core/metadata/util"
+	"github.com/go-gost/x/internal/util/mux"
 )
 
 const (
@@ -16,6 +17,8 @@ type metadata struct {
 	noTLS          bool
 	relay          string
 	udpBufferSize  int
+
+	muxCfg *mux.Config
 }
 
 func (c *socks5Connector) parseMetadata(md mdata.Metadata) (err error) {
@@ -24,6 +27,16 @@ func (c *socks5Connector) parseMetadata(md mdata.Metadata) (err error) {
 		noTLS          = "notls"
 		relay          = "relay"
 		udpBufferSize  = "udpBufferSize"
+
+		muxProtocol          = "muxProtocol"
+		muxVersion           = "muxVersion"
+		muxKeepAliveDisabled = "muxKeepAliveDisabled"
+		muxKeepAliveInterval = "muxKeepAliveInterval"
+		muxKeepAliveTimeout  = "muxKeepAliveTimeout"
+		muxMaxFrameSize      = "muxMaxFrameSize"
+		muxMaxReceiveBuffer  = "muxMaxReceiveBuffer"
+		muxMaxStreamBuffer   = "muxMaxStreamBuffer"
+		muxMaxStreams        = "muxMaxStreams"
 	)
 
 	c.md.connectTimeout = mdutil.GetDuration(md, connectTimeout)
@@ -34,5 +47,17 @@ func (c *socks5Connector) parseMetadata(md mdata.Metadata) (err error) {
 		c.md.udpBufferSize = defaultUDPBufferSize
 	}
 
+	c.md.muxCfg = &mux.Config{
+		Protocol:          mdutil.GetString(md, muxProtocol),
+		Version:           mdutil.GetInt(md, muxVersion),
+		KeepAliveDisabled: mdutil.GetBool(md, muxKeepAliveDisabled),
+		KeepAliveInterval: mdutil.GetDuration(md, muxKeepAliveInterval),
+		KeepAliveTimeout:  mdutil.GetDuration(md, muxKeepAliveTimeout),
+		MaxFrameSize:      mdutil.GetInt(md, muxMaxFrameSize),
+		MaxReceiveBuffer:  mdutil.GetInt(md, muxMaxReceiveBuffer),
+		MaxStreamBuffer:   mdutil.GetInt(md, muxMaxStreamBuffer),
+		MaxStreams:        mdutil.GetInt(md, muxMaxStreams),
+	}
+
 	return
 }
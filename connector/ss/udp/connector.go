@@ -86,6 +86,11 @@ func (c *ssuConnector) Connect(ctx context.Context, conn net.Conn, network, addr
 		return ss.UDPClientConn(pc, conn.RemoteAddr(), taddr, c.md.bufferSize), nil
 	}
 
+	if _, ok := c.cipher.(*ss.Cipher2022); ok {
+		err := fmt.Errorf("UDP over TCP is unsupported by the 2022 ciphers")
+		log.Error(err)
+		return nil, err
+	}
 	if c.cipher != nil {
 		conn = ss.ShadowConn(c.cipher.StreamConn(conn), nil)
 	}
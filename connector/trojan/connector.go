@@ -0,0 +1,139 @@
+package trojan
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/go-gost/core/connector"
+	md "github.com/go-gost/core/metadata"
+	"github.com/go-gost/gosocks5"
+	"github.com/go-gost/x/internal/util/trojan"
+	"github.com/go-gost/x/registry"
+)
+
+func init() {
+	registry.ConnectorRegistry().Register("trojan", NewConnector)
+}
+
+type trojanConnector struct {
+	hash    string
+	md      metadata
+	options connector.Options
+}
+
+func NewConnector(opts ...connector.Option) connector.Connector {
+	options := connector.Options{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return &trojanConnector{
+		options: options,
+	}
+}
+
+func (c *trojanConnector) Init(md md.Metadata) (err error) {
+	if err = c.parseMetadata(md); err != nil {
+		return
+	}
+
+	if c.options.Auth == nil {
+		return errors.New("trojan: password is required")
+	}
+	// the password can be given in the form of either user:password or password.
+	password, ok := c.options.Auth.Password()
+	if !ok {
+		password = c.options.Auth.Username()
+	}
+	c.hash = trojan.Hash(password)
+
+	return
+}
+
+func (c *trojanConnector) Connect(ctx context.Context, conn net.Conn, network, address string, opts ...connector.ConnectOption) (net.Conn, error) {
+	log := c.options.Logger.WithFields(map[string]any{
+		"remote":  conn.RemoteAddr().String(),
+		"local":   conn.LocalAddr().String(),
+		"network": network,
+		"address": address,
+	})
+	log.Debugf("connect %s/%s", address, network)
+
+	req := &trojan.Request{
+		Hash: c.hash,
+		Addr: &gosocks5.Addr{},
+	}
+	switch network {
+	case "tcp", "tcp4", "tcp6":
+		if _, ok := conn.(net.PacketConn); ok {
+			err := fmt.Errorf("tcp over udp is unsupported")
+			log.Error(err)
+			return nil, err
+		}
+		req.Cmd = trojan.CmdConnect
+	case "udp", "udp4", "udp6":
+		req.Cmd = trojan.CmdUDPAssociate
+	default:
+		err := fmt.Errorf("network %s is unsupported", network)
+		log.Error(err)
+		return nil, err
+	}
+
+	if err := req.Addr.ParseFrom(address); err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	if c.md.connectTimeout > 0 {
+		conn.SetDeadline(time.Now().Add(c.md.connectTimeout))
+		defer conn.SetDeadline(time.Time{})
+	}
+
+	hc := &headerConn{Conn: conn}
+	if _, err := req.WriteTo(&hc.header); err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	if c.md.noDelay {
+		// write the header at once.
+		if _, err := hc.Conn.Write(hc.header.Bytes()); err != nil {
+			log.Error(err)
+			return nil, err
+		}
+		hc.header.Reset()
+	}
+	conn = hc
+
+	if req.Cmd == trojan.CmdUDPAssociate {
+		taddr, _ := net.ResolveUDPAddr(network, address)
+		if taddr == nil {
+			taddr = &net.UDPAddr{}
+		}
+		return trojan.UDPClientConn(conn, taddr), nil
+	}
+
+	return conn, nil
+}
+
+// headerConn sends the cached request header along with the first write.
+type headerConn struct {
+	net.Conn
+	header bytes.Buffer
+}
+
+func (c *headerConn) Write(b []byte) (n int, err error) {
+	if c.header.Len() > 0 {
+		c.header.Write(b)
+		_, err = c.Conn.Write(c.header.Bytes())
+		c.header.Reset()
+		if err != nil {
+			return
+		}
+		return len(b), nil
+	}
+	return c.Conn.Write(b)
+}
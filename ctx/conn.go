@@ -0,0 +1,120 @@
+package ctx
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// connInfoKey saves the information of the client connection.
+type connInfoKey struct{}
+
+var (
+	keyConnInfo = &connInfoKey{}
+)
+
+// ConnInfo is the information of a client connection of a service,
+// it is updated by the router while the connection is alive.
+type ConnInfo struct {
+	ID      uint64
+	Service string
+	Client  string
+	Start   time.Time
+	// Counted reports whether the bytes are counted on the client connection,
+	// rather than on the connections dialed for it.
+	Counted bool
+
+	user    string
+	network string
+	dst     string
+	chain   string
+	nodes   []string
+	mu      sync.RWMutex
+
+	// bytes received from the destination and sent to the destination.
+	input  atomic.Int64
+	output atomic.Int64
+}
+
+// ConnCounter is a client connection which can count its bytes in the ConnInfo,
+// such as the connections wrapped by the listeners for the metrics and the traffic limiter.
+type ConnCounter interface {
+	// CountConn counts the bytes read from the client as the output and the bytes written to the client as the input,
+	// it returns false if the bytes can not be counted.
+	CountConn(info *ConnInfo) bool
+}
+
+// ConnStat is a snapshot of the ConnInfo.
+type ConnStat struct {
+	ID      uint64
+	Service string
+	Client  string
+	User    string
+	Network string
+	Dst     string
+	Chain   string
+	Nodes   []string
+	Start   time.Time
+	// InputBytes is the number of bytes received from the destination.
+	InputBytes int64
+	// OutputBytes is the number of bytes sent to the destination.
+	OutputBytes int64
+}
+
+// SetDst sets the destination the client connects to and the user of the client.
+func (c *ConnInfo) SetDst(network, dst string, user string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.network = network
+	c.dst = dst
+	if user != "" {
+		c.user = user
+	}
+}
+
+// SetRoute sets the chain and the nodes the destination is connected through.
+func (c *ConnInfo) SetRoute(chain string, nodes []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.chain = chain
+	c.nodes = nodes
+}
+
+func (c *ConnInfo) AddInput(n int64) {
+	c.input.Add(n)
+}
+
+func (c *ConnInfo) AddOutput(n int64) {
+	c.output.Add(n)
+}
+
+func (c *ConnInfo) Stat() ConnStat {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return ConnStat{
+		ID:          c.ID,
+		Service:     c.Service,
+		Client:      c.Client,
+		User:        c.user,
+		Network:     c.network,
+		Dst:         c.dst,
+		Chain:       c.chain,
+		Nodes:       append([]string(nil), c.nodes...),
+		Start:       c.Start,
+		InputBytes:  c.input.Load(),
+		OutputBytes: c.output.Load(),
+	}
+}
+
+func ContextWithConnInfo(ctx context.Context, info *ConnInfo) context.Context {
+	return context.WithValue(ctx, keyConnInfo, info)
+}
+
+func ConnInfoFromContext(ctx context.Context) *ConnInfo {
+	v, _ := ctx.Value(keyConnInfo).(*ConnInfo)
+	return v
+}
@@ -0,0 +1,44 @@
+package ctx
+
+import (
+	"context"
+
+	"github.com/go-gost/core/resolver"
+)
+
+// clientIDKey saves the client ID.
+type clientIDKey struct{}
+
+// ClientID is the identity of an authenticated client, e.g. the username.
+type ClientID string
+
+var (
+	keyClientID = &clientIDKey{}
+)
+
+func ContextWithClientID(ctx context.Context, clientID ClientID) context.Context {
+	return context.WithValue(ctx, keyClientID, clientID)
+}
+
+func ClientIDFromContext(ctx context.Context) ClientID {
+	v, _ := ctx.Value(keyClientID).(ClientID)
+	return v
+}
+
+// resolverKey saves the resolver of the service.
+type resolverKey struct{}
+
+var (
+	keyResolver = &resolverKey{}
+)
+
+// ContextWithResolver passes the resolver of the service to the routes,
+// which resolve the destination address to all its addresses.
+func ContextWithResolver(ctx context.Context, r resolver.Resolver) context.Context {
+	return context.WithValue(ctx, keyResolver, r)
+}
+
+func ResolverFromContext(ctx context.Context) resolver.Resolver {
+	v, _ := ctx.Value(keyResolver).(resolver.Resolver)
+	return v
+}
@@ -0,0 +1,81 @@
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+
+	tls_util "github.com/go-gost/x/internal/util/tls"
+	"google.golang.org/grpc/credentials"
+)
+
+// clientHelloCredentials is the TLS transport credentials with the custom ClientHello.
+type clientHelloCredentials struct {
+	config      *tls.Config
+	clientHello tls_util.ClientHelloOptions
+}
+
+func newClientHelloCredentials(cfg *tls.Config, clientHello tls_util.ClientHelloOptions) credentials.TransportCredentials {
+	if cfg == nil {
+		cfg = &tls.Config{}
+	}
+	// gRPC requires HTTP/2.
+	if len(clientHello.ALPN) == 0 {
+		clientHello.ALPN = []string{"h2"}
+	}
+	return &clientHelloCredentials{
+		config:      cfg,
+		clientHello: clientHello,
+	}
+}
+
+func (c *clientHelloCredentials) ClientHandshake(ctx context.Context, authority string, rawConn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	cfg := c.config
+	if cfg.ServerName == "" {
+		cfg = cfg.Clone()
+		cfg.ServerName = authority
+		if host, _, err := net.SplitHostPort(authority); err == nil {
+			cfg.ServerName = host
+		}
+	}
+
+	conn, err := tls_util.ClientHandshake(ctx, rawConn, cfg, &c.clientHello)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var state tls.ConnectionState
+	if cs, ok := conn.(interface{ ConnectionState() tls.ConnectionState }); ok {
+		state = cs.ConnectionState()
+	}
+	return conn, credentials.TLSInfo{
+		State: state,
+		CommonAuthInfo: credentials.CommonAuthInfo{
+			SecurityLevel: credentials.PrivacyAndIntegrity,
+		},
+	}, nil
+}
+
+func (c *clientHelloCredentials) ServerHandshake(conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	return nil, nil, credentials.ErrConnDispatched
+}
+
+func (c *clientHelloCredentials) Info() credentials.ProtocolInfo {
+	return credentials.ProtocolInfo{
+		SecurityProtocol: "tls",
+		SecurityVersion:  "1.2",
+		ServerName:       c.config.ServerName,
+	}
+}
+
+func (c *clientHelloCredentials) Clone() credentials.TransportCredentials {
+	return &clientHelloCredentials{
+		config:      c.config.Clone(),
+		clientHello: c.clientHello,
+	}
+}
+
+func (c *clientHelloCredentials) OverrideServerName(serverNameOverride string) error {
+	c.config.ServerName = serverNameOverride
+	return nil
+}
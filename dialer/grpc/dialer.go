@@ -84,10 +84,12 @@ func (d *grpcDialer) Dial(ctx context.Context, addr string, opts ...dialer.DialO
 			}),
 			grpc.FailOnNonTempDialError(true),
 		}
-		if !d.md.insecure {
-			grpcOpts = append(grpcOpts, grpc.WithTransportCredentials(credentials.NewTLS(d.options.TLSConfig)))
-		} else {
+		if d.md.insecure {
 			grpcOpts = append(grpcOpts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		} else if !d.md.clientHello.IsZero() {
+			grpcOpts = append(grpcOpts, grpc.WithTransportCredentials(newClientHelloCredentials(d.options.TLSConfig, d.md.clientHello)))
+		} else {
+			grpcOpts = append(grpcOpts, grpc.WithTransportCredentials(credentials.NewTLS(d.options.TLSConfig)))
 		}
 
 		cc, err := grpc.DialContext(ctx, addr, grpcOpts...)
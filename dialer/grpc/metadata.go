@@ -3,12 +3,15 @@ package grpc
 import (
 	mdata "github.com/go-gost/core/metadata"
 	mdutil "github.com/go-gost/core/metadata/util"
+	tls_util "github.com/go-gost/x/internal/util/tls"
 )
 
 type metadata struct {
 	insecure bool
 	host     string
 	path     string
+
+	clientHello tls_util.ClientHelloOptions
 }
 
 func (d *grpcDialer) parseMetadata(md mdata.Metadata) (err error) {
@@ -16,11 +19,24 @@ func (d *grpcDialer) parseMetadata(md mdata.Metadata) (err error) {
 		insecure = "grpcInsecure"
 		host     = "host"
 		path     = "path"
+
+		fingerprint = "fingerprint"
+		alpn        = "alpn"
+		sni         = "sni"
+		disableSNI  = "disableSNI"
 	)
 
 	d.md.insecure = mdutil.GetBool(md, insecure)
 	d.md.host = mdutil.GetString(md, host)
 	d.md.path = mdutil.GetString(md, path)
 
+	d.md.clientHello = tls_util.ClientHelloOptions{
+		Fingerprint: mdutil.GetString(md, fingerprint),
+		ALPN:        mdutil.GetStrings(md, alpn),
+		SNI:         mdutil.GetString(md, sni),
+		DisableSNI:  mdutil.GetBool(md, disableSNI),
+	}
+	err = tls_util.ValidateFingerprint(d.md.clientHello.Fingerprint)
+
 	return
 }
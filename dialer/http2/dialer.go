@@ -2,6 +2,7 @@ package http2
 
 import (
 	"context"
+	"crypto/tls"
 	"net"
 	"net/http"
 	"sync"
@@ -11,8 +12,10 @@ import (
 	"github.com/go-gost/core/dialer"
 	"github.com/go-gost/core/logger"
 	md "github.com/go-gost/core/metadata"
+	tls_util "github.com/go-gost/x/internal/util/tls"
 	mdx "github.com/go-gost/x/metadata"
 	"github.com/go-gost/x/registry"
+	"golang.org/x/net/http2"
 )
 
 func init() {
@@ -70,22 +73,41 @@ func (d *http2Dialer) Dial(ctx context.Context, address string, opts ...dialer.D
 			opt(&options)
 		}
 
-		client = &http.Client{
-			Transport: &http.Transport{
-				TLSClientConfig: d.options.TLSConfig,
-				DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
-					netd := options.NetDialer
-					if netd == nil {
-						netd = net_dialer.DefaultNetDialer
-					}
-					return netd.Dial(ctx, network, addr)
-				},
+		dial := func(ctx context.Context, network, addr string) (net.Conn, error) {
+			netd := options.NetDialer
+			if netd == nil {
+				netd = net_dialer.DefaultNetDialer
+			}
+			return netd.Dial(ctx, network, addr)
+		}
+
+		client = &http.Client{}
+		if d.md.clientHello.IsZero() {
+			client.Transport = &http.Transport{
+				TLSClientConfig:       d.options.TLSConfig,
+				DialContext:           dial,
 				ForceAttemptHTTP2:     true,
 				MaxIdleConns:          100,
 				IdleConnTimeout:       90 * time.Second,
 				TLSHandshakeTimeout:   10 * time.Second,
 				ExpectContinueTimeout: 1 * time.Second,
-			},
+			}
+		} else {
+			// the TLS connection with custom ClientHello is used by HTTP/2 transport directly.
+			client.Transport = &http2.Transport{
+				DialTLSContext: func(ctx context.Context, network, addr string, cfg *tls.Config) (net.Conn, error) {
+					conn, err := dial(ctx, network, addr)
+					if err != nil {
+						return nil, err
+					}
+					tlsConn, err := tls_util.ClientHandshake(ctx, conn, d.options.TLSConfig, &d.md.clientHello)
+					if err != nil {
+						conn.Close()
+						return nil, err
+					}
+					return tlsConn, nil
+				},
+			}
 		}
 		d.clients[address] = client
 	}
@@ -15,6 +15,7 @@ import (
 	"github.com/go-gost/core/dialer"
 	"github.com/go-gost/core/logger"
 	md "github.com/go-gost/core/metadata"
+	tls_util "github.com/go-gost/x/internal/util/tls"
 	"github.com/go-gost/x/registry"
 	"golang.org/x/net/http2"
 )
@@ -97,6 +98,21 @@ func (d *h2Dialer) Dial(ctx context.Context, address string, opts ...dialer.Dial
 					return options.NetDialer.Dial(ctx, network, addr)
 				},
 			}
+		} else if !d.md.clientHello.IsZero() {
+			client.Transport = &http2.Transport{
+				DialTLSContext: func(ctx context.Context, network, addr string, cfg *tls.Config) (net.Conn, error) {
+					conn, err := options.NetDialer.Dial(ctx, network, addr)
+					if err != nil {
+						return nil, err
+					}
+					tlsConn, err := tls_util.ClientHandshake(ctx, conn, d.options.TLSConfig, &d.md.clientHello)
+					if err != nil {
+						conn.Close()
+						return nil, err
+					}
+					return tlsConn, nil
+				},
+			}
 		} else {
 			client.Transport = &http.Transport{
 				TLSClientConfig: d.options.TLSConfig,
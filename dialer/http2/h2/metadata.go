@@ -5,12 +5,15 @@ import (
 
 	mdata "github.com/go-gost/core/metadata"
 	mdutil "github.com/go-gost/core/metadata/util"
+	tls_util "github.com/go-gost/x/internal/util/tls"
 )
 
 type metadata struct {
 	host   string
 	path   string
 	header http.Header
+
+	clientHello tls_util.ClientHelloOptions
 }
 
 func (d *h2Dialer) parseMetadata(md mdata.Metadata) (err error) {
@@ -18,6 +21,11 @@ func (d *h2Dialer) parseMetadata(md mdata.Metadata) (err error) {
 		host   = "host"
 		path   = "path"
 		header = "header"
+
+		fingerprint = "fingerprint"
+		alpn        = "alpn"
+		sni         = "sni"
+		disableSNI  = "disableSNI"
 	)
 
 	d.md.host = mdutil.GetString(md, host)
@@ -29,5 +37,14 @@ func (d *h2Dialer) parseMetadata(md mdata.Metadata) (err error) {
 		}
 		d.md.header = h
 	}
+
+	d.md.clientHello = tls_util.ClientHelloOptions{
+		Fingerprint: mdutil.GetString(md, fingerprint),
+		ALPN:        mdutil.GetStrings(md, alpn),
+		SNI:         mdutil.GetString(md, sni),
+		DisableSNI:  mdutil.GetBool(md, disableSNI),
+	}
+	err = tls_util.ValidateFingerprint(d.md.clientHello.Fingerprint)
+
 	return
 }
@@ -2,10 +2,29 @@ package http2
 
 import (
 	mdata "github.com/go-gost/core/metadata"
+	mdutil "github.com/go-gost/core/metadata/util"
+	tls_util "github.com/go-gost/x/internal/util/tls"
 )
 
-type metadata struct{}
+type metadata struct {
+	clientHello tls_util.ClientHelloOptions
+}
 
 func (d *http2Dialer) parseMetadata(md mdata.Metadata) (err error) {
+	const (
+		fingerprint = "fingerprint"
+		alpn        = "alpn"
+		sni         = "sni"
+		disableSNI  = "disableSNI"
+	)
+
+	d.md.clientHello = tls_util.ClientHelloOptions{
+		Fingerprint: mdutil.GetString(md, fingerprint),
+		ALPN:        mdutil.GetStrings(md, alpn),
+		SNI:         mdutil.GetString(md, sni),
+		DisableSNI:  mdutil.GetBool(md, disableSNI),
+	}
+	err = tls_util.ValidateFingerprint(d.md.clientHello.Fingerprint)
+
 	return
 }
@@ -5,15 +5,14 @@ import (
 	"errors"
 	"net"
 	"sync"
-	"time"
 
 	"github.com/go-gost/core/dialer"
 	"github.com/go-gost/core/logger"
 	md "github.com/go-gost/core/metadata"
 	kcp_util "github.com/go-gost/x/internal/util/kcp"
+	"github.com/go-gost/x/internal/util/mux"
 	"github.com/go-gost/x/registry"
 	"github.com/xtaci/kcp-go/v5"
-	"github.com/xtaci/smux"
 	"github.com/xtaci/tcpraw"
 )
 
@@ -22,7 +21,7 @@ func init() {
 }
 
 type kcpDialer struct {
-	sessions     map[string]*muxSession
+	pool         *mux.Pool[*muxSession]
 	sessionMutex sync.Mutex
 	logger       logger.Logger
 	md           metadata
@@ -36,9 +35,8 @@ func NewDialer(opts ...dialer.Option) dialer.Dialer {
 	}
 
 	return &kcpDialer{
-		sessions: make(map[string]*muxSession),
-		logger:   options.Logger,
-		options:  options,
+		logger:  options.Logger,
+		options: options,
 	}
 }
 
@@ -48,6 +46,7 @@ func (d *kcpDialer) Init(md md.Metadata) (err error) {
 	}
 
 	d.md.config.Init()
+	d.pool = mux.NewPool[*muxSession](d.md.poolOptions)
 
 	return nil
 }
@@ -61,10 +60,9 @@ func (d *kcpDialer) Dial(ctx context.Context, addr string, opts ...dialer.DialOp
 	d.sessionMutex.Lock()
 	defer d.sessionMutex.Unlock()
 
-	session, ok := d.sessions[addr]
-	if session != nil && session.IsClosed() {
-		delete(d.sessions, addr) // session is dead
-		ok = false
+	session, ok, err := d.pool.Get(addr)
+	if err != nil {
+		return nil, err
 	}
 	if !ok {
 		var options dialer.DialOptions
@@ -102,13 +100,15 @@ func (d *kcpDialer) Dial(ctx context.Context, addr string, opts ...dialer.DialOp
 			pc.Close()
 			return nil, err
 		}
-		d.sessions[addr] = session
+		d.pool.Add(addr, session)
 	}
 
 	conn, err = session.GetConn()
 	if err != nil {
-		session.Close()
-		delete(d.sessions, addr)
+		// the session is unhealthy unless it is just full.
+		if !errors.Is(err, mux.ErrTooManyStreams) {
+			d.pool.Remove(addr, session)
+		}
 		return nil, err
 	}
 
@@ -145,16 +145,11 @@ func (d *kcpDialer) initSession(ctx context.Context, addr net.Addr, conn net.Pac
 	}
 
 	// stream multiplex
-	smuxConfig := smux.DefaultConfig()
-	smuxConfig.Version = config.SmuxVer
-	smuxConfig.MaxReceiveBuffer = config.SmuxBuf
-	smuxConfig.MaxStreamBuffer = config.StreamBuf
-	smuxConfig.KeepAliveInterval = time.Duration(config.KeepAlive) * time.Second
 	var cc net.Conn = kcpconn
 	if !config.NoComp {
 		cc = kcp_util.CompStreamConn(kcpconn)
 	}
-	session, err := smux.Client(cc, smuxConfig)
+	session, err := mux.ClientSession(cc, d.md.muxCfg)
 	if err != nil {
 		return nil, err
 	}
@@ -7,11 +7,14 @@ import (
 	mdata "github.com/go-gost/core/metadata"
 	mdutil "github.com/go-gost/core/metadata/util"
 	kcp_util "github.com/go-gost/x/internal/util/kcp"
+	"github.com/go-gost/x/internal/util/mux"
 )
 
 type metadata struct {
 	handshakeTimeout time.Duration
 	config           *kcp_util.Config
+	muxCfg           *mux.Config
+	poolOptions      mux.PoolOptions
 }
 
 func (d *kcpDialer) parseMetadata(md mdata.Metadata) (err error) {
@@ -19,6 +22,12 @@ func (d *kcpDialer) parseMetadata(md mdata.Metadata) (err error) {
 		config           = "config"
 		configFile       = "c"
 		handshakeTimeout = "handshakeTimeout"
+
+		muxProtocol    = "muxProtocol"
+		muxMaxStreams  = "muxMaxStreams"
+		muxMinSessions = "muxMinSessions"
+		muxMaxSessions = "muxMaxSessions"
+		muxIdleTimeout = "muxIdleTimeout"
 	)
 
 	if file := mdutil.GetString(md, configFile); file != "" {
@@ -43,6 +52,22 @@ func (d *kcpDialer) parseMetadata(md mdata.Metadata) (err error) {
 		d.md.config = kcp_util.DefaultConfig
 	}
 
+	// the multiplexing parameters except the protocol and stream limit come from the KCP config.
+	d.md.muxCfg = &mux.Config{
+		Protocol:          mdutil.GetString(md, muxProtocol),
+		Version:           d.md.config.SmuxVer,
+		KeepAliveInterval: time.Duration(d.md.config.KeepAlive) * time.Second,
+		MaxReceiveBuffer:  d.md.config.SmuxBuf,
+		MaxStreamBuffer:   d.md.config.StreamBuf,
+		MaxStreams:        mdutil.GetInt(md, muxMaxStreams),
+	}
+	d.md.poolOptions = mux.PoolOptions{
+		MinSessions: mdutil.GetInt(md, muxMinSessions),
+		MaxSessions: mdutil.GetInt(md, muxMaxSessions),
+		MaxStreams:  d.md.muxCfg.MaxStreams,
+		IdleTimeout: mdutil.GetDuration(md, muxIdleTimeout),
+	}
+
 	d.md.handshakeTimeout = mdutil.GetDuration(md, handshakeTimeout)
 	return
 }
@@ -2,37 +2,75 @@ package mtls
 
 import (
 	"net"
+	"sync"
+	"time"
 
-	"github.com/xtaci/smux"
+	"github.com/go-gost/x/internal/util/mux"
+)
+
+const (
+	// defaultHandshakeTimeout bounds the handshake of the new session if handshakeTimeout is not set.
+	defaultHandshakeTimeout = 30 * time.Second
 )
 
 type muxSession struct {
 	conn    net.Conn
-	session *smux.Session
+	session *mux.Session
+	// handshakeDeadline is the deadline of the handshake of the new session.
+	handshakeDeadline time.Time
+	// mu protects session which is set after the handshake.
+	mu sync.RWMutex
+}
+
+func (session *muxSession) get() *mux.Session {
+	session.mu.RLock()
+	defer session.mu.RUnlock()
+
+	return session.session
+}
+
+func (session *muxSession) set(s *mux.Session) {
+	session.mu.Lock()
+	defer session.mu.Unlock()
+
+	session.session = s
 }
 
 func (session *muxSession) GetConn() (net.Conn, error) {
-	return session.session.OpenStream()
+	return session.get().GetConn()
 }
 
 func (session *muxSession) Accept() (net.Conn, error) {
-	return session.session.AcceptStream()
+	return session.get().Accept()
 }
 
 func (session *muxSession) Close() error {
-	if session.session == nil {
+	s := session.get()
+	if s == nil {
+		// the session is in handshake.
+		if session.conn != nil {
+			return session.conn.Close()
+		}
 		return nil
 	}
-	return session.session.Close()
+	return s.Close()
 }
 
+// IsClosed reports whether the session is closed.
+// The session without the mux session is closed unless it is in handshake,
+// so that the session whose handshake is abandoned is evicted from the pool.
 func (session *muxSession) IsClosed() bool {
-	if session.session == nil {
-		return true
+	s := session.get()
+	if s == nil {
+		return !time.Now().Before(session.handshakeDeadline)
 	}
-	return session.session.IsClosed()
+	return s.IsClosed()
 }
 
 func (session *muxSession) NumStreams() int {
-	return session.session.NumStreams()
+	s := session.get()
+	if s == nil {
+		return 0
+	}
+	return s.NumStreams()
 }
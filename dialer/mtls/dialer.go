@@ -2,7 +2,6 @@ package mtls
 
 import (
 	"context"
-	"crypto/tls"
 	"errors"
 	"net"
 	"sync"
@@ -11,8 +10,9 @@ import (
 	"github.com/go-gost/core/dialer"
 	"github.com/go-gost/core/logger"
 	md "github.com/go-gost/core/metadata"
+	"github.com/go-gost/x/internal/util/mux"
+	tls_util "github.com/go-gost/x/internal/util/tls"
 	"github.com/go-gost/x/registry"
-	"github.com/xtaci/smux"
 )
 
 func init() {
@@ -20,7 +20,7 @@ func init() {
 }
 
 type mtlsDialer struct {
-	sessions     map[string]*muxSession
+	pool         *mux.Pool[*muxSession]
 	sessionMutex sync.Mutex
 	logger       logger.Logger
 	md           metadata
@@ -34,9 +34,8 @@ func NewDialer(opts ...dialer.Option) dialer.Dialer {
 	}
 
 	return &mtlsDialer{
-		sessions: make(map[string]*muxSession),
-		logger:   options.Logger,
-		options:  options,
+		logger:  options.Logger,
+		options: options,
 	}
 }
 
@@ -45,6 +44,8 @@ func (d *mtlsDialer) Init(md md.Metadata) (err error) {
 		return
 	}
 
+	d.pool = mux.NewPool[*muxSession](d.md.poolOptions)
+
 	return nil
 }
 
@@ -57,10 +58,9 @@ func (d *mtlsDialer) Dial(ctx context.Context, addr string, opts ...dialer.DialO
 	d.sessionMutex.Lock()
 	defer d.sessionMutex.Unlock()
 
-	session, ok := d.sessions[addr]
-	if session != nil && session.IsClosed() {
-		delete(d.sessions, addr) // session is dead
-		ok = false
+	session, ok, err := d.pool.Get(addr)
+	if err != nil {
+		return
 	}
 	if !ok {
 		var options dialer.DialOptions
@@ -73,8 +73,15 @@ func (d *mtlsDialer) Dial(ctx context.Context, addr string, opts ...dialer.DialO
 			return
 		}
 
-		session = &muxSession{conn: conn}
-		d.sessions[addr] = session
+		timeout := d.md.handshakeTimeout
+		if timeout <= 0 {
+			timeout = defaultHandshakeTimeout
+		}
+		session = &muxSession{
+			conn:              conn,
+			handshakeDeadline: time.Now().Add(timeout),
+		}
+		d.pool.Add(addr, session)
 	}
 
 	return session.conn, err
@@ -95,27 +102,29 @@ func (d *mtlsDialer) Handshake(ctx context.Context, conn net.Conn, options ...di
 		defer conn.SetDeadline(time.Time{})
 	}
 
-	session, ok := d.sessions[opts.Addr]
-	if session != nil && session.conn != conn {
+	session, ok := d.pool.Find(opts.Addr, func(s *muxSession) bool {
+		return s.conn == conn
+	})
+	if !ok {
 		conn.Close()
 		return nil, errors.New("mtls: unrecognized connection")
 	}
 
-	if !ok || session.session == nil {
+	if session.get() == nil {
 		s, err := d.initSession(ctx, conn)
 		if err != nil {
 			d.logger.Error(err)
-			conn.Close()
-			delete(d.sessions, opts.Addr)
+			d.pool.Remove(opts.Addr, session)
 			return nil, err
 		}
-		session = s
-		d.sessions[opts.Addr] = session
+		session.set(s.session)
 	}
 	cc, err := session.GetConn()
 	if err != nil {
-		session.Close()
-		delete(d.sessions, opts.Addr)
+		// the session is unhealthy unless it is just full.
+		if !errors.Is(err, mux.ErrTooManyStreams) {
+			d.pool.Remove(opts.Addr, session)
+		}
 		return nil, err
 	}
 
@@ -123,32 +132,14 @@ func (d *mtlsDialer) Handshake(ctx context.Context, conn net.Conn, options ...di
 }
 
 func (d *mtlsDialer) initSession(ctx context.Context, conn net.Conn) (*muxSession, error) {
-	tlsConn := tls.Client(conn, d.options.TLSConfig)
-	if err := tlsConn.HandshakeContext(ctx); err != nil {
+	tlsConn, err := tls_util.ClientHandshake(ctx, conn, d.options.TLSConfig, &d.md.clientHello)
+	if err != nil {
 		return nil, err
 	}
 	conn = tlsConn
 
 	// stream multiplex
-	smuxConfig := smux.DefaultConfig()
-	smuxConfig.KeepAliveDisabled = d.md.muxKeepAliveDisabled
-	if d.md.muxKeepAliveInterval > 0 {
-		smuxConfig.KeepAliveInterval = d.md.muxKeepAliveInterval
-	}
-	if d.md.muxKeepAliveTimeout > 0 {
-		smuxConfig.KeepAliveTimeout = d.md.muxKeepAliveTimeout
-	}
-	if d.md.muxMaxFrameSize > 0 {
-		smuxConfig.MaxFrameSize = d.md.muxMaxFrameSize
-	}
-	if d.md.muxMaxReceiveBuffer > 0 {
-		smuxConfig.MaxReceiveBuffer = d.md.muxMaxReceiveBuffer
-	}
-	if d.md.muxMaxStreamBuffer > 0 {
-		smuxConfig.MaxStreamBuffer = d.md.muxMaxStreamBuffer
-	}
-
-	session, err := smux.Client(conn, smuxConfig)
+	session, err := mux.ClientSession(conn, d.md.muxCfg)
 	if err != nil {
 		return nil, err
 	}
@@ -5,39 +5,69 @@ import (
 
 	mdata "github.com/go-gost/core/metadata"
 	mdutil "github.com/go-gost/core/metadata/util"
+	"github.com/go-gost/x/internal/util/mux"
+	tls_util "github.com/go-gost/x/internal/util/tls"
 )
 
 type metadata struct {
 	handshakeTimeout time.Duration
 
-	muxKeepAliveDisabled bool
-	muxKeepAliveInterval time.Duration
-	muxKeepAliveTimeout  time.Duration
-	muxMaxFrameSize      int
-	muxMaxReceiveBuffer  int
-	muxMaxStreamBuffer   int
+	muxCfg      *mux.Config
+	poolOptions mux.PoolOptions
+
+	clientHello tls_util.ClientHelloOptions
 }
 
 func (d *mtlsDialer) parseMetadata(md mdata.Metadata) (err error) {
 	const (
 		handshakeTimeout = "handshakeTimeout"
 
+		muxProtocol          = "muxProtocol"
+		muxVersion           = "muxVersion"
 		muxKeepAliveDisabled = "muxKeepAliveDisabled"
 		muxKeepAliveInterval = "muxKeepAliveInterval"
 		muxKeepAliveTimeout  = "muxKeepAliveTimeout"
 		muxMaxFrameSize      = "muxMaxFrameSize"
 		muxMaxReceiveBuffer  = "muxMaxReceiveBuffer"
 		muxMaxStreamBuffer   = "muxMaxStreamBuffer"
+		muxMaxStreams        = "muxMaxStreams"
+		muxMinSessions       = "muxMinSessions"
+		muxMaxSessions       = "muxMaxSessions"
+		muxIdleTimeout       = "muxIdleTimeout"
+
+		fingerprint = "fingerprint"
+		alpn        = "alpn"
+		sni         = "sni"
+		disableSNI  = "disableSNI"
 	)
 
 	d.md.handshakeTimeout = mdutil.GetDuration(md, handshakeTimeout)
 
-	d.md.muxKeepAliveDisabled = mdutil.GetBool(md, muxKeepAliveDisabled)
-	d.md.muxKeepAliveInterval = mdutil.GetDuration(md, muxKeepAliveInterval)
-	d.md.muxKeepAliveTimeout = mdutil.GetDuration(md, muxKeepAliveTimeout)
-	d.md.muxMaxFrameSize = mdutil.GetInt(md, muxMaxFrameSize)
-	d.md.muxMaxReceiveBuffer = mdutil.GetInt(md, muxMaxReceiveBuffer)
-	d.md.muxMaxStreamBuffer = mdutil.GetInt(md, muxMaxStreamBuffer)
+	d.md.muxCfg = &mux.Config{
+		Protocol:          mdutil.GetString(md, muxProtocol),
+		Version:           mdutil.GetInt(md, muxVersion),
+		KeepAliveDisabled: mdutil.GetBool(md, muxKeepAliveDisabled),
+		KeepAliveInterval: mdutil.GetDuration(md, muxKeepAliveInterval),
+		KeepAliveTimeout:  mdutil.GetDuration(md, muxKeepAliveTimeout),
+		MaxFrameSize:      mdutil.GetInt(md, muxMaxFrameSize),
+		MaxReceiveBuffer:  mdutil.GetInt(md, muxMaxReceiveBuffer),
+		MaxStreamBuffer:   mdutil.GetInt(md, muxMaxStreamBuffer),
+		MaxStreams:        mdutil.GetInt(md, muxMaxStreams),
+	}
+	d.md.poolOptions = mux.PoolOptions{
+		MinSessions: mdutil.GetInt(md, muxMinSessions),
+		MaxSessions: mdutil.GetInt(md, muxMaxSessions),
+		MaxStreams:  d.md.muxCfg.MaxStreams,
+		IdleTimeout: mdutil.GetDuration(md, muxIdleTimeout),
+	}
+
+	d.md.clientHello = tls_util.ClientHelloOptions{
+		Fingerprint: mdutil.GetString(md, fingerprint),
+		ALPN:        mdutil.GetStrings(md, alpn),
+		SNI:         mdutil.GetString(md, sni),
+		DisableSNI:  mdutil.GetBool(md, disableSNI),
+	}
+	err = tls_util.ValidateFingerprint(d.md.clientHello.Fingerprint)
 
 	return
 }
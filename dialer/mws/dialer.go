@@ -10,10 +10,11 @@ import (
 
 	"github.com/go-gost/core/dialer"
 	md "github.com/go-gost/core/metadata"
+	"github.com/go-gost/x/internal/util/mux"
+	tls_util "github.com/go-gost/x/internal/util/tls"
 	ws_util "github.com/go-gost/x/internal/util/ws"
 	"github.com/go-gost/x/registry"
 	"github.com/gorilla/websocket"
-	"github.com/xtaci/smux"
 )
 
 func init() {
@@ -22,7 +23,7 @@ func init() {
 }
 
 type mwsDialer struct {
-	sessions     map[string]*muxSession
+	pool         *mux.Pool[*muxSession]
 	sessionMutex sync.Mutex
 	tlsEnabled   bool
 	md           metadata
@@ -36,8 +37,7 @@ func NewDialer(opts ...dialer.Option) dialer.Dialer {
 	}
 
 	return &mwsDialer{
-		sessions: make(map[string]*muxSession),
-		options:  options,
+		options: options,
 	}
 }
 
@@ -49,7 +49,6 @@ func NewTLSDialer(opts ...dialer.Option) dialer.Dialer {
 
 	return &mwsDialer{
 		tlsEnabled: true,
-		sessions:   make(map[string]*muxSession),
 		options:    options,
 	}
 }
@@ -58,6 +57,8 @@ func (d *mwsDialer) Init(md md.Metadata) (err error) {
 		return
 	}
 
+	d.pool = mux.NewPool[*muxSession](d.md.poolOptions)
+
 	return nil
 }
 
@@ -70,10 +71,9 @@ func (d *mwsDialer) Dial(ctx context.Context, addr string, opts ...dialer.DialOp
 	d.sessionMutex.Lock()
 	defer d.sessionMutex.Unlock()
 
-	session, ok := d.sessions[addr]
-	if session != nil && session.IsClosed() {
-		delete(d.sessions, addr) // session is dead
-		ok = false
+	session, ok, err := d.pool.Get(addr)
+	if err != nil {
+		return
 	}
 	if !ok {
 		var options dialer.DialOptions
@@ -86,8 +86,15 @@ func (d *mwsDialer) Dial(ctx context.Context, addr string, opts ...dialer.DialOp
 			return
 		}
 
-		session = &muxSession{conn: conn}
-		d.sessions[addr] = session
+		timeout := d.md.handshakeTimeout
+		if timeout <= 0 {
+			timeout = defaultHandshakeTimeout
+		}
+		session = &muxSession{
+			conn:              conn,
+			handshakeDeadline: time.Now().Add(timeout),
+		}
+		d.pool.Add(addr, session)
 	}
 
 	return session.conn, err
@@ -103,13 +110,15 @@ func (d *mwsDialer) Handshake(ctx context.Context, conn net.Conn, options ...dia
 	d.sessionMutex.Lock()
 	defer d.sessionMutex.Unlock()
 
-	session, ok := d.sessions[opts.Addr]
-	if session != nil && session.conn != conn {
+	session, ok := d.pool.Find(opts.Addr, func(s *muxSession) bool {
+		return s.conn == conn
+	})
+	if !ok {
 		conn.Close()
 		return nil, errors.New("mtls: unrecognized connection")
 	}
 
-	if !ok || session.session == nil {
+	if session.get() == nil {
 		host := d.md.host
 		if host == "" {
 			host = opts.Addr
@@ -117,17 +126,17 @@ func (d *mwsDialer) Handshake(ctx context.Context, conn net.Conn, options ...dia
 		s, err := d.initSession(ctx, host, conn)
 		if err != nil {
 			d.options.Logger.Error(err)
-			conn.Close()
-			delete(d.sessions, opts.Addr)
+			d.pool.Remove(opts.Addr, session)
 			return nil, err
 		}
-		session = s
-		d.sessions[opts.Addr] = session
+		session.set(s.session)
 	}
 	cc, err := session.GetConn()
 	if err != nil {
-		session.Close()
-		delete(d.sessions, opts.Addr)
+		// the session is unhealthy unless it is just full.
+		if !errors.Is(err, mux.ErrTooManyStreams) {
+			d.pool.Remove(opts.Addr, session)
+		}
 		return nil, err
 	}
 
@@ -149,6 +158,16 @@ func (d *mwsDialer) initSession(ctx context.Context, host string, conn net.Conn)
 	if d.tlsEnabled {
 		url.Scheme = "wss"
 		dialer.TLSClientConfig = d.options.TLSConfig
+		if !d.md.clientHello.IsZero() {
+			clientHello := d.md.clientHello
+			// the websocket handshake is done over HTTP/1.1.
+			if len(clientHello.ALPN) == 0 {
+				clientHello.ALPN = []string{"http/1.1"}
+			}
+			dialer.NetDialTLSContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return tls_util.ClientHandshake(ctx, conn, d.options.TLSConfig, &clientHello)
+			}
+		}
 	}
 
 	if d.md.handshakeTimeout > 0 {
@@ -177,25 +196,7 @@ func (d *mwsDialer) initSession(ctx context.Context, host string, conn net.Conn)
 	}
 
 	// stream multiplex
-	smuxConfig := smux.DefaultConfig()
-	smuxConfig.KeepAliveDisabled = d.md.muxKeepAliveDisabled
-	if d.md.muxKeepAliveInterval > 0 {
-		smuxConfig.KeepAliveInterval = d.md.muxKeepAliveInterval
-	}
-	if d.md.muxKeepAliveTimeout > 0 {
-		smuxConfig.KeepAliveTimeout = d.md.muxKeepAliveTimeout
-	}
-	if d.md.muxMaxFrameSize > 0 {
-		smuxConfig.MaxFrameSize = d.md.muxMaxFrameSize
-	}
-	if d.md.muxMaxReceiveBuffer > 0 {
-		smuxConfig.MaxReceiveBuffer = d.md.muxMaxReceiveBuffer
-	}
-	if d.md.muxMaxStreamBuffer > 0 {
-		smuxConfig.MaxStreamBuffer = d.md.muxMaxStreamBuffer
-	}
-
-	session, err := smux.Client(cc, smuxConfig)
+	session, err := mux.ClientSession(cc, d.md.muxCfg)
 	if err != nil {
 		return nil, err
 	}
@@ -6,6 +6,8 @@ import (
 
 	mdata "github.com/go-gost/core/metadata"
 	mdutil "github.com/go-gost/core/metadata/util"
+	"github.com/go-gost/x/internal/util/mux"
+	tls_util "github.com/go-gost/x/internal/util/tls"
 )
 
 const (
@@ -22,15 +24,13 @@ type metadata struct {
 	writeBufferSize   int
 	enableCompression bool
 
-	muxKeepAliveDisabled bool
-	muxKeepAliveInterval time.Duration
-	muxKeepAliveTimeout  time.Duration
-	muxMaxFrameSize      int
-	muxMaxReceiveBuffer  int
-	muxMaxStreamBuffer   int
+	muxCfg      *mux.Config
+	poolOptions mux.PoolOptions
 
 	header    http.Header
 	keepAlive time.Duration
+
+	clientHello tls_util.ClientHelloOptions
 }
 
 func (d *mwsDialer) parseMetadata(md mdata.Metadata) (err error) {
@@ -47,12 +47,23 @@ func (d *mwsDialer) parseMetadata(md mdata.Metadata) (err error) {
 		header    = "header"
 		keepAlive = "keepAlive"
 
+		muxProtocol          = "muxProtocol"
+		muxVersion           = "muxVersion"
 		muxKeepAliveDisabled = "muxKeepAliveDisabled"
 		muxKeepAliveInterval = "muxKeepAliveInterval"
 		muxKeepAliveTimeout  = "muxKeepAliveTimeout"
 		muxMaxFrameSize      = "muxMaxFrameSize"
 		muxMaxReceiveBuffer  = "muxMaxReceiveBuffer"
 		muxMaxStreamBuffer   = "muxMaxStreamBuffer"
+		muxMaxStreams        = "muxMaxStreams"
+		muxMinSessions       = "muxMinSessions"
+		muxMaxSessions       = "muxMaxSessions"
+		muxIdleTimeout       = "muxIdleTimeout"
+
+		fingerprint = "fingerprint"
+		alpn        = "alpn"
+		sni         = "sni"
+		disableSNI  = "disableSNI"
 	)
 
 	d.md.host = mdutil.GetString(md, host)
@@ -62,12 +73,23 @@ func (d *mwsDialer) parseMetadata(md mdata.Metadata) (err error) {
 		d.md.path = defaultPath
 	}
 
-	d.md.muxKeepAliveDisabled = mdutil.GetBool(md, muxKeepAliveDisabled)
-	d.md.muxKeepAliveInterval = mdutil.GetDuration(md, muxKeepAliveInterval)
-	d.md.muxKeepAliveTimeout = mdutil.GetDuration(md, muxKeepAliveTimeout)
-	d.md.muxMaxFrameSize = mdutil.GetInt(md, muxMaxFrameSize)
-	d.md.muxMaxReceiveBuffer = mdutil.GetInt(md, muxMaxReceiveBuffer)
-	d.md.muxMaxStreamBuffer = mdutil.GetInt(md, muxMaxStreamBuffer)
+	d.md.muxCfg = &mux.Config{
+		Protocol:          mdutil.GetString(md, muxProtocol),
+		Version:           mdutil.GetInt(md, muxVersion),
+		KeepAliveDisabled: mdutil.GetBool(md, muxKeepAliveDisabled),
+		KeepAliveInterval: mdutil.GetDuration(md, muxKeepAliveInterval),
+		KeepAliveTimeout:  mdutil.GetDuration(md, muxKeepAliveTimeout),
+		MaxFrameSize:      mdutil.GetInt(md, muxMaxFrameSize),
+		MaxReceiveBuffer:  mdutil.GetInt(md, muxMaxReceiveBuffer),
+		MaxStreamBuffer:   mdutil.GetInt(md, muxMaxStreamBuffer),
+		MaxStreams:        mdutil.GetInt(md, muxMaxStreams),
+	}
+	d.md.poolOptions = mux.PoolOptions{
+		MinSessions: mdutil.GetInt(md, muxMinSessions),
+		MaxSessions: mdutil.GetInt(md, muxMaxSessions),
+		MaxStreams:  d.md.muxCfg.MaxStreams,
+		IdleTimeout: mdutil.GetDuration(md, muxIdleTimeout),
+	}
 
 	d.md.handshakeTimeout = mdutil.GetDuration(md, handshakeTimeout)
 	d.md.readHeaderTimeout = mdutil.GetDuration(md, readHeaderTimeout)
@@ -84,5 +106,13 @@ func (d *mwsDialer) parseMetadata(md mdata.Metadata) (err error) {
 	}
 	d.md.keepAlive = mdutil.GetDuration(md, keepAlive)
 
+	d.md.clientHello = tls_util.ClientHelloOptions{
+		Fingerprint: mdutil.GetString(md, fingerprint),
+		ALPN:        mdutil.GetStrings(md, alpn),
+		SNI:         mdutil.GetString(md, sni),
+		DisableSNI:  mdutil.GetBool(md, disableSNI),
+	}
+	err = tls_util.ValidateFingerprint(d.md.clientHello.Fingerprint)
+
 	return
 }
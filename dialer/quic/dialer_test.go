@@ -0,0 +1,116 @@
+package quic
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"testing"
+	"time"
+
+	net_dialer "github.com/go-gost/core/common/net/dialer"
+	"github.com/go-gost/core/dialer"
+	"github.com/go-gost/core/listener"
+	"github.com/go-gost/core/logger"
+	quic_listener "github.com/go-gost/x/listener/quic"
+	xlogger "github.com/go-gost/x/logger"
+	mdx "github.com/go-gost/x/metadata"
+)
+
+func serverTLSConfig(t *testing.T) *tls.Config {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "quic"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+	}
+}
+
+func TestDialListen(t *testing.T) {
+	log := xlogger.NewLogger(xlogger.LevelLoggerOption(logger.FatalLevel))
+
+	tests := []struct {
+		name string
+		md   map[string]any
+	}{
+		{"plain", nil},
+		{"cipher", map[string]any{"cipherKey": "0123456789abcdef"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ln := quic_listener.NewListener(
+				listener.AddrOption("127.0.0.1:0"),
+				listener.TLSConfigOption(serverTLSConfig(t)),
+				listener.LoggerOption(log),
+			)
+			if err := ln.Init(mdx.NewMetadata(tt.md)); err != nil {
+				t.Fatal(err)
+			}
+			defer ln.Close()
+
+			go func() {
+				for {
+					conn, err := ln.Accept()
+					if err != nil {
+						return
+					}
+					go func() {
+						defer conn.Close()
+						io.Copy(conn, conn)
+					}()
+				}
+			}()
+
+			d := NewDialer(
+				dialer.TLSConfigOption(&tls.Config{InsecureSkipVerify: true}),
+				dialer.LoggerOption(log),
+			)
+			if err := d.Init(mdx.NewMetadata(tt.md)); err != nil {
+				t.Fatal(err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			for i := 0; i < 2; i++ {
+				conn, err := d.Dial(ctx, ln.Addr().String(), dialer.NetDialerDialOption(&net_dialer.NetDialer{}))
+				if err != nil {
+					t.Fatal(err)
+				}
+				conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+				msg := []byte("hello")
+				if _, err := conn.Write(msg); err != nil {
+					t.Fatal(err)
+				}
+				buf := make([]byte, len(msg))
+				if _, err := io.ReadFull(conn, buf); err != nil {
+					t.Fatal(err)
+				}
+				if string(buf) != string(msg) {
+					t.Errorf("got %q, want %q", buf, msg)
+				}
+				conn.Close()
+			}
+		})
+	}
+}
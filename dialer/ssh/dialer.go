@@ -139,7 +139,7 @@ func (d *sshDialer) Handshake(ctx context.Context, conn net.Conn, options ...dia
 func (d *sshDialer) initSession(ctx context.Context, addr string, conn net.Conn) (*sshSession, error) {
 	config := ssh.ClientConfig{
 		Timeout:         30 * time.Second,
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		HostKeyCallback: d.md.hostKeyCallback,
 	}
 	if d.options.Auth != nil {
 		config.User = d.options.Auth.Username()
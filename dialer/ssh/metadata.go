@@ -6,12 +6,14 @@ import (
 
 	mdata "github.com/go-gost/core/metadata"
 	mdutil "github.com/go-gost/core/metadata/util"
+	ssh_util "github.com/go-gost/x/internal/util/ssh"
 	"golang.org/x/crypto/ssh"
 )
 
 type metadata struct {
 	handshakeTimeout time.Duration
 	signer           ssh.Signer
+	hostKeyCallback  ssh.HostKeyCallback
 }
 
 func (d *sshDialer) parseMetadata(md mdata.Metadata) (err error) {
@@ -19,6 +21,9 @@ func (d *sshDialer) parseMetadata(md mdata.Metadata) (err error) {
 		handshakeTimeout = "handshakeTimeout"
 		privateKeyFile   = "privateKeyFile"
 		passphrase       = "passphrase"
+		knownHosts       = "knownHosts"
+		hostKey          = "hostKey"
+		tofu             = "tofu"
 	)
 
 	if key := mdutil.GetString(md, privateKeyFile); key != "" {
@@ -38,6 +43,15 @@ func (d *sshDialer) parseMetadata(md mdata.Metadata) (err error) {
 		}
 	}
 
+	d.md.hostKeyCallback, err = ssh_util.HostKeyCallback(ssh_util.HostKeyOptions{
+		KnownHosts: mdutil.GetString(md, knownHosts),
+		HostKey:    mdutil.GetString(md, hostKey),
+		TOFU:       mdutil.GetBool(md, tofu),
+	})
+	if err != nil {
+		return
+	}
+
 	d.md.handshakeTimeout = mdutil.GetDuration(md, handshakeTimeout)
 
 	return
@@ -132,7 +132,7 @@ func (d *sshdDialer) Handshake(ctx context.Context, conn net.Conn, options ...di
 func (d *sshdDialer) initSession(ctx context.Context, addr string, conn net.Conn) (*sshSession, error) {
 	config := ssh.ClientConfig{
 		// Timeout:         timeout,
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		HostKeyCallback: d.md.hostKeyCallback,
 	}
 	if d.options.Auth != nil {
 		config.User = d.options.Auth.Username()
@@ -4,9 +4,11 @@ import (
 	"context"
 	"net"
 
+	net_dialer "github.com/go-gost/core/common/net/dialer"
 	"github.com/go-gost/core/dialer"
 	"github.com/go-gost/core/logger"
 	md "github.com/go-gost/core/metadata"
+	xnet "github.com/go-gost/x/internal/net"
 	"github.com/go-gost/x/registry"
 )
 
@@ -40,7 +42,21 @@ func (d *tcpDialer) Dial(ctx context.Context, addr string, opts ...dialer.DialOp
 		opt(&options)
 	}
 
-	conn, err := options.NetDialer.Dial(ctx, "tcp", addr)
+	var conn net.Conn
+	var err error
+	if options.NetDialer != nil && options.NetDialer.DialFunc != nil {
+		// the address is resolved by the route of the dial function.
+		conn, err = options.NetDialer.Dial(ctx, "tcp", addr)
+	} else {
+		netd := net_dialer.NetDialer{}
+		if options.NetDialer != nil {
+			netd = *options.NetDialer
+		}
+		if netd.Timeout <= 0 {
+			netd.Timeout = net_dialer.DefaultTimeout
+		}
+		conn, err = xnet.DialHappyEyeballs(ctx, "tcp", addr, netd.Dial)
+	}
 	if err != nil {
 		d.logger.Error(err)
 	}
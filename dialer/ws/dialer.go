@@ -8,6 +8,7 @@ import (
 
 	"github.com/go-gost/core/dialer"
 	md "github.com/go-gost/core/metadata"
+	tls_util "github.com/go-gost/x/internal/util/tls"
 	ws_util "github.com/go-gost/x/internal/util/ws"
 	"github.com/go-gost/x/registry"
 	"github.com/gorilla/websocket"
@@ -95,6 +96,16 @@ func (d *wsDialer) Handshake(ctx context.Context, conn net.Conn, options ...dial
 	if d.tlsEnabled {
 		url.Scheme = "wss"
 		dialer.TLSClientConfig = d.options.TLSConfig
+		if !d.md.clientHello.IsZero() {
+			clientHello := d.md.clientHello
+			// the websocket handshake is done over HTTP/1.1.
+			if len(clientHello.ALPN) == 0 {
+				clientHello.ALPN = []string{"http/1.1"}
+			}
+			dialer.NetDialTLSContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return tls_util.ClientHandshake(ctx, conn, d.options.TLSConfig, &clientHello)
+			}
+		}
 	}
 
 	c, resp, err := dialer.DialContext(ctx, url.String(), d.md.header)
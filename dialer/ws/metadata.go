@@ -6,6 +6,7 @@ import (
 
 	mdata "github.com/go-gost/core/metadata"
 	mdutil "github.com/go-gost/core/metadata/util"
+	tls_util "github.com/go-gost/x/internal/util/tls"
 )
 
 const (
@@ -25,6 +26,8 @@ type metadata struct {
 
 	header    http.Header
 	keepAlive time.Duration
+
+	clientHello tls_util.ClientHelloOptions
 }
 
 func (d *wsDialer) parseMetadata(md mdata.Metadata) (err error) {
@@ -41,6 +44,11 @@ func (d *wsDialer) parseMetadata(md mdata.Metadata) (err error) {
 		header          = "header"
 		keepAlive       = "keepAlive"
 		keepAlivePeriod = "ttl"
+
+		fingerprint = "fingerprint"
+		alpn        = "alpn"
+		sni         = "sni"
+		disableSNI  = "disableSNI"
 	)
 
 	d.md.host = mdutil.GetString(md, host)
@@ -71,5 +79,13 @@ func (d *wsDialer) parseMetadata(md mdata.Metadata) (err error) {
 		}
 	}
 
+	d.md.clientHello = tls_util.ClientHelloOptions{
+		Fingerprint: mdutil.GetString(md, fingerprint),
+		ALPN:        mdutil.GetStrings(md, alpn),
+		SNI:         mdutil.GetString(md, sni),
+		DisableSNI:  mdutil.GetBool(md, disableSNI),
+	}
+	err = tls_util.ValidateFingerprint(d.md.clientHello.Fingerprint)
+
 	return
 }
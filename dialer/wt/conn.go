@@ -0,0 +1,29 @@
+package wt
+
+import (
+	"context"
+	"net"
+
+	wt_util "github.com/go-gost/x/internal/util/wt"
+	"github.com/quic-go/webtransport-go"
+)
+
+type wtSession struct {
+	session *webtransport.Session
+}
+
+func (session *wtSession) GetConn(ctx context.Context) (net.Conn, error) {
+	stream, err := session.session.OpenStreamSync(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return wt_util.Conn(session.session, stream), nil
+}
+
+func (session *wtSession) IsClosed() bool {
+	return session.session.Context().Err() != nil
+}
+
+func (session *wtSession) Close() error {
+	return session.session.CloseWithError(0, "closed")
+}
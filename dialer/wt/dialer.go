@@ -0,0 +1,151 @@
+package wt
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/url"
+	"sync"
+
+	"github.com/go-gost/core/dialer"
+	"github.com/go-gost/core/logger"
+	md "github.com/go-gost/core/metadata"
+	"github.com/go-gost/x/registry"
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+	"github.com/quic-go/webtransport-go"
+)
+
+func init() {
+	registry.DialerRegistry().Register("wt", NewDialer)
+}
+
+type wtDialer struct {
+	sessions     map[string]*wtSession
+	sessionMutex sync.Mutex
+	logger       logger.Logger
+	md           metadata
+	options      dialer.Options
+}
+
+func NewDialer(opts ...dialer.Option) dialer.Dialer {
+	options := dialer.Options{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return &wtDialer{
+		sessions: make(map[string]*wtSession),
+		logger:   options.Logger,
+		options:  options,
+	}
+}
+
+func (d *wtDialer) Init(md md.Metadata) (err error) {
+	return d.parseMetadata(md)
+}
+
+func (d *wtDialer) Dial(ctx context.Context, addr string, opts ...dialer.DialOption) (conn net.Conn, err error) {
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "0")
+	}
+
+	d.sessionMutex.Lock()
+	defer d.sessionMutex.Unlock()
+
+	session, ok := d.sessions[addr]
+	if ok && session.IsClosed() {
+		delete(d.sessions, addr)
+		ok = false
+	}
+	if !ok {
+		options := &dialer.DialOptions{}
+		for _, opt := range opts {
+			opt(options)
+		}
+
+		session, err = d.initSession(ctx, addr, options)
+		if err != nil {
+			d.logger.Error(err)
+			return nil, err
+		}
+
+		d.sessions[addr] = session
+	}
+
+	conn, err = session.GetConn(ctx)
+	if err != nil {
+		session.Close()
+		delete(d.sessions, addr)
+		return nil, err
+	}
+
+	return
+}
+
+func (d *wtDialer) initSession(ctx context.Context, addr string, options *dialer.DialOptions) (*wtSession, error) {
+	udpAddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	host := d.md.host
+	if host == "" {
+		host = options.Host
+	}
+	if host == "" {
+		host = addr
+	}
+
+	tlsCfg := d.options.TLSConfig
+	if tlsCfg == nil {
+		tlsCfg = &tls.Config{}
+	}
+	tlsCfg = tlsCfg.Clone()
+	tlsCfg.NextProtos = []string{http3.NextProtoH3}
+
+	wd := &webtransport.Dialer{
+		TLSClientConfig: tlsCfg,
+		QUICConfig: &quic.Config{
+			KeepAlivePeriod:                  d.md.keepAlivePeriod,
+			HandshakeIdleTimeout:             d.md.handshakeTimeout,
+			MaxIdleTimeout:                   d.md.maxIdleTimeout,
+			EnableDatagrams:                  true,
+			EnableStreamResetPartialDelivery: true,
+		},
+		DialAddr: func(ctx context.Context, _ string, tlsCfg *tls.Config, cfg *quic.Config) (*quic.Conn, error) {
+			c, err := options.NetDialer.Dial(ctx, "udp", "")
+			if err != nil {
+				return nil, err
+			}
+			pc, ok := c.(net.PacketConn)
+			if !ok {
+				c.Close()
+				return nil, errors.New("wt: wrong connection type")
+			}
+
+			conn, err := quic.DialEarly(ctx, pc, udpAddr, tlsCfg, cfg)
+			if err != nil {
+				pc.Close()
+				return nil, err
+			}
+			context.AfterFunc(conn.Context(), func() { pc.Close() })
+			return conn, nil
+		},
+	}
+
+	u := url.URL{Scheme: "https", Host: host, Path: d.md.path}
+	// the body of the response is the session stream which must be kept open.
+	_, session, err := wd.Dial(ctx, u.String(), d.md.header.Clone())
+	if err != nil {
+		return nil, err
+	}
+
+	return &wtSession{session: session}, nil
+}
+
+// Multiplex implements dialer.Multiplexer interface.
+func (d *wtDialer) Multiplex() bool {
+	return true
+}
@@ -0,0 +1,149 @@
+package wt
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"testing"
+	"time"
+
+	net_dialer "github.com/go-gost/core/common/net/dialer"
+	"github.com/go-gost/core/dialer"
+	"github.com/go-gost/core/listener"
+	"github.com/go-gost/core/logger"
+	wt_listener "github.com/go-gost/x/listener/wt"
+	xlogger "github.com/go-gost/x/logger"
+	mdx "github.com/go-gost/x/metadata"
+)
+
+func serverTLSConfig(t *testing.T) *tls.Config {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "wt"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+	}
+}
+
+func TestDialListen(t *testing.T) {
+	log := xlogger.NewLogger(xlogger.LevelLoggerOption(logger.FatalLevel))
+
+	tests := []struct {
+		name   string
+		ln     map[string]any
+		dialer map[string]any
+		denied bool
+	}{
+		{name: "plain"},
+		{
+			name:   "path",
+			ln:     map[string]any{"path": "/tunnel"},
+			dialer: map[string]any{"path": "/tunnel"},
+		},
+		{
+			name:   "any origin",
+			dialer: map[string]any{"header": map[string]any{"Origin": "https://example.org"}},
+		},
+		{
+			name:   "origin allowed",
+			ln:     map[string]any{"origins": []any{"https://*.example.com"}},
+			dialer: map[string]any{"header": map[string]any{"Origin": "https://www.Example.com"}},
+		},
+		{
+			name: "no origin",
+			ln:   map[string]any{"origins": []any{"https://*.example.com"}},
+		},
+		{
+			name:   "origin denied",
+			ln:     map[string]any{"origins": []any{"https://*.example.com"}},
+			dialer: map[string]any{"header": map[string]any{"Origin": "https://example.org"}},
+			denied: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ln := wt_listener.NewListener(
+				listener.AddrOption("127.0.0.1:0"),
+				listener.TLSConfigOption(serverTLSConfig(t)),
+				listener.LoggerOption(log),
+			)
+			if err := ln.Init(mdx.NewMetadata(tt.ln)); err != nil {
+				t.Fatal(err)
+			}
+			defer ln.Close()
+
+			go func() {
+				for {
+					conn, err := ln.Accept()
+					if err != nil {
+						return
+					}
+					go func() {
+						defer conn.Close()
+						io.Copy(conn, conn)
+					}()
+				}
+			}()
+
+			d := NewDialer(
+				dialer.TLSConfigOption(&tls.Config{InsecureSkipVerify: true}),
+				dialer.LoggerOption(log),
+			)
+			if err := d.Init(mdx.NewMetadata(tt.dialer)); err != nil {
+				t.Fatal(err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			// the streams of the session are reused by the second dial.
+			for i := 0; i < 2; i++ {
+				conn, err := d.Dial(ctx, ln.Addr().String(), dialer.NetDialerDialOption(&net_dialer.NetDialer{}))
+				if tt.denied {
+					if err == nil {
+						conn.Close()
+						t.Fatal("the origin not allowed is connected")
+					}
+					return
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+				msg := []byte("hello")
+				if _, err := conn.Write(msg); err != nil {
+					t.Fatal(err)
+				}
+				buf := make([]byte, len(msg))
+				if _, err := io.ReadFull(conn, buf); err != nil {
+					t.Fatal(err)
+				}
+				if string(buf) != string(msg) {
+					t.Errorf("got %q, want %q", buf, msg)
+				}
+				conn.Close()
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package wt
+
+import (
+	"net/http"
+	"time"
+
+	mdata "github.com/go-gost/core/metadata"
+	mdutil "github.com/go-gost/core/metadata/util"
+)
+
+const (
+	defaultPath = "/wt"
+)
+
+type metadata struct {
+	host   string
+	path   string
+	header http.Header
+
+	keepAlivePeriod  time.Duration
+	handshakeTimeout time.Duration
+	maxIdleTimeout   time.Duration
+}
+
+func (d *wtDialer) parseMetadata(md mdata.Metadata) (err error) {
+	const (
+		host   = "host"
+		path   = "path"
+		header = "header"
+
+		keepAlive        = "keepAlive"
+		keepAlivePeriod  = "ttl"
+		handshakeTimeout = "handshakeTimeout"
+		maxIdleTimeout   = "maxIdleTimeout"
+	)
+
+	d.md.host = mdutil.GetString(md, host)
+
+	d.md.path = mdutil.GetString(md, path)
+	if d.md.path == "" {
+		d.md.path = defaultPath
+	}
+
+	if m := mdutil.GetStringMapString(md, header); len(m) > 0 {
+		h := http.Header{}
+		for k, v := range m {
+			h.Add(k, v)
+		}
+		d.md.header = h
+	}
+
+	if mdutil.GetBool(md, keepAlive) {
+		d.md.keepAlivePeriod = mdutil.GetDuration(md, keepAlivePeriod)
+		if d.md.keepAlivePeriod <= 0 {
+			d.md.keepAlivePeriod = 10 * time.Second
+		}
+	}
+	d.md.handshakeTimeout = mdutil.GetDuration(md, handshakeTimeout)
+	d.md.maxIdleTimeout = mdutil.GetDuration(md, maxIdleTimeout)
+
+	return
+}
@@ -16,6 +16,7 @@ import (
 	md "github.com/go-gost/core/metadata"
 	xchain "github.com/go-gost/x/chain"
 	resolver_util "github.com/go-gost/x/internal/util/resolver"
+	xrecorder "github.com/go-gost/x/recorder"
 	"github.com/go-gost/x/registry"
 	"github.com/go-gost/x/resolver/exchanger"
 	"github.com/miekg/dns"
@@ -177,6 +178,9 @@ func (h *dnsHandler) exchange(ctx context.Context, msg []byte, log logger.Logger
 	if len(mq.Question) == 0 {
 		return nil, errors.New("msg: empty question")
 	}
+	xrecorder.Record(ctx, xrecorder.RecorderServiceHandlerDNSQuery,
+		[]byte(fmt.Sprintf("%s %s %s", mq.Question[0].Name,
+			dns.ClassToString[mq.Question[0].Qclass], dns.TypeToString[mq.Question[0].Qtype])))
 
 	resolver_util.AddSubnetOpt(&mq, h.md.clientIP)
 
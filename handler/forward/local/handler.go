@@ -12,6 +12,7 @@ import (
 	md "github.com/go-gost/core/metadata"
 	xchain "github.com/go-gost/x/chain"
 	netpkg "github.com/go-gost/x/internal/net"
+	xrecorder "github.com/go-gost/x/recorder"
 	"github.com/go-gost/x/registry"
 )
 
@@ -101,6 +102,7 @@ func (h *forwardHandler) Handle(ctx context.Context, conn net.Conn, opts ...hand
 	})
 
 	log.Debugf("%s >> %s", conn.RemoteAddr(), target.Addr)
+	xrecorder.Record(ctx, xrecorder.RecorderServiceHandlerDstAddress, []byte(target.Addr))
 
 	cc, err := h.router.Dial(ctx, network, target.Addr)
 	if err != nil {
@@ -11,6 +11,7 @@ import (
 	"github.com/go-gost/core/handler"
 	md "github.com/go-gost/core/metadata"
 	netpkg "github.com/go-gost/x/internal/net"
+	xrecorder "github.com/go-gost/x/recorder"
 	"github.com/go-gost/x/registry"
 )
 
@@ -95,6 +96,7 @@ func (h *forwardHandler) Handle(ctx context.Context, conn net.Conn, opts ...hand
 	})
 
 	log.Debugf("%s >> %s", conn.RemoteAddr(), target.Addr)
+	xrecorder.Record(ctx, xrecorder.RecorderServiceHandlerDstAddress, []byte(target.Addr))
 
 	cc, err := h.router.Dial(ctx, network, target.Addr)
 	if err != nil {
@@ -0,0 +1,39 @@
+package http
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+
+	"github.com/go-gost/core/logger"
+	tls_util "github.com/go-gost/x/internal/util/tls"
+)
+
+// acmeChallengeHandler returns the handler for the ACME HTTP-01 challenge request,
+// or nil if req is not a direct request for the challenge.
+func (h *httpHandler) acmeChallengeHandler(req *http.Request) http.Handler {
+	if req.URL.IsAbs() || req.Method != http.MethodGet {
+		return nil
+	}
+	return tls_util.ACMEHTTPHandler(req.Host, req.URL.Path)
+}
+
+func (h *httpHandler) handleACMEChallenge(conn net.Conn, req *http.Request, handler http.Handler, log logger.Logger) error {
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	resp := w.Result()
+	resp.ProtoMajor = 1
+	resp.ProtoMinor = 1
+	resp.ContentLength = int64(w.Body.Len())
+	resp.Close = true
+
+	if log.IsLevelEnabled(logger.TraceLevel) {
+		dump, _ := httputil.DumpResponse(resp, false)
+		log.Trace(string(dump))
+	}
+	log.Debugf("acme challenge: %s %d", req.URL.Path, resp.StatusCode)
+
+	return resp.Write(conn)
+}
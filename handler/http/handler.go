@@ -7,6 +7,7 @@ import (
 	"encoding/binary"
 	"errors"
 	"hash/crc32"
+	"io"
 	"net"
 	"net/http"
 	"net/http/httputil"
@@ -20,8 +21,11 @@ import (
 	"github.com/go-gost/core/handler"
 	"github.com/go-gost/core/logger"
 	md "github.com/go-gost/core/metadata"
+	xbypass "github.com/go-gost/x/bypass"
+	xctx "github.com/go-gost/x/ctx"
 	netpkg "github.com/go-gost/x/internal/net"
 	sx "github.com/go-gost/x/internal/util/selector"
+	xrecorder "github.com/go-gost/x/recorder"
 	"github.com/go-gost/x/registry"
 )
 
@@ -80,17 +84,35 @@ func (h *httpHandler) Handle(ctx context.Context, conn net.Conn, opts ...handler
 		return nil
 	}
 
-	req, err := http.ReadRequest(bufio.NewReader(conn))
+	br := bufio.NewReader(conn)
+	req, err := http.ReadRequest(br)
 	if err != nil {
 		log.Error(err)
 		return err
 	}
-	defer req.Body.Close()
 
-	return h.handleRequest(ctx, conn, req, log)
+	conn = netpkg.NewBufferReaderConn(conn, br)
+	for {
+		next, err := h.handleRequest(ctx, conn, br, req, log)
+		req.Body.Close()
+		if next == nil {
+			return err
+		}
+		// the request to another host on the connection is handled as a new one.
+		req = next
+	}
 }
 
-func (h *httpHandler) handleRequest(ctx context.Context, conn net.Conn, req *http.Request, log logger.Logger) error {
+// handleRequest handles the request req read from br, the buffered reader of conn.
+// It returns the next request on conn if it should be handled again, e.g. for a different host.
+func (h *httpHandler) handleRequest(ctx context.Context, conn net.Conn, br *bufio.Reader, req *http.Request, log logger.Logger) (*http.Request, error) {
+	if h.isPACRequest(req) {
+		return nil, h.handlePAC(conn, req, log)
+	}
+	if handler := h.acmeChallengeHandler(req); handler != nil {
+		return nil, h.handleACMEChallenge(conn, req, handler, log)
+	}
+
 	if !req.URL.IsAbs() && govalidator.IsDNSName(req.Host) {
 		req.URL.Scheme = "http"
 	}
@@ -124,8 +146,9 @@ func (h *httpHandler) handleRequest(ctx context.Context, conn net.Conn, req *htt
 	fields := map[string]any{
 		"dst": addr,
 	}
-	if u, _, _ := h.basicProxyAuth(req.Header.Get("Proxy-Authorization"), log); u != "" {
-		fields["user"] = u
+	user, _, _ := h.basicProxyAuth(req.Header.Get("Proxy-Authorization"), log)
+	if user != "" {
+		fields["user"] = user
 	}
 	log = log.WithFields(fields)
 
@@ -144,7 +167,27 @@ func (h *httpHandler) handleRequest(ctx context.Context, conn net.Conn, req *htt
 		resp.Header = http.Header{}
 	}
 
-	if h.options.Bypass != nil && h.options.Bypass.Contains(addr) {
+	if !h.authenticate(conn, req, resp, log) {
+		return nil, nil
+	}
+	// the user is trusted only if it is authenticated.
+	if h.options.Auther == nil {
+		user = ""
+	}
+	if user != "" {
+		ctx = xctx.ContextWithClientID(ctx, xctx.ClientID(user))
+	}
+
+	// the bypass is checked after the authentication, as the rules may match the user.
+	clientIP, _, _ := net.SplitHostPort(conn.RemoteAddr().String())
+	if h.options.Bypass != nil && xbypass.Contains(h.options.Bypass, &xbypass.Request{
+		Network:  network,
+		Addr:     addr,
+		ClientIP: clientIP,
+		User:     user,
+		Method:   req.Method,
+		Path:     req.URL.Path,
+	}) {
 		resp.StatusCode = http.StatusForbidden
 
 		if log.IsLevelEnabled(logger.TraceLevel) {
@@ -153,15 +196,13 @@ func (h *httpHandler) handleRequest(ctx context.Context, conn net.Conn, req *htt
 		}
 		log.Debug("bypass: ", addr)
 
-		return resp.Write(conn)
-	}
-
-	if !h.authenticate(conn, req, resp, log) {
-		return nil
+		return nil, resp.Write(conn)
 	}
+	xrecorder.Record(ctx, xrecorder.RecorderServiceHandlerDstAddress, []byte(addr))
+	xrecorder.Record(ctx, xrecorder.RecorderServiceHandlerHTTPRequest, []byte(req.Method+" "+req.RequestURI+" "+req.Proto))
 
 	if network == "udp" {
-		return h.handleUDP(ctx, conn, log)
+		return nil, h.handleUDP(ctx, conn, log)
 	}
 
 	if req.Method == "PRI" ||
@@ -173,7 +214,7 @@ func (h *httpHandler) handleRequest(ctx context.Context, conn net.Conn, req *htt
 			log.Trace(string(dump))
 		}
 
-		return resp.Write(conn)
+		return nil, resp.Write(conn)
 	}
 
 	req.Header.Del("Proxy-Authorization")
@@ -192,7 +233,7 @@ func (h *httpHandler) handleRequest(ctx context.Context, conn net.Conn, req *htt
 			log.Trace(string(dump))
 		}
 		resp.Write(conn)
-		return err
+		return nil, err
 	}
 	defer cc.Close()
 
@@ -206,13 +247,24 @@ func (h *httpHandler) handleRequest(ctx context.Context, conn net.Conn, req *htt
 		}
 		if err = resp.Write(conn); err != nil {
 			log.Error(err)
-			return err
+			return nil, err
 		}
 	} else {
 		req.Header.Del("Proxy-Connection")
+		if len(h.md.requestHeaders) > 0 || len(h.md.responseHeaders) > 0 {
+			vars := &headerVars{clientIP: clientIP, user: user}
+
+			start := time.Now()
+			log.Debugf("%s <-> %s", conn.RemoteAddr(), addr)
+			next, err := h.forwardRequest(conn, br, cc, req, vars, log)
+			log.WithFields(map[string]any{
+				"duration": time.Since(start),
+			}).Debugf("%s >-< %s", conn.RemoteAddr(), addr)
+			return next, err
+		}
 		if err = req.Write(cc); err != nil {
 			log.Error(err)
-			return err
+			return nil, err
 		}
 	}
 
@@ -223,7 +275,90 @@ func (h *httpHandler) handleRequest(ctx context.Context, conn net.Conn, req *htt
 		"duration": time.Since(start),
 	}).Debugf("%s >-< %s", conn.RemoteAddr(), addr)
 
-	return nil
+	return nil, nil
+}
+
+// forwardRequest forwards the plain HTTP requests read from br to cc one by one,
+// and rewrites the headers of the requests and responses by the header rules.
+// It returns the request to a different host, which should be sent via a new connection.
+func (h *httpHandler) forwardRequest(conn net.Conn, br *bufio.Reader, cc net.Conn, req *http.Request, vars *headerVars, log logger.Logger) (*http.Request, error) {
+	host := req.Host
+	cbr := bufio.NewReader(cc)
+
+	for {
+		rewriteHeader(req.Header, h.md.requestHeaders, vars)
+		if log.IsLevelEnabled(logger.TraceLevel) {
+			dump, _ := httputil.DumpRequest(req, false)
+			log.Trace(string(dump))
+		}
+		// the request is written concurrently with reading the responses,
+		// as the client may wait for the interim response 100 Continue before sending the body.
+		errc := make(chan error, 1)
+		go func(req *http.Request) {
+			errc <- req.Write(cc)
+		}(req)
+
+		resp, err := h.readResponse(conn, cbr, req, vars, log)
+		if err != nil {
+			log.Error(err)
+			return nil, err
+		}
+		err = resp.Write(conn)
+		resp.Body.Close()
+		if err != nil {
+			log.Error(err)
+			return nil, err
+		}
+		if err := <-errc; err != nil {
+			log.Error(err)
+			return nil, err
+		}
+
+		// the connection is upgraded, e.g. websocket.
+		if resp.StatusCode == http.StatusSwitchingProtocols {
+			return nil, netpkg.Transport(conn, netpkg.NewBufferReaderConn(cc, cbr))
+		}
+		if req.Close || resp.Close {
+			return nil, nil
+		}
+
+		req, err = http.ReadRequest(br)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		if req.Host != host {
+			log.Debugf("host changed to %s", req.Host)
+			return req, nil
+		}
+		req.Header.Del("Proxy-Authorization")
+		req.Header.Del("Proxy-Connection")
+	}
+}
+
+// readResponse reads the final response of req from br,
+// the interim responses except 101 Switching Protocols are written to conn before it.
+func (h *httpHandler) readResponse(conn net.Conn, br *bufio.Reader, req *http.Request, vars *headerVars, log logger.Logger) (*http.Response, error) {
+	for {
+		resp, err := http.ReadResponse(br, req)
+		if err != nil {
+			return nil, err
+		}
+		rewriteHeader(resp.Header, h.md.responseHeaders, vars)
+		if log.IsLevelEnabled(logger.TraceLevel) {
+			dump, _ := httputil.DumpResponse(resp, false)
+			log.Trace(string(dump))
+		}
+		if resp.StatusCode >= 200 || resp.StatusCode == http.StatusSwitchingProtocols {
+			return resp, nil
+		}
+
+		if err := resp.Write(conn); err != nil {
+			return nil, err
+		}
+	}
 }
 
 func (h *httpHandler) decodeServerName(s string) (string, error) {
@@ -0,0 +1,293 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-gost/core/auth"
+	"github.com/go-gost/core/bypass"
+	"github.com/go-gost/core/handler"
+	xauth "github.com/go-gost/x/auth"
+	xbypass "github.com/go-gost/x/bypass"
+	xlogger "github.com/go-gost/x/logger"
+	mdx "github.com/go-gost/x/metadata"
+)
+
+// newTestProxy serves the http handler with the metadata and options,
+// and returns the connection to it.
+func newTestProxy(t *testing.T, md map[string]any, opts ...handler.Option) net.Conn {
+	t.Helper()
+
+	h := NewHandler(append([]handler.Option{handler.LoggerOption(xlogger.Nop())}, opts...)...)
+	if err := h.Init(mdx.NewMetadata(md)); err != nil {
+		t.Fatal(err)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go h.Handle(context.Background(), conn)
+		}
+	}()
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// echoServer responds with the header X-Forwarded-For and the body of the request.
+func echoServer(t *testing.T, name string) *httptest.Server {
+	t.Helper()
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		w.Header()["X-Forwarded-For"] = r.Header.Values("X-Forwarded-For")
+		fmt.Fprintf(w, "%s %s", name, b)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func readBody(t *testing.T, resp *http.Response) string {
+	t.Helper()
+
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestForwardRequest(t *testing.T) {
+	s := echoServer(t, "a")
+	conn := newTestProxy(t, map[string]any{
+		"requestHeaders": []string{"add X-Forwarded-For: {clientIP}"},
+	})
+	br := bufio.NewReader(conn)
+
+	for i := 0; i < 2; i++ {
+		fmt.Fprintf(conn, "POST %s/ HTTP/1.1\r\nHost: %s\r\nX-Forwarded-For: 10.0.0.1\r\nContent-Length: 4\r\n\r\nping",
+			s.URL, s.Listener.Addr())
+		resp, err := http.ReadResponse(br, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if body := readBody(t, resp); body != "a ping" {
+			t.Errorf("got body %q, want %q", body, "a ping")
+		}
+		if got := resp.Header.Values("X-Forwarded-For"); len(got) != 1 || got[0] != "10.0.0.1, 127.0.0.1" {
+			t.Errorf("got X-Forwarded-For %q, want one field of the list", got)
+		}
+	}
+}
+
+func TestForwardRequestContinue(t *testing.T) {
+	s := echoServer(t, "a")
+	conn := newTestProxy(t, map[string]any{
+		"requestHeaders": []string{"set Via: 1.1 gost"},
+	})
+	br := bufio.NewReader(conn)
+
+	// the body is sent after the interim response.
+	fmt.Fprintf(conn, "POST %s/ HTTP/1.1\r\nHost: %s\r\nExpect: 100-continue\r\nContent-Length: 4\r\n\r\n",
+		s.URL, s.Listener.Addr())
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusContinue {
+		t.Fatalf("got status %d, want %d", resp.StatusCode, http.StatusContinue)
+	}
+	io.WriteString(conn, "ping")
+
+	if resp, err = http.ReadResponse(br, nil); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("got status %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if body := readBody(t, resp); body != "a ping" {
+		t.Errorf("got body %q, want %q", body, "a ping")
+	}
+}
+
+func TestForwardRequestEarlyHints(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Link", "</style.css>; rel=preload")
+		w.WriteHeader(http.StatusEarlyHints)
+		io.WriteString(w, "ok")
+	}))
+	defer s.Close()
+
+	conn := newTestProxy(t, map[string]any{
+		"responseHeaders": []string{"set Via: 1.1 gost"},
+	})
+	br := bufio.NewReader(conn)
+
+	for i := 0; i < 2; i++ {
+		fmt.Fprintf(conn, "GET %s/ HTTP/1.1\r\nHost: %s\r\n\r\n", s.URL, s.Listener.Addr())
+
+		for _, code := range []int{http.StatusEarlyHints, http.StatusOK} {
+			resp, err := http.ReadResponse(br, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != code {
+				t.Fatalf("got status %d, want %d", resp.StatusCode, code)
+			}
+			if resp.Header.Get("Via") != "1.1 gost" {
+				t.Errorf("the response %d is not rewritten", code)
+			}
+			if code == http.StatusOK {
+				if body := readBody(t, resp); body != "ok" {
+					t.Errorf("got body %q, want %q", body, "ok")
+				}
+			}
+		}
+	}
+}
+
+func TestForwardRequestHostChanged(t *testing.T) {
+	a, b := echoServer(t, "a"), echoServer(t, "b")
+	conn := newTestProxy(t, map[string]any{
+		"requestHeaders": []string{"set Via: 1.1 gost"},
+	})
+	br := bufio.NewReader(conn)
+
+	// the request to another host on the connection is answered by that host.
+	for _, s := range []struct {
+		server *httptest.Server
+		name   string
+	}{{a, "a"}, {b, "b"}, {a, "a"}} {
+		fmt.Fprintf(conn, "POST %s/ HTTP/1.1\r\nHost: %s\r\nContent-Length: 4\r\n\r\nping",
+			s.server.URL, s.server.Listener.Addr())
+		resp, err := http.ReadResponse(br, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if body, want := readBody(t, resp), s.name+" ping"; body != want {
+			t.Errorf("got body %q, want %q", body, want)
+		}
+	}
+}
+
+func TestForwardRequestUpgrade(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		c, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		if _, err := http.ReadRequest(bufio.NewReader(c)); err != nil {
+			return
+		}
+		io.WriteString(c, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: test\r\nConnection: Upgrade\r\n\r\n")
+		io.Copy(c, c)
+	}()
+
+	conn := newTestProxy(t, map[string]any{
+		"responseHeaders": []string{"set Via: 1.1 gost"},
+	})
+	br := bufio.NewReader(conn)
+
+	addr := ln.Addr().String()
+	fmt.Fprintf(conn, "GET http://%s/ HTTP/1.1\r\nHost: %s\r\nUpgrade: test\r\nConnection: Upgrade\r\n\r\n", addr, addr)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("got status %d, want %d", resp.StatusCode, http.StatusSwitchingProtocols)
+	}
+
+	io.WriteString(conn, "ping")
+	b := make([]byte, 4)
+	if _, err := io.ReadFull(br, b); err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "ping" {
+		t.Errorf("got %q, want %q", b, "ping")
+	}
+}
+
+func TestBypassUser(t *testing.T) {
+	s := echoServer(t, "a")
+	auther := xauth.NewAuthenticator(
+		xauth.AuthsOption(map[string]string{"alice": "pass", "bob": "pass"}),
+		xauth.LoggerOption(xlogger.Nop()),
+	)
+	newBypass := func(patterns ...string) bypass.Bypass {
+		return xbypass.NewBypass(xbypass.MatchersOption(patterns), xbypass.LoggerOption(xlogger.Nop()))
+	}
+
+	tests := []struct {
+		name   string
+		auther auth.Authenticator
+		bypass bypass.Bypass
+		user   string
+		pass   string
+		status int
+	}{
+		{name: "user allowed", auther: auther, bypass: newBypass("* user=bob"), user: "alice", pass: "pass", status: http.StatusOK},
+		{name: "user bypassed", auther: auther, bypass: newBypass("* user=bob"), user: "bob", pass: "pass", status: http.StatusForbidden},
+		{name: "user not authenticated", auther: auther, bypass: newBypass("* user=bob"), user: "bob", pass: "wrong", status: http.StatusProxyAuthRequired},
+		{name: "other users bypassed", auther: auther, bypass: newBypass("* !user=alice"), user: "bob", pass: "pass", status: http.StatusForbidden},
+		{
+			// the user claimed without auther is not trusted.
+			name:   "user without auther",
+			bypass: newBypass("* !user=alice"),
+			user:   "alice",
+			status: http.StatusForbidden,
+		},
+		{name: "host bypassed", auther: auther, bypass: newBypass("127.0.0.1"), user: "alice", pass: "pass", status: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := []handler.Option{handler.BypassOption(tt.bypass)}
+			if tt.auther != nil {
+				opts = append(opts, handler.AutherOption(tt.auther))
+			}
+			conn := newTestProxy(t, nil, opts...)
+
+			req, _ := http.NewRequest(http.MethodGet, s.URL, nil)
+			req.Header.Set("Proxy-Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(tt.user+":"+tt.pass)))
+			if err := req.WriteProxy(conn); err != nil {
+				t.Fatal(err)
+			}
+			resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.status {
+				t.Errorf("got status %d, want %d", resp.StatusCode, tt.status)
+			}
+		})
+	}
+}
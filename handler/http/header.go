@@ -0,0 +1,166 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"strings"
+
+	"github.com/gobwas/glob"
+)
+
+const (
+	headerOpAdd    = "add"
+	headerOpSet    = "set"
+	headerOpDel    = "del"
+	headerOpRename = "rename"
+)
+
+const (
+	// hopHeaders is the special name for the hop-by-hop headers in the del rule.
+	hopHeaders = ":hop"
+)
+
+// Hop-by-hop headers, see RFC 7230, section 6.1.
+var hopByHopHeaders = []string{
+	"Connection",
+	"Proxy-Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Te",
+	"Trailer",
+	"Upgrade",
+}
+
+// headerRule is a rule for rewriting HTTP headers.
+// The rule is in the form of:
+//
+//	add Name: value (appended to the existing value as a comma-separated list)
+//	set Name: value
+//	del Name (Name can be a wildcard pattern or :hop for the hop-by-hop headers)
+//	rename Name NewName
+//
+// The value can contain the placeholders {clientIP} and {user}.
+type headerRule struct {
+	op    string
+	name  string
+	value string
+	glob  glob.Glob
+}
+
+func parseHeaderRules(rules []string) ([]headerRule, error) {
+	var hrs []headerRule
+	for _, s := range rules {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		op, args, _ := strings.Cut(s, " ")
+		args = strings.TrimSpace(args)
+
+		hr := headerRule{op: strings.ToLower(op)}
+		switch hr.op {
+		case headerOpAdd, headerOpSet:
+			name, value, ok := strings.Cut(args, ":")
+			if !ok || strings.TrimSpace(name) == "" {
+				return nil, fmt.Errorf("invalid header rule: %s", s)
+			}
+			hr.name = textproto.CanonicalMIMEHeaderKey(strings.TrimSpace(name))
+			hr.value = strings.TrimSpace(value)
+		case headerOpDel:
+			if args == "" {
+				return nil, fmt.Errorf("invalid header rule: %s", s)
+			}
+			hr.name = args
+			if args != hopHeaders && strings.ContainsAny(args, "*?") {
+				g, err := glob.Compile(strings.ToLower(args))
+				if err != nil {
+					return nil, fmt.Errorf("invalid header rule: %s: %v", s, err)
+				}
+				hr.glob = g
+			} else {
+				hr.name = textproto.CanonicalMIMEHeaderKey(args)
+			}
+		case headerOpRename:
+			ss := strings.Fields(args)
+			if len(ss) != 2 {
+				return nil, fmt.Errorf("invalid header rule: %s", s)
+			}
+			hr.name = textproto.CanonicalMIMEHeaderKey(ss[0])
+			hr.value = textproto.CanonicalMIMEHeaderKey(ss[1])
+		default:
+			return nil, fmt.Errorf("unknown header rule: %s", s)
+		}
+		hrs = append(hrs, hr)
+	}
+	return hrs, nil
+}
+
+// headerVars holds the values of the placeholders in the header rules.
+type headerVars struct {
+	clientIP string
+	user     string
+}
+
+func (v *headerVars) expand(s string) string {
+	if !strings.Contains(s, "{") {
+		return s
+	}
+	return strings.NewReplacer(
+		"{clientIP}", v.clientIP,
+		"{user}", v.user,
+	).Replace(s)
+}
+
+func rewriteHeader(header http.Header, rules []headerRule, vars *headerVars) {
+	for _, rule := range rules {
+		switch rule.op {
+		case headerOpAdd:
+			// the value is appended to the list of the existing field, e.g. X-Forwarded-For,
+			// except Set-Cookie which can not be combined, see RFC 7230 section 3.2.2.
+			v := vars.expand(rule.value)
+			if vs := header.Values(rule.name); len(vs) > 0 && rule.name != "Set-Cookie" {
+				header.Set(rule.name, strings.Join(vs, ", ")+", "+v)
+			} else {
+				header.Add(rule.name, v)
+			}
+		case headerOpSet:
+			header.Set(rule.name, vars.expand(rule.value))
+		case headerOpDel:
+			switch {
+			case rule.name == hopHeaders:
+				delHopHeaders(header)
+			case rule.glob != nil:
+				for k := range header {
+					if rule.glob.Match(strings.ToLower(k)) {
+						header.Del(k)
+					}
+				}
+			default:
+				header.Del(rule.name)
+			}
+		case headerOpRename:
+			if vs := header.Values(rule.name); len(vs) > 0 {
+				header.Del(rule.name)
+				for _, v := range vs {
+					header.Add(rule.value, v)
+				}
+			}
+		}
+	}
+}
+
+func delHopHeaders(header http.Header) {
+	// the headers listed in the Connection header are also hop-by-hop.
+	for _, v := range header.Values("Connection") {
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				header.Del(s)
+			}
+		}
+	}
+	for _, k := range hopByHopHeaders {
+		header.Del(k)
+	}
+}
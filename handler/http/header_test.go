@@ -0,0 +1,61 @@
+package http
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestRewriteHeader(t *testing.T) {
+	rules, err := parseHeaderRules([]string{
+		"add X-Forwarded-For: {clientIP}",
+		"add Via: 1.1 gost",
+		"add Set-Cookie: b=2",
+		"set X-User: {user}",
+		"del X-Tracking-*",
+		"del :hop",
+		"rename X-Old X-New",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header := http.Header{
+		"X-Forwarded-For":  {"10.0.0.1", "10.0.0.2"},
+		"Set-Cookie":       {"a=1"},
+		"X-User":           {"spoofed"},
+		"X-Tracking-Id":    {"1"},
+		"Connection":       {"X-Hop"},
+		"X-Hop":            {"1"},
+		"Proxy-Connection": {"keep-alive"},
+		"X-Old":            {"v"},
+	}
+	rewriteHeader(header, rules, &headerVars{clientIP: "192.168.1.1", user: "alice"})
+
+	want := http.Header{
+		// the value is appended to the existing list instead of added as another field.
+		"X-Forwarded-For": {"10.0.0.1, 10.0.0.2, 192.168.1.1"},
+		"Via":             {"1.1 gost"},
+		"Set-Cookie":      {"a=1", "b=2"},
+		"X-User":          {"alice"},
+		"X-New":           {"v"},
+	}
+	if !reflect.DeepEqual(header, want) {
+		t.Errorf("got header %v, want %v", header, want)
+	}
+}
+
+func TestParseHeaderRulesInvalid(t *testing.T) {
+	for _, rule := range []string{
+		"add X-Name",
+		"set : value",
+		"del",
+		"del X-[a-*",
+		"rename X-Name",
+		"replace X-Name: value",
+	} {
+		if _, err := parseHeaderRules([]string{rule}); err == nil {
+			t.Errorf("no error for the rule %q", rule)
+		}
+	}
+}
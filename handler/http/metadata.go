@@ -13,9 +13,13 @@ type metadata struct {
 	enableUDP       bool
 	header          http.Header
 	hash            string
+	pac             bool
+	pacProxy        string
+	requestHeaders  []headerRule
+	responseHeaders []headerRule
 }
 
-func (h *httpHandler) parseMetadata(md mdata.Metadata) error {
+func (h *httpHandler) parseMetadata(md mdata.Metadata) (err error) {
 	const (
 		header          = "header"
 		probeResistKey  = "probeResistance"
@@ -23,6 +27,10 @@ func (h *httpHandler) parseMetadata(md mdata.Metadata) error {
 		knock           = "knock"
 		enableUDP       = "udp"
 		hash            = "hash"
+		pac             = "pac"
+		pacProxy        = "pacProxy"
+		requestHeaders  = "requestHeaders"
+		responseHeaders = "responseHeaders"
 	)
 
 	if m := mdutil.GetStringMapString(md, header); len(m) > 0 {
@@ -48,8 +56,17 @@ func (h *httpHandler) parseMetadata(md mdata.Metadata) error {
 	}
 	h.md.enableUDP = mdutil.GetBool(md, enableUDP)
 	h.md.hash = mdutil.GetString(md, hash)
+	h.md.pac = mdutil.GetBool(md, pac)
+	h.md.pacProxy = mdutil.GetString(md, pacProxy)
 
-	return nil
+	if h.md.requestHeaders, err = parseHeaderRules(mdutil.GetStrings(md, requestHeaders)); err != nil {
+		return
+	}
+	if h.md.responseHeaders, err = parseHeaderRules(mdutil.GetStrings(md, responseHeaders)); err != nil {
+		return
+	}
+
+	return
 }
 
 type probeResistance struct {
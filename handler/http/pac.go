@@ -0,0 +1,162 @@
+package http
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"strconv"
+	"strings"
+
+	"github.com/go-gost/core/bypass"
+	"github.com/go-gost/core/logger"
+	xbypass "github.com/go-gost/x/bypass"
+	"github.com/go-gost/x/internal/matcher"
+)
+
+const (
+	pacContentType = "application/x-ns-proxy-autoconfig"
+)
+
+var (
+	pacPaths = []string{"/proxy.pac", "/wpad.dat"}
+)
+
+// isPACRequest reports whether req is a direct GET request for the PAC file.
+func (h *httpHandler) isPACRequest(req *http.Request) bool {
+	if !h.md.pac || req.URL.IsAbs() ||
+		(req.Method != http.MethodGet && req.Method != http.MethodHead) {
+		return false
+	}
+	for _, path := range pacPaths {
+		if req.URL.Path == path {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *httpHandler) handlePAC(conn net.Conn, req *http.Request, log logger.Logger) error {
+	proxy := h.md.pacProxy
+	if proxy == "" {
+		proxy = req.Host
+	}
+	if proxy == "" {
+		proxy = conn.LocalAddr().String()
+	}
+
+	body := generatePAC(h.options.Bypass, "PROXY "+proxy, log)
+
+	resp := &http.Response{
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		StatusCode:    http.StatusOK,
+		Header:        http.Header{},
+		ContentLength: int64(len(body)),
+	}
+	resp.Header.Set("Content-Type", pacContentType)
+	resp.Header.Set("Cache-Control", "no-cache")
+	resp.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	if req.Method == http.MethodGet {
+		resp.Body = &pacBody{Reader: bytes.NewReader(body)}
+	}
+
+	if log.IsLevelEnabled(logger.TraceLevel) {
+		dump, _ := httputil.DumpResponse(resp, false)
+		log.Trace(string(dump))
+	}
+	log.Debugf("pac: %s", req.URL.Path)
+
+	return resp.Write(conn)
+}
+
+type pacBody struct {
+	*bytes.Reader
+}
+
+func (b *pacBody) Close() error {
+	return nil
+}
+
+// generatePAC builds the PAC file from the patterns of bp.
+// The hosts contained in bp are connected directly, others go to proxy.
+// The whitelist with the patterns not supported by PAC is skipped so that its hosts go to proxy,
+// as the hosts of the skipped patterns would be connected directly otherwise.
+func generatePAC(bp bypass.Bypass, proxy string, log logger.Logger) []byte {
+	buf := &bytes.Buffer{}
+
+	buf.WriteString("function isIPv4(host) {\n")
+	buf.WriteString("  return /^\\d+\\.\\d+\\.\\d+\\.\\d+$/.test(host);\n")
+	buf.WriteString("}\n\n")
+
+	buf.WriteString("function FindProxyForURL(url, host) {\n")
+	for _, b := range xbypass.Bypasses(bp) {
+		lister, ok := b.(xbypass.Lister)
+		if !ok {
+			continue
+		}
+		patterns, whitelist := lister.Patterns()
+		cond, skipped := pacCondition(patterns)
+		if whitelist {
+			if len(skipped) > 0 {
+				log.Warnf("pac: whitelist skipped for the patterns not supported: %s", strings.Join(skipped, ", "))
+				continue
+			}
+			if cond == "" {
+				cond = "true"
+			} else {
+				cond = "!(" + cond + ")"
+			}
+		}
+		if cond == "" {
+			continue
+		}
+		fmt.Fprintf(buf, "  if (%s) {\n    return \"DIRECT\";\n  }\n", cond)
+	}
+	fmt.Fprintf(buf, "  return %q;\n", proxy)
+	buf.WriteString("}\n")
+
+	return buf.Bytes()
+}
+
+// pacCondition converts the bypass patterns to a PAC expression, and returns the patterns skipped.
+// IPv6 CIDR patterns, port patterns and the extended rules are skipped as they are not supported by PAC.
+func pacCondition(patterns []string) (cond string, skipped []string) {
+	var conds []string
+	for _, pattern := range patterns {
+		// the extended rules can not be expressed in PAC.
+		if strings.HasPrefix(pattern, "!") || strings.ContainsAny(pattern, " \t") {
+			skipped = append(skipped, pattern)
+			continue
+		}
+		if _, _, ok := matcher.SplitHostPort(pattern); ok {
+			skipped = append(skipped, pattern)
+			continue
+		}
+		if ip := net.ParseIP(pattern); ip != nil {
+			conds = append(conds, fmt.Sprintf("host == %q", ip.String()))
+			continue
+		}
+		if _, inet, err := net.ParseCIDR(pattern); err == nil {
+			if inet.IP.To4() == nil {
+				skipped = append(skipped, pattern)
+				continue
+			}
+			conds = append(conds, fmt.Sprintf("(isIPv4(host) && isInNet(host, %q, %q))",
+				inet.IP.String(), net.IP(inet.Mask).String()))
+			continue
+		}
+		if strings.ContainsAny(pattern, "*?") {
+			conds = append(conds, fmt.Sprintf("shExpMatch(host, %q)", pattern))
+			continue
+		}
+		if strings.HasPrefix(pattern, ".") {
+			conds = append(conds, fmt.Sprintf("(host == %q || dnsDomainIs(host, %q))",
+				pattern[1:], pattern))
+			continue
+		}
+		conds = append(conds, fmt.Sprintf("host == %q", pattern))
+	}
+	return strings.Join(conds, " ||\n      "), skipped
+}
@@ -0,0 +1,128 @@
+package http
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-gost/core/bypass"
+	xbypass "github.com/go-gost/x/bypass"
+	xlogger "github.com/go-gost/x/logger"
+)
+
+func TestPACCondition(t *testing.T) {
+	tests := []struct {
+		pattern string
+		cond    string
+	}{
+		{pattern: "example.com", cond: `host == "example.com"`},
+		{pattern: ".example.com", cond: `(host == "example.com" || dnsDomainIs(host, ".example.com"))`},
+		{pattern: "*.example.com", cond: `shExpMatch(host, "*.example.com")`},
+		{pattern: "192.168.1.1", cond: `host == "192.168.1.1"`},
+		{pattern: "2001:db8::1", cond: `host == "2001:db8::1"`},
+		{pattern: "10.0.0.0/8", cond: `(isIPv4(host) && isInNet(host, "10.0.0.0", "255.0.0.0"))`},
+		// the patterns not supported by PAC.
+		{pattern: "2001:db8::/32"},
+		{pattern: "example.com:443"},
+		{pattern: "* network=udp"},
+		{pattern: "!*.example.com user=alice"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			cond, skipped := pacCondition([]string{tt.pattern})
+			if cond != tt.cond {
+				t.Errorf("got condition %q, want %q", cond, tt.cond)
+			}
+			if want := tt.cond == ""; (len(skipped) > 0) != want {
+				t.Errorf("got skipped %v, want skipped %v", skipped, want)
+			}
+		})
+	}
+
+	cond, skipped := pacCondition([]string{"example.com", "example.com:443", "10.0.0.0/8"})
+	if want := "host == \"example.com\" ||\n      (isIPv4(host) && isInNet(host, \"10.0.0.0\", \"255.0.0.0\"))"; cond != want {
+		t.Errorf("got condition %q, want %q", cond, want)
+	}
+	if len(skipped) != 1 || skipped[0] != "example.com:443" {
+		t.Errorf("got skipped %v, want [example.com:443]", skipped)
+	}
+}
+
+func TestGeneratePAC(t *testing.T) {
+	newBypass := func(whitelist bool, patterns ...string) bypass.Bypass {
+		return xbypass.NewBypass(
+			xbypass.MatchersOption(patterns),
+			xbypass.WhitelistOption(whitelist),
+			xbypass.LoggerOption(xlogger.Nop()),
+		)
+	}
+
+	tests := []struct {
+		name   string
+		bypass bypass.Bypass
+		// the conditions of the DIRECT branches in order.
+		direct []string
+	}{
+		{name: "no bypass"},
+		{
+			name:   "blacklist",
+			bypass: newBypass(false, "example.com", "example.org:443"),
+			direct: []string{`host == "example.com"`},
+		},
+		{
+			name:   "blacklist without supported patterns",
+			bypass: newBypass(false, "example.org:443"),
+		},
+		{
+			name:   "whitelist",
+			bypass: newBypass(true, "example.com", "*.example.org"),
+			direct: []string{"!(host == \"example.com\" ||\n      shExpMatch(host, \"*.example.org\"))"},
+		},
+		{
+			name:   "empty whitelist",
+			bypass: newBypass(true),
+			direct: []string{"true"},
+		},
+		{
+			// the hosts of the unsupported patterns go to proxy rather than directly.
+			name:   "whitelist with unsupported patterns",
+			bypass: newBypass(true, "example.com", "example.org:443"),
+		},
+		{
+			name:   "whitelist with only unsupported patterns",
+			bypass: newBypass(true, "* user=alice"),
+		},
+		{
+			name: "group",
+			bypass: xbypass.BypassGroup(
+				newBypass(false, "example.com"),
+				newBypass(true, "2001:db8::/32"),
+				newBypass(false, "10.0.0.0/8"),
+			),
+			direct: []string{
+				`host == "example.com"`,
+				`(isIPv4(host) && isInNet(host, "10.0.0.0", "255.0.0.0"))`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pac := string(generatePAC(tt.bypass, "PROXY 127.0.0.1:8080", xlogger.Nop()))
+
+			if !strings.HasSuffix(pac, "  return \"PROXY 127.0.0.1:8080\";\n}\n") {
+				t.Errorf("the PAC does not end with the proxy:\n%s", pac)
+			}
+			if got := strings.Count(pac, `return "DIRECT"`); got != len(tt.direct) {
+				t.Fatalf("got %d DIRECT branches, want %d:\n%s", got, len(tt.direct), pac)
+			}
+			rest := pac
+			for _, cond := range tt.direct {
+				branch := "  if (" + cond + ") {\n    return \"DIRECT\";\n  }\n"
+				i := strings.Index(rest, branch)
+				if i < 0 {
+					t.Fatalf("no DIRECT branch for %q:\n%s", cond, pac)
+				}
+				rest = rest[i+len(branch):]
+			}
+		})
+	}
+}
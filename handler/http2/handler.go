@@ -22,8 +22,10 @@ import (
 	"github.com/go-gost/core/handler"
 	"github.com/go-gost/core/logger"
 	md "github.com/go-gost/core/metadata"
+	xctx "github.com/go-gost/x/ctx"
 	netpkg "github.com/go-gost/x/internal/net"
 	sx "github.com/go-gost/x/internal/util/selector"
+	xrecorder "github.com/go-gost/x/recorder"
 	"github.com/go-gost/x/registry"
 )
 
@@ -122,8 +124,9 @@ func (h *http2Handler) roundTrip(ctx context.Context, w http.ResponseWriter, req
 	fields := map[string]any{
 		"dst": addr,
 	}
-	if u, _, _ := h.basicProxyAuth(req.Header.Get("Proxy-Authorization")); u != "" {
-		fields["user"] = u
+	user, _, _ := h.basicProxyAuth(req.Header.Get("Proxy-Authorization"))
+	if user != "" {
+		fields["user"] = user
 	}
 	log = log.WithFields(fields)
 
@@ -153,6 +156,11 @@ func (h *http2Handler) roundTrip(ctx context.Context, w http.ResponseWriter, req
 	if !h.authenticate(w, req, resp, log) {
 		return nil
 	}
+	if user != "" {
+		ctx = xctx.ContextWithClientID(ctx, xctx.ClientID(user))
+	}
+	xrecorder.Record(ctx, xrecorder.RecorderServiceHandlerDstAddress, []byte(addr))
+	xrecorder.Record(ctx, xrecorder.RecorderServiceHandlerHTTPRequest, []byte(req.Method+" "+req.RequestURI+" "+req.Proto))
 
 	// delete the proxy related headers.
 	req.Header.Del("Proxy-Authorization")
@@ -19,6 +19,7 @@ import (
 	md "github.com/go-gost/core/metadata"
 	dissector "github.com/go-gost/tls-dissector"
 	netpkg "github.com/go-gost/x/internal/net"
+	xrecorder "github.com/go-gost/x/recorder"
 	"github.com/go-gost/x/registry"
 )
 
@@ -122,6 +123,7 @@ func (h *redirectHandler) Handle(ctx context.Context, conn net.Conn, opts ...han
 	}
 
 	log.Debugf("%s >> %s", conn.RemoteAddr(), dstAddr)
+	xrecorder.Record(ctx, xrecorder.RecorderServiceHandlerDstAddress, []byte(dstAddr.String()))
 
 	if h.options.Bypass != nil && h.options.Bypass.Contains(dstAddr.String()) {
 		log.Debug("bypass: ", dstAddr)
@@ -163,6 +165,8 @@ func (h *redirectHandler) handleHTTP(ctx context.Context, rw io.ReadWriter, radd
 	log = log.WithFields(map[string]any{
 		"host": host,
 	})
+	xrecorder.Record(ctx, xrecorder.RecorderServiceHandlerDstAddress, []byte(host))
+	xrecorder.Record(ctx, xrecorder.RecorderServiceHandlerHTTPRequest, []byte(req.Method+" "+req.RequestURI+" "+req.Proto))
 
 	if h.options.Bypass != nil && h.options.Bypass.Contains(host) {
 		log.Debug("bypass: ", host)
@@ -214,6 +218,7 @@ func (h *redirectHandler) handleHTTPS(ctx context.Context, rw io.ReadWriter, rad
 	if host == "" {
 		host = dstAddr.String()
 	} else {
+		xrecorder.Record(ctx, xrecorder.RecorderServiceHandlerTLSSNI, []byte(host))
 		if _, _, err := net.SplitHostPort(host); err != nil {
 			_, port, _ := net.SplitHostPort(dstAddr.String())
 			if port == "" {
@@ -226,6 +231,7 @@ func (h *redirectHandler) handleHTTPS(ctx context.Context, rw io.ReadWriter, rad
 	log = log.WithFields(map[string]any{
 		"host": host,
 	})
+	xrecorder.Record(ctx, xrecorder.RecorderServiceHandlerDstAddress, []byte(host))
 
 	if h.options.Bypass != nil && h.options.Bypass.Contains(host) {
 		log.Debug("bypass: ", host)
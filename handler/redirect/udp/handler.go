@@ -12,6 +12,7 @@ import (
 	"github.com/go-gost/core/handler"
 	md "github.com/go-gost/core/metadata"
 	netpkg "github.com/go-gost/x/internal/net"
+	xrecorder "github.com/go-gost/x/recorder"
 	"github.com/go-gost/x/registry"
 )
 
@@ -104,6 +105,7 @@ func (h *redirectHandler) Handle(ctx context.Context, conn net.Conn, opts ...han
 	}
 
 	log.Debugf("%s >> %s", conn.RemoteAddr(), dstAddr)
+	xrecorder.Record(ctx, xrecorder.RecorderServiceHandlerDstAddress, []byte(dstAddr.String()))
 
 	if h.options.Bypass != nil && h.options.Bypass.Contains(dstAddr.String()) {
 		log.Debug("bypass: ", dstAddr)
@@ -11,6 +11,7 @@ import (
 	"github.com/go-gost/relay"
 	netpkg "github.com/go-gost/x/internal/net"
 	sx "github.com/go-gost/x/internal/util/selector"
+	xrecorder "github.com/go-gost/x/recorder"
 )
 
 func (h *relayHandler) handleConnect(ctx context.Context, conn net.Conn, network, address string, log logger.Logger) error {
@@ -20,6 +21,7 @@ func (h *relayHandler) handleConnect(ctx context.Context, conn net.Conn, network
 	})
 
 	log.Debugf("%s >> %s", conn.RemoteAddr(), address)
+	xrecorder.Record(ctx, xrecorder.RecorderServiceHandlerDstAddress, []byte(address))
 
 	resp := relay.Response{
 		Version: relay.Version1,
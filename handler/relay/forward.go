@@ -10,6 +10,7 @@ import (
 	"github.com/go-gost/core/logger"
 	"github.com/go-gost/relay"
 	netpkg "github.com/go-gost/x/internal/net"
+	xrecorder "github.com/go-gost/x/recorder"
 )
 
 func (h *relayHandler) handleForward(ctx context.Context, conn net.Conn, network string, log logger.Logger) error {
@@ -32,6 +33,7 @@ func (h *relayHandler) handleForward(ctx context.Context, conn net.Conn, network
 	})
 
 	log.Debugf("%s >> %s", conn.RemoteAddr(), target.Addr)
+	xrecorder.Record(ctx, xrecorder.RecorderServiceHandlerDstAddress, []byte(target.Addr))
 
 	cc, err := h.router.Dial(ctx, network, target.Addr)
 	if err != nil {
@@ -11,6 +11,7 @@ import (
 	"github.com/go-gost/core/handler"
 	md "github.com/go-gost/core/metadata"
 	"github.com/go-gost/relay"
+	xctx "github.com/go-gost/x/ctx"
 	"github.com/go-gost/x/registry"
 )
 
@@ -124,6 +125,9 @@ func (h *relayHandler) Handle(ctx context.Context, conn net.Conn, opts ...handle
 		_, err := resp.WriteTo(conn)
 		return err
 	}
+	if user != "" {
+		ctx = xctx.ContextWithClientID(ctx, xctx.ClientID(user))
+	}
 
 	network := "tcp"
 	if (req.Flags & relay.FUDP) == relay.FUDP {
@@ -6,6 +6,7 @@ import (
 
 	mdata "github.com/go-gost/core/metadata"
 	mdutil "github.com/go-gost/core/metadata/util"
+	"github.com/go-gost/x/internal/util/mux"
 )
 
 type metadata struct {
@@ -14,6 +15,8 @@ type metadata struct {
 	udpBufferSize int
 	noDelay       bool
 	hash          string
+
+	muxCfg *mux.Config
 }
 
 func (h *relayHandler) parseMetadata(md mdata.Metadata) (err error) {
@@ -23,6 +26,16 @@ func (h *relayHandler) parseMetadata(md mdata.Metadata) (err error) {
 		udpBufferSize = "udpBufferSize"
 		noDelay       = "nodelay"
 		hash          = "hash"
+
+		muxProtocol          = "muxProtocol"
+		muxVersion           = "muxVersion"
+		muxKeepAliveDisabled = "muxKeepAliveDisabled"
+		muxKeepAliveInterval = "muxKeepAliveInterval"
+		muxKeepAliveTimeout  = "muxKeepAliveTimeout"
+		muxMaxFrameSize      = "muxMaxFrameSize"
+		muxMaxReceiveBuffer  = "muxMaxReceiveBuffer"
+		muxMaxStreamBuffer   = "muxMaxStreamBuffer"
+		muxMaxStreams        = "muxMaxStreams"
 	)
 
 	h.md.readTimeout = mdutil.GetDuration(md, readTimeout)
@@ -36,5 +49,18 @@ func (h *relayHandler) parseMetadata(md mdata.Metadata) (err error) {
 	}
 
 	h.md.hash = mdutil.GetString(md, hash)
+
+	h.md.muxCfg = &mux.Config{
+		Protocol:          mdutil.GetString(md, muxProtocol),
+		Version:           mdutil.GetInt(md, muxVersion),
+		KeepAliveDisabled: mdutil.GetBool(md, muxKeepAliveDisabled),
+		KeepAliveInterval: mdutil.GetDuration(md, muxKeepAliveInterval),
+		KeepAliveTimeout:  mdutil.GetDuration(md, muxKeepAliveTimeout),
+		MaxFrameSize:      mdutil.GetInt(md, muxMaxFrameSize),
+		MaxReceiveBuffer:  mdutil.GetInt(md, muxMaxReceiveBuffer),
+		MaxStreamBuffer:   mdutil.GetInt(md, muxMaxStreamBuffer),
+		MaxStreams:        mdutil.GetInt(md, muxMaxStreams),
+	}
+
 	return
 }
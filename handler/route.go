@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/go-gost/core/auth"
+	"github.com/go-gost/core/bypass"
+	"github.com/go-gost/core/chain"
+	"github.com/go-gost/core/limiter/rate"
+	xauth "github.com/go-gost/x/auth"
+)
+
+// UserRoute holds the routing objects used for the connections of the matched clients.
+// The zero value of each field means the default object of the handler.
+type UserRoute struct {
+	// Users is the list of the usernames this route applies to.
+	Users []string
+	// Auther matches the clients whose usernames are listed by it, see auth.Lister of package x/auth.
+	Auther      auth.Authenticator
+	Router      *chain.Router
+	Bypass      bypass.Bypass
+	RateLimiter rate.RateLimiter
+}
+
+// Match reports whether the client authenticated as id by the handler matches this route,
+// the client is not authenticated again by the auther of the route.
+func (r *UserRoute) Match(id string) bool {
+	if r == nil || id == "" {
+		return false
+	}
+	for _, u := range r.Users {
+		if u == id {
+			return true
+		}
+	}
+	if lister, ok := r.Auther.(xauth.Lister); ok {
+		_, ok = lister.Users()[id]
+		return ok
+	}
+	return false
+}
+
+// UserRouter is implemented by the handlers which support per-user routing.
+type UserRouter interface {
+	RouteUsers(routes ...*UserRoute)
+}
+
+// MatchUserRoute returns the first route of routes matching the client authenticated as id.
+func MatchUserRoute(routes []*UserRoute, id string) *UserRoute {
+	for _, r := range routes {
+		if r.Match(id) {
+			return r
+		}
+	}
+	return nil
+}
+
+type userRouteKey struct{}
+
+var (
+	keyUserRoute = &userRouteKey{}
+)
+
+func ContextWithUserRoute(ctx context.Context, route *UserRoute) context.Context {
+	return context.WithValue(ctx, keyUserRoute, route)
+}
+
+func UserRouteFromContext(ctx context.Context) *UserRoute {
+	v, _ := ctx.Value(keyUserRoute).(*UserRoute)
+	return v
+}
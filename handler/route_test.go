@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/go-gost/core/auth"
+	xauth "github.com/go-gost/x/auth"
+	xlogger "github.com/go-gost/x/logger"
+)
+
+// countingAuther counts the calls of Authenticate.
+type countingAuther struct {
+	auth.Authenticator
+	n int
+}
+
+func (a *countingAuther) Authenticate(user, password string) bool {
+	a.n++
+	return a.Authenticator.Authenticate(user, password)
+}
+
+func TestUserRouteMatch(t *testing.T) {
+	auther := xauth.NewAuthenticator(
+		xauth.AuthsOption(map[string]string{"carol": "pass"}),
+		xauth.LoggerOption(xlogger.Nop()),
+	)
+
+	tests := []struct {
+		name  string
+		route *UserRoute
+		id    string
+		want  bool
+	}{
+		{name: "nil route", id: "alice"},
+		{name: "user", route: &UserRoute{Users: []string{"alice", "bob"}}, id: "bob", want: true},
+		{name: "user not listed", route: &UserRoute{Users: []string{"alice"}}, id: "bob"},
+		{name: "no client id", route: &UserRoute{Users: []string{""}}},
+		{name: "user of auther", route: &UserRoute{Auther: auther}, id: "carol", want: true},
+		{name: "user not of auther", route: &UserRoute{Auther: auther}, id: "alice"},
+		{
+			// the users of the auther not listing them are not known.
+			name:  "auther not a lister",
+			route: &UserRoute{Auther: &countingAuther{Authenticator: auther}},
+			id:    "carol",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.route.Match(tt.id); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchUserRoute(t *testing.T) {
+	auther := &countingAuther{
+		Authenticator: xauth.NewAuthenticator(
+			xauth.AuthsOption(map[string]string{"alice": "pass"}),
+			xauth.LoggerOption(xlogger.Nop()),
+		),
+	}
+	alice := &UserRoute{Users: []string{"alice"}}
+	bob := &UserRoute{Users: []string{"alice", "bob"}}
+	routes := []*UserRoute{alice, bob, {Auther: auther}}
+
+	if r := MatchUserRoute(routes, "alice"); r != alice {
+		t.Error("the first matched route is not returned")
+	}
+	if r := MatchUserRoute(routes, "bob"); r != bob {
+		t.Error("the route of bob is not matched")
+	}
+	if r := MatchUserRoute(routes, "carol"); r != nil {
+		t.Error("the route is matched for the unknown client")
+	}
+	if r := MatchUserRoute(nil, "alice"); r != nil {
+		t.Error("the route is matched without routes")
+	}
+	// the clients are not authenticated again.
+	if auther.n > 0 {
+		t.Errorf("the auther of the route is called %d times", auther.n)
+	}
+}
@@ -22,6 +22,7 @@ import (
 	dissector "github.com/go-gost/tls-dissector"
 	netpkg "github.com/go-gost/x/internal/net"
 	sx "github.com/go-gost/x/internal/util/selector"
+	xrecorder "github.com/go-gost/x/recorder"
 	"github.com/go-gost/x/registry"
 )
 
@@ -116,6 +117,8 @@ func (h *sniHandler) handleHTTP(ctx context.Context, rw io.ReadWriter, raddr net
 	log = log.WithFields(map[string]any{
 		"host": host,
 	})
+	xrecorder.Record(ctx, xrecorder.RecorderServiceHandlerDstAddress, []byte(host))
+	xrecorder.Record(ctx, xrecorder.RecorderServiceHandlerHTTPRequest, []byte(req.Method+" "+req.RequestURI+" "+req.Proto))
 
 	if h.options.Bypass != nil && h.options.Bypass.Contains(host) {
 		log.Debug("bypass: ", host)
@@ -170,6 +173,7 @@ func (h *sniHandler) handleHTTPS(ctx context.Context, rw io.ReadWriter, raddr ne
 		log.Error(err)
 		return err
 	}
+	xrecorder.Record(ctx, xrecorder.RecorderServiceHandlerTLSSNI, []byte(host))
 
 	if _, _, err := net.SplitHostPort(host); err != nil {
 		host = net.JoinHostPort(host, "443")
@@ -179,6 +183,7 @@ func (h *sniHandler) handleHTTPS(ctx context.Context, rw io.ReadWriter, raddr ne
 		"dst": host,
 	})
 	log.Debugf("%s >> %s", raddr, host)
+	xrecorder.Record(ctx, xrecorder.RecorderServiceHandlerDstAddress, []byte(host))
 
 	if h.options.Bypass != nil && h.options.Bypass.Contains(host) {
 		log.Debug("bypass: ", host)
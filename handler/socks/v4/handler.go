@@ -11,8 +11,10 @@ import (
 	"github.com/go-gost/core/logger"
 	md "github.com/go-gost/core/metadata"
 	"github.com/go-gost/gosocks4"
+	xctx "github.com/go-gost/x/ctx"
 	netpkg "github.com/go-gost/x/internal/net"
 	sx "github.com/go-gost/x/internal/util/selector"
+	xrecorder "github.com/go-gost/x/recorder"
 	"github.com/go-gost/x/registry"
 )
 
@@ -96,6 +98,9 @@ func (h *socks4Handler) Handle(ctx context.Context, conn net.Conn, opts ...handl
 		log.Trace(resp)
 		return resp.Write(conn)
 	}
+	if len(req.Userid) > 0 {
+		ctx = xctx.ContextWithClientID(ctx, xctx.ClientID(req.Userid))
+	}
 
 	switch req.Cmd {
 	case gosocks4.CmdConnect:
@@ -116,6 +121,7 @@ func (h *socks4Handler) handleConnect(ctx context.Context, conn net.Conn, req *g
 		"dst": addr,
 	})
 	log.Debugf("%s >> %s", conn.RemoteAddr(), addr)
+	xrecorder.Record(ctx, xrecorder.RecorderServiceHandlerDstAddress, []byte(addr))
 
 	if h.options.Bypass != nil && h.options.Bypass.Contains(addr) {
 		resp := gosocks4.NewReply(gosocks4.Rejected, nil)
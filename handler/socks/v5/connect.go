@@ -10,6 +10,7 @@ import (
 	"github.com/go-gost/gosocks5"
 	netpkg "github.com/go-gost/x/internal/net"
 	sx "github.com/go-gost/x/internal/util/selector"
+	xrecorder "github.com/go-gost/x/recorder"
 )
 
 func (h *socks5Handler) handleConnect(ctx context.Context, conn net.Conn, network, address string, log logger.Logger) error {
@@ -18,8 +19,9 @@ func (h *socks5Handler) handleConnect(ctx context.Context, conn net.Conn, networ
 		"cmd": "connect",
 	})
 	log.Debugf("%s >> %s", conn.RemoteAddr(), address)
+	xrecorder.Record(ctx, xrecorder.RecorderServiceHandlerDstAddress, []byte(address))
 
-	if h.options.Bypass != nil && h.options.Bypass.Contains(address) {
+	if h.isBypassed(ctx, conn, network, address) {
 		resp := gosocks5.NewReply(gosocks5.NotAllowed, nil)
 		log.Trace(resp)
 		log.Debug("bypass: ", address)
@@ -31,7 +33,7 @@ func (h *socks5Handler) handleConnect(ctx context.Context, conn net.Conn, networ
 		ctx = sx.ContextWithHash(ctx, &sx.Hash{Source: address})
 	}
 
-	cc, err := h.router.Dial(ctx, network, address)
+	cc, err := h.getRouter(ctx).Dial(ctx, network, address)
 	if err != nil {
 		resp := gosocks5.NewReply(gosocks5.NetUnreachable, nil)
 		log.Trace(resp)
@@ -6,10 +6,14 @@ import (
 	"net"
 	"time"
 
+	"github.com/go-gost/core/bypass"
 	"github.com/go-gost/core/chain"
 	"github.com/go-gost/core/handler"
 	md "github.com/go-gost/core/metadata"
 	"github.com/go-gost/gosocks5"
+	xbypass "github.com/go-gost/x/bypass"
+	xctx "github.com/go-gost/x/ctx"
+	xhandler "github.com/go-gost/x/handler"
 	"github.com/go-gost/x/internal/util/socks"
 	"github.com/go-gost/x/registry"
 )
@@ -24,10 +28,10 @@ func init() {
 }
 
 type socks5Handler struct {
-	selector gosocks5.Selector
-	router   *chain.Router
-	md       metadata
-	options  handler.Options
+	router  *chain.Router
+	routes  []*xhandler.UserRoute
+	md      metadata
+	options handler.Options
 }
 
 func NewHandler(opts ...handler.Option) handler.Handler {
@@ -51,16 +55,14 @@ func (h *socks5Handler) Init(md md.Metadata) (err error) {
 		h.router = chain.NewRouter(chain.LoggerRouterOption(h.options.Logger))
 	}
 
-	h.selector = &serverSelector{
-		Authenticator: h.options.Auther,
-		TLSConfig:     h.options.TLSConfig,
-		logger:        h.options.Logger,
-		noTLS:         h.md.noTLS,
-	}
-
 	return
 }
 
+// RouteUsers implements handler.UserRouter.
+func (h *socks5Handler) RouteUsers(routes ...*xhandler.UserRoute) {
+	h.routes = routes
+}
+
 func (h *socks5Handler) Handle(ctx context.Context, conn net.Conn, opts ...handler.HandleOption) error {
 	defer conn.Close()
 
@@ -86,7 +88,14 @@ func (h *socks5Handler) Handle(ctx context.Context, conn net.Conn, opts ...handl
 		conn.SetReadDeadline(time.Now().Add(h.md.readTimeout))
 	}
 
-	conn = gosocks5.ServerConn(conn, h.selector)
+	selector := &serverSelector{
+		Authenticator: h.options.Auther,
+		TLSConfig:     h.options.TLSConfig,
+		Routes:        h.routes,
+		logger:        log,
+		noTLS:         h.md.noTLS,
+	}
+	conn = gosocks5.ServerConn(conn, selector)
 	req, err := gosocks5.ReadRequest(conn)
 	if err != nil {
 		log.Error(err)
@@ -95,6 +104,20 @@ func (h *socks5Handler) Handle(ctx context.Context, conn net.Conn, opts ...handl
 	log.Trace(req)
 	conn.SetReadDeadline(time.Time{})
 
+	if selector.user != "" {
+		ctx = xctx.ContextWithClientID(ctx, xctx.ClientID(selector.user))
+		log = log.WithFields(map[string]any{"user": selector.user})
+	}
+	if route := selector.route; route != nil {
+		ctx = xhandler.ContextWithUserRoute(ctx, route)
+		if !h.checkUserRateLimit(route, selector.user) {
+			resp := gosocks5.NewReply(gosocks5.NotAllowed, nil)
+			log.Trace(resp)
+			log.Debugf("rate limit: user %s", selector.user)
+			return resp.Write(conn)
+		}
+	}
+
 	address := req.Addr.String()
 
 	switch req.Cmd {
@@ -129,3 +152,39 @@ func (h *socks5Handler) checkRateLimit(addr net.Addr) bool {
 
 	return true
 }
+
+func (h *socks5Handler) checkUserRateLimit(route *xhandler.UserRoute, user string) bool {
+	if route.RateLimiter == nil {
+		return true
+	}
+	if limiter := route.RateLimiter.Limiter(user); limiter != nil {
+		return limiter.Allow(1)
+	}
+	return true
+}
+
+// getRouter returns the router of the client's route if any, otherwise the default router.
+func (h *socks5Handler) getRouter(ctx context.Context) *chain.Router {
+	if route := xhandler.UserRouteFromContext(ctx); route != nil && route.Router != nil {
+		return route.Router
+	}
+	return h.router
+}
+
+func (h *socks5Handler) isBypassed(ctx context.Context, conn net.Conn, network, address string) bool {
+	clientIP, _, _ := net.SplitHostPort(conn.RemoteAddr().String())
+	return xbypass.Contains(h.getBypass(ctx), &xbypass.Request{
+		Network:  network,
+		Addr:     address,
+		ClientIP: clientIP,
+		User:     string(xctx.ClientIDFromContext(ctx)),
+	})
+}
+
+// getBypass returns the bypass of the client's route if any, otherwise the default bypass.
+func (h *socks5Handler) getBypass(ctx context.Context) bypass.Bypass {
+	if route := xhandler.UserRouteFromContext(ctx); route != nil && route.Bypass != nil {
+		return route.Bypass
+	}
+	return h.options.Bypass
+}
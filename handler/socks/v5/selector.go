@@ -7,6 +7,7 @@ import (
 	"github.com/go-gost/core/auth"
 	"github.com/go-gost/core/logger"
 	"github.com/go-gost/gosocks5"
+	xhandler "github.com/go-gost/x/handler"
 	"github.com/go-gost/x/internal/util/socks"
 )
 
@@ -14,8 +15,13 @@ type serverSelector struct {
 	methods       []uint8
 	Authenticator auth.Authenticator
 	TLSConfig     *tls.Config
+	Routes        []*xhandler.UserRoute
 	logger        logger.Logger
 	noTLS         bool
+
+	// the authenticated user and its matched route.
+	user  string
+	route *xhandler.UserRoute
 }
 
 func (selector *serverSelector) Methods() []uint8 {
@@ -74,6 +80,11 @@ func (s *serverSelector) OnSelected(method uint8, conn net.Conn) (net.Conn, erro
 
 			return nil, gosocks5.ErrAuthFailure
 		}
+		// the username is trusted only if it is authenticated.
+		if s.Authenticator != nil {
+			s.user = req.Username
+			s.route = xhandler.MatchUserRoute(s.Routes, s.user)
+		}
 
 		resp := gosocks5.NewUserPassResponse(gosocks5.UserPassVer, gosocks5.Succeeded)
 		s.logger.Trace(resp)
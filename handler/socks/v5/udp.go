@@ -11,6 +11,7 @@ import (
 
 	"github.com/go-gost/core/logger"
 	"github.com/go-gost/gosocks5"
+	xctx "github.com/go-gost/x/ctx"
 	"github.com/go-gost/x/internal/net/udp"
 	"github.com/go-gost/x/internal/util/socks"
 )
@@ -54,7 +55,7 @@ func (h *socks5Handler) handleUDP(ctx context.Context, conn net.Conn, log logger
 	log.Debugf("bind on %s OK", cc.LocalAddr())
 
 	// obtain a udp connection
-	c, err := h.router.Dial(ctx, "udp", "") // UDP association
+	c, err := h.getRouter(ctx).Dial(ctx, "udp", "") // UDP association
 	if err != nil {
 		log.Error(err)
 		return err
@@ -68,9 +69,12 @@ func (h *socks5Handler) handleUDP(ctx context.Context, conn net.Conn, log logger
 		return err
 	}
 
+	clientIP, _, _ := net.SplitHostPort(conn.RemoteAddr().String())
 	r := udp.NewRelay(socks.UDPConn(cc, h.md.udpBufferSize), pc).
-		WithBypass(h.options.Bypass).
+		WithBypass(h.getBypass(ctx)).
+		WithClient(clientIP, string(xctx.ClientIDFromContext(ctx))).
 		WithLogger(log).
+		WithContext(ctx).
 		WithStun(*h.options.Stun)
 	r.SetBufferSize(h.md.udpBufferSize)
 
@@ -7,6 +7,7 @@ import (
 
 	"github.com/go-gost/core/logger"
 	"github.com/go-gost/gosocks5"
+	xctx "github.com/go-gost/x/ctx"
 	"github.com/go-gost/x/internal/net/udp"
 	"github.com/go-gost/x/internal/util/socks"
 )
@@ -49,7 +50,7 @@ func (h *socks5Handler) handleUDPTun(ctx context.Context, conn net.Conn, network
 		log.Error(err)
 		return err
 	}
-	mark := h.router.Options().SockOpts.Mark
+	mark := h.getRouter(ctx).Options().SockOpts.Mark
 	err = sc.Control(func(fd uintptr) {
 		if mark != 0 {
 			if err := setMark(fd, mark); err != nil {
@@ -72,9 +73,12 @@ func (h *socks5Handler) handleUDPTun(ctx context.Context, conn net.Conn, network
 	}
 	log.Debugf("bind on %s OK", pc.LocalAddr())
 
+	clientIP, _, _ := net.SplitHostPort(conn.RemoteAddr().String())
 	r := udp.NewRelay(socks.UDPTunServerConn(conn), pc).
-		WithBypass(h.options.Bypass).
+		WithBypass(h.getBypass(ctx)).
+		WithClient(clientIP, string(xctx.ClientIDFromContext(ctx))).
 		WithLogger(log).
+		WithContext(ctx).
 		WithStun(*h.options.Stun)
 	r.SetBufferSize(h.md.udpBufferSize)
 
@@ -11,9 +11,14 @@ import (
 	"github.com/go-gost/core/handler"
 	md "github.com/go-gost/core/metadata"
 	"github.com/go-gost/gosocks5"
+	xauth "github.com/go-gost/x/auth"
+	xbypass "github.com/go-gost/x/bypass"
+	xctx "github.com/go-gost/x/ctx"
+	xhandler "github.com/go-gost/x/handler"
 	netpkg "github.com/go-gost/x/internal/net"
 	sx "github.com/go-gost/x/internal/util/selector"
 	"github.com/go-gost/x/internal/util/ss"
+	xrecorder "github.com/go-gost/x/recorder"
 	"github.com/go-gost/x/registry"
 	"github.com/shadowsocks/go-shadowsocks2/core"
 )
@@ -25,6 +30,7 @@ func init() {
 type ssHandler struct {
 	cipher  core.Cipher
 	router  *chain.Router
+	routes  []*xhandler.UserRoute
 	md      metadata
 	options handler.Options
 }
@@ -52,6 +58,11 @@ func (h *ssHandler) Init(md md.Metadata) (err error) {
 			return
 		}
 	}
+	if c, ok := h.cipher.(*ss.Cipher2022); ok {
+		if lister, ok := h.options.Auther.(xauth.Lister); ok {
+			c.SetUsers(lister)
+		}
+	}
 
 	h.router = h.options.Router
 	if h.router == nil {
@@ -61,6 +72,12 @@ func (h *ssHandler) Init(md md.Metadata) (err error) {
 	return
 }
 
+// RouteUsers implements handler.UserRouter.
+// The users are identified by the identity headers of the 2022 ciphers.
+func (h *ssHandler) RouteUsers(routes ...*xhandler.UserRoute) {
+	h.routes = routes
+}
+
 func (h *ssHandler) Handle(ctx context.Context, conn net.Conn, opts ...handler.HandleOption) error {
 	defer conn.Close()
 
@@ -82,7 +99,7 @@ func (h *ssHandler) Handle(ctx context.Context, conn net.Conn, opts ...handler.H
 	}
 
 	if h.cipher != nil {
-		conn = ss.ShadowConn(h.cipher.StreamConn(conn), nil)
+		conn = ss.ShadowConn(ss.ServerStreamConn(h.cipher, conn), nil)
 	}
 
 	if h.md.readTimeout > 0 {
@@ -100,9 +117,36 @@ func (h *ssHandler) Handle(ctx context.Context, conn net.Conn, opts ...handler.H
 		"dst": addr.String(),
 	})
 
-	log.Debugf("%s >> %s", conn.RemoteAddr(), addr)
+	bp, router := h.options.Bypass, h.router
+	user, _ := ss.User(conn)
+	if user != "" {
+		ctx = xctx.ContextWithClientID(ctx, xctx.ClientID(user))
+		log = log.WithFields(map[string]any{"user": user})
+
+		if route := xhandler.MatchUserRoute(h.routes, user); route != nil {
+			if !h.checkUserRateLimit(route, user) {
+				log.Debugf("rate limit: user %s", user)
+				return nil
+			}
+			if route.Bypass != nil {
+				bp = route.Bypass
+			}
+			if route.Router != nil {
+				router = route.Router
+			}
+		}
+	}
 
-	if h.options.Bypass != nil && h.options.Bypass.Contains(addr.String()) {
+	log.Debugf("%s >> %s", conn.RemoteAddr(), addr)
+	xrecorder.Record(ctx, xrecorder.RecorderServiceHandlerDstAddress, []byte(addr.String()))
+
+	clientIP, _, _ := net.SplitHostPort(conn.RemoteAddr().String())
+	if xbypass.Contains(bp, &xbypass.Request{
+		Network:  "tcp",
+		Addr:     addr.String(),
+		ClientIP: clientIP,
+		User:     user,
+	}) {
 		log.Debug("bypass: ", addr.String())
 		return nil
 	}
@@ -112,7 +156,7 @@ func (h *ssHandler) Handle(ctx context.Context, conn net.Conn, opts ...handler.H
 		ctx = sx.ContextWithHash(ctx, &sx.Hash{Source: addr.String()})
 	}
 
-	cc, err := h.router.Dial(ctx, "tcp", addr.String())
+	cc, err := router.Dial(ctx, "tcp", addr.String())
 	if err != nil {
 		return err
 	}
@@ -139,3 +183,13 @@ func (h *ssHandler) checkRateLimit(addr net.Addr) bool {
 
 	return true
 }
+
+func (h *ssHandler) checkUserRateLimit(route *xhandler.UserRoute, user string) bool {
+	if route.RateLimiter == nil {
+		return true
+	}
+	if limiter := route.RateLimiter.Limiter(user); limiter != nil {
+		return limiter.Allow(1)
+	}
+	return true
+}
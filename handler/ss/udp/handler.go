@@ -11,8 +11,10 @@ import (
 	"github.com/go-gost/core/handler"
 	"github.com/go-gost/core/logger"
 	md "github.com/go-gost/core/metadata"
+	xauth "github.com/go-gost/x/auth"
 	"github.com/go-gost/x/internal/util/relay"
 	"github.com/go-gost/x/internal/util/ss"
+	xrecorder "github.com/go-gost/x/recorder"
 	"github.com/go-gost/x/registry"
 	"github.com/shadowsocks/go-shadowsocks2/core"
 )
@@ -52,6 +54,11 @@ func (h *ssuHandler) Init(md md.Metadata) (err error) {
 			return
 		}
 	}
+	if c, ok := h.cipher.(*ss.Cipher2022); ok {
+		if lister, ok := h.options.Auther.(xauth.Lister); ok {
+			c.SetUsers(lister)
+		}
+	}
 
 	h.router = h.options.Router
 	if h.router == nil {
@@ -84,11 +91,16 @@ func (h *ssuHandler) Handle(ctx context.Context, conn net.Conn, opts ...handler.
 	pc, ok := conn.(net.PacketConn)
 	if ok {
 		if h.cipher != nil {
-			pc = h.cipher.PacketConn(pc)
+			pc = ss.ServerPacketConn(h.cipher, pc)
 		}
 		// standard UDP relay.
 		pc = ss.UDPServerConn(pc, conn.RemoteAddr(), h.md.bufferSize)
 	} else {
+		if _, ok := h.cipher.(*ss.Cipher2022); ok {
+			err := errors.New("ss: UDP over TCP is unsupported by the 2022 ciphers")
+			log.Error(err)
+			return err
+		}
 		if h.cipher != nil {
 			conn = ss.ShadowConn(h.cipher.StreamConn(conn), nil)
 		}
@@ -113,18 +125,20 @@ func (h *ssuHandler) Handle(ctx context.Context, conn net.Conn, opts ...handler.
 
 	t := time.Now()
 	log.Debugf("%s <-> %s", conn.LocalAddr(), cc.LocalAddr())
-	h.relayPacket(pc, cc, log)
+	h.relayPacket(ctx, pc, cc, log)
 	log.WithFields(map[string]any{"duration": time.Since(t)}).
 		Debugf("%s >-< %s", conn.LocalAddr(), cc.LocalAddr())
 
 	return nil
 }
 
-func (h *ssuHandler) relayPacket(pc1, pc2 net.PacketConn, log logger.Logger) (err error) {
+func (h *ssuHandler) relayPacket(ctx context.Context, pc1, pc2 net.PacketConn, log logger.Logger) (err error) {
 	bufSize := h.md.bufferSize
 	errc := make(chan error, 2)
 
 	go func() {
+		// the destination addresses recorded.
+		dsts := make(map[string]bool)
 		for {
 			err := func() error {
 				b := bufpool.Get(bufSize)
@@ -140,6 +154,11 @@ func (h *ssuHandler) relayPacket(pc1, pc2 net.PacketConn, log logger.Logger) (er
 					return nil
 				}
 
+				if !dsts[addr.String()] {
+					dsts[addr.String()] = true
+					xrecorder.Record(ctx, xrecorder.RecorderServiceHandlerDstAddress, []byte(addr.String()))
+				}
+
 				if _, err = pc2.WriteTo((*b)[:n], addr); err != nil {
 					return err
 				}
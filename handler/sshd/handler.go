@@ -15,6 +15,7 @@ import (
 	md "github.com/go-gost/core/metadata"
 	netpkg "github.com/go-gost/x/internal/net"
 	sshd_util "github.com/go-gost/x/internal/util/sshd"
+	xrecorder "github.com/go-gost/x/recorder"
 	"github.com/go-gost/x/registry"
 	"golang.org/x/crypto/ssh"
 )
@@ -91,6 +92,7 @@ func (h *forwardHandler) handleDirectForward(ctx context.Context, conn *sshd_uti
 	})
 
 	log.Debugf("%s >> %s", conn.RemoteAddr(), targetAddr)
+	xrecorder.Record(ctx, xrecorder.RecorderServiceHandlerDstAddress, []byte(targetAddr))
 
 	if h.options.Bypass != nil && h.options.Bypass.Contains(targetAddr) {
 		log.Debugf("bypass %s", targetAddr)
@@ -0,0 +1,54 @@
+package trojan
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/go-gost/core/logger"
+	netpkg "github.com/go-gost/x/internal/net"
+)
+
+// handleFallback forwards the non-Trojan or unauthenticated traffic to the target of the forwarder,
+// so that the service looks like the fallback server to the probes.
+func (h *trojanHandler) handleFallback(ctx context.Context, conn net.Conn, log logger.Logger) error {
+	if h.hop == nil {
+		log.Debug(ErrNoFallback)
+		return ErrNoFallback
+	}
+
+	target := h.hop.Select(ctx)
+	if target == nil {
+		err := ErrNoFallback
+		log.Error(err)
+		return err
+	}
+
+	log = log.WithFields(map[string]any{
+		"dst": target.Addr,
+		"cmd": "fallback",
+	})
+	log.Debugf("%s >> %s", conn.RemoteAddr(), target.Addr)
+
+	cc, err := h.router.Dial(ctx, "tcp", target.Addr)
+	if err != nil {
+		if marker := target.Marker(); marker != nil {
+			marker.Mark()
+		}
+		log.Error(err)
+		return err
+	}
+	defer cc.Close()
+	if marker := target.Marker(); marker != nil {
+		marker.Reset()
+	}
+
+	t := time.Now()
+	log.Debugf("%s <-> %s", conn.RemoteAddr(), target.Addr)
+	netpkg.Transport(conn, cc)
+	log.WithFields(map[string]any{
+		"duration": time.Since(t),
+	}).Debugf("%s >-< %s", conn.RemoteAddr(), target.Addr)
+
+	return nil
+}
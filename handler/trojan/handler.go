@@ -0,0 +1,293 @@
+package trojan
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/go-gost/core/auth"
+	"github.com/go-gost/core/bypass"
+	"github.com/go-gost/core/chain"
+	"github.com/go-gost/core/handler"
+	md "github.com/go-gost/core/metadata"
+	xauth "github.com/go-gost/x/auth"
+	xbypass "github.com/go-gost/x/bypass"
+	xctx "github.com/go-gost/x/ctx"
+	xhandler "github.com/go-gost/x/handler"
+	netpkg "github.com/go-gost/x/internal/net"
+	"github.com/go-gost/x/internal/util/trojan"
+	"github.com/go-gost/x/registry"
+)
+
+var (
+	ErrUnknownCmd  = errors.New("trojan: unknown command")
+	ErrAuthFailure = errors.New("trojan: authentication failure")
+	ErrUDPDisabled = errors.New("trojan: UDP relay is disabled")
+	ErrNoFallback  = errors.New("trojan: no fallback target")
+)
+
+const (
+	// usersReloadInterval is the minimum interval of reloading the users from the auther
+	// when an unknown password hash is received.
+	usersReloadInterval = time.Second
+)
+
+func init() {
+	registry.HandlerRegistry().Register("trojan", NewHandler)
+}
+
+type trojanHandler struct {
+	hop     chain.Hop
+	router  *chain.Router
+	routes  []*xhandler.UserRoute
+	users   *users
+	md      metadata
+	options handler.Options
+}
+
+func NewHandler(opts ...handler.Option) handler.Handler {
+	options := handler.Options{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return &trojanHandler{
+		options: options,
+	}
+}
+
+func (h *trojanHandler) Init(md md.Metadata) (err error) {
+	if err = h.parseMetadata(md); err != nil {
+		return
+	}
+
+	h.users = &users{
+		auther: h.options.Auther,
+	}
+	if h.options.Auth != nil {
+		password, _ := h.options.Auth.Password()
+		h.users.auth = &credential{
+			user:     h.options.Auth.Username(),
+			password: password,
+		}
+	}
+	if h.options.Auther != nil {
+		if _, ok := h.options.Auther.(xauth.Lister); !ok {
+			return errors.New("trojan: the users of the auther can not be listed")
+		}
+	}
+
+	h.router = h.options.Router
+	if h.router == nil {
+		h.router = chain.NewRouter(chain.LoggerRouterOption(h.options.Logger))
+	}
+
+	return
+}
+
+// Forward implements handler.Forwarder.
+// The unauthenticated traffic is forwarded to the target of the hop.
+func (h *trojanHandler) Forward(hop chain.Hop) {
+	h.hop = hop
+}
+
+// RouteUsers implements handler.UserRouter.
+func (h *trojanHandler) RouteUsers(routes ...*xhandler.UserRoute) {
+	h.routes = routes
+}
+
+func (h *trojanHandler) Handle(ctx context.Context, conn net.Conn, opts ...handler.HandleOption) error {
+	defer conn.Close()
+
+	start := time.Now()
+	log := h.options.Logger.WithFields(map[string]any{
+		"remote": conn.RemoteAddr().String(),
+		"local":  conn.LocalAddr().String(),
+	})
+
+	log.Infof("%s <> %s", conn.RemoteAddr(), conn.LocalAddr())
+	defer func() {
+		log.WithFields(map[string]any{
+			"duration": time.Since(start),
+		}).Infof("%s >< %s", conn.RemoteAddr(), conn.LocalAddr())
+	}()
+
+	if !h.checkRateLimit(conn.RemoteAddr()) {
+		return nil
+	}
+
+	if h.md.readTimeout > 0 {
+		conn.SetReadDeadline(time.Now().Add(h.md.readTimeout))
+	}
+
+	br := bufio.NewReader(conn)
+	hash, err := trojan.PeekHash(br)
+	if err != nil {
+		if errors.Is(err, trojan.ErrBadRequest) {
+			conn.SetReadDeadline(time.Time{})
+			return h.handleFallback(ctx, netpkg.NewBufferReaderConn(conn, br), log)
+		}
+		log.Error(err)
+		return err
+	}
+
+	user, _, ok := h.users.authenticate(hash)
+	if !ok {
+		log.Debug(ErrAuthFailure)
+		conn.SetReadDeadline(time.Time{})
+		return h.handleFallback(ctx, netpkg.NewBufferReaderConn(conn, br), log)
+	}
+
+	br.Discard(trojan.HeaderLen)
+
+	req := &trojan.Request{Hash: hash}
+	if _, err := req.ReadFrom(br); err != nil {
+		log.Error(err)
+		return err
+	}
+	conn.SetReadDeadline(time.Time{})
+	conn = netpkg.NewBufferReaderConn(conn, br)
+
+	if user != "" {
+		ctx = xctx.ContextWithClientID(ctx, xctx.ClientID(user))
+		log = log.WithFields(map[string]any{"user": user})
+	}
+	if route := xhandler.MatchUserRoute(h.routes, user); route != nil {
+		ctx = xhandler.ContextWithUserRoute(ctx, route)
+		if !h.checkUserRateLimit(route, user) {
+			log.Debugf("rate limit: user %s", user)
+			return nil
+		}
+	}
+
+	switch req.Cmd {
+	case trojan.CmdConnect:
+		return h.handleConnect(ctx, conn, "tcp", req.Addr.String(), log)
+	case trojan.CmdUDPAssociate:
+		return h.handleUDP(ctx, conn, log)
+	default:
+		err = ErrUnknownCmd
+		log.Error(err)
+		return err
+	}
+}
+
+func (h *trojanHandler) checkRateLimit(addr net.Addr) bool {
+	if h.options.RateLimiter == nil {
+		return true
+	}
+	host, _, _ := net.SplitHostPort(addr.String())
+	if limiter := h.options.RateLimiter.Limiter(host); limiter != nil {
+		return limiter.Allow(1)
+	}
+
+	return true
+}
+
+func (h *trojanHandler) checkUserRateLimit(route *xhandler.UserRoute, user string) bool {
+	if route.RateLimiter == nil {
+		return true
+	}
+	if limiter := route.RateLimiter.Limiter(user); limiter != nil {
+		return limiter.Allow(1)
+	}
+	return true
+}
+
+// getRouter returns the router of the client's route if any, otherwise the default router.
+func (h *trojanHandler) getRouter(ctx context.Context) *chain.Router {
+	if route := xhandler.UserRouteFromContext(ctx); route != nil && route.Router != nil {
+		return route.Router
+	}
+	return h.router
+}
+
+func (h *trojanHandler) isBypassed(ctx context.Context, conn net.Conn, network, address string) bool {
+	clientIP, _, _ := net.SplitHostPort(conn.RemoteAddr().String())
+	return xbypass.Contains(h.getBypass(ctx), &xbypass.Request{
+		Network:  network,
+		Addr:     address,
+		ClientIP: clientIP,
+		User:     string(xctx.ClientIDFromContext(ctx)),
+	})
+}
+
+// getBypass returns the bypass of the client's route if any, otherwise the default bypass.
+func (h *trojanHandler) getBypass(ctx context.Context) bypass.Bypass {
+	if route := xhandler.UserRouteFromContext(ctx); route != nil && route.Bypass != nil {
+		return route.Bypass
+	}
+	return h.options.Bypass
+}
+
+type credential struct {
+	user     string
+	password string
+}
+
+// users maps the password hashes to the credentials of the handler.
+// The users without password are identified by the username.
+type users struct {
+	auth    *credential
+	auther  auth.Authenticator
+	hashes  map[string]credential
+	updated time.Time
+	mu      sync.Mutex
+}
+
+// authenticate returns the credential of the password hash.
+// All clients are allowed if neither the auth nor the auther is specified.
+func (u *users) authenticate(hash string) (user, password string, ok bool) {
+	if u.auth == nil && u.auther == nil {
+		return "", "", true
+	}
+
+	c, ok := u.lookup(hash)
+	if !ok {
+		return
+	}
+	// the auther is authoritative for the removed or changed users.
+	if u.auther != nil && (u.auth == nil || c != *u.auth) &&
+		!u.auther.Authenticate(c.user, c.password) {
+		return "", "", false
+	}
+	return c.user, c.password, true
+}
+
+func (u *users) lookup(hash string) (credential, bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	c, ok := u.hashes[hash]
+	if !ok && time.Since(u.updated) >= usersReloadInterval {
+		// the users may be updated by the auther.
+		u.reload()
+		c, ok = u.hashes[hash]
+	}
+	return c, ok
+}
+
+func (u *users) reload() {
+	hashes := make(map[string]credential)
+	if lister, ok := u.auther.(xauth.Lister); ok {
+		for user, password := range lister.Users() {
+			hashes[credentialHash(user, password)] = credential{user: user, password: password}
+		}
+	}
+	if u.auth != nil {
+		hashes[credentialHash(u.auth.user, u.auth.password)] = *u.auth
+	}
+
+	u.hashes = hashes
+	u.updated = time.Now()
+}
+
+func credentialHash(user, password string) string {
+	if password == "" {
+		return trojan.Hash(user)
+	}
+	return trojan.Hash(password)
+}
@@ -0,0 +1,101 @@
+package trojan
+
+import (
+	"testing"
+
+	"github.com/go-gost/core/auth"
+	xauth "github.com/go-gost/x/auth"
+	"github.com/go-gost/x/internal/util/trojan"
+	xlogger "github.com/go-gost/x/logger"
+)
+
+func newAuther(auths map[string]string) auth.Authenticator {
+	return xauth.NewAuthenticator(xauth.AuthsOption(auths), xauth.LoggerOption(xlogger.Nop()))
+}
+
+// changingAuther is the auther whose users can be changed.
+type changingAuther struct {
+	users map[string]string
+}
+
+func (a *changingAuther) Authenticate(user, password string) bool {
+	v, ok := a.users[user]
+	return ok && v == password
+}
+
+func (a *changingAuther) Users() map[string]string {
+	return a.users
+}
+
+func TestUsersAuthenticate(t *testing.T) {
+	tests := []struct {
+		name     string
+		auth     *credential
+		auther   auth.Authenticator
+		password string
+		user     string
+		ok       bool
+	}{
+		{name: "no auth", password: "any", ok: true},
+		{
+			name:     "auth",
+			auth:     &credential{user: "admin", password: "pass"},
+			password: "pass",
+			user:     "admin",
+			ok:       true,
+		},
+		{
+			name:     "auth without password",
+			auth:     &credential{user: "token"},
+			password: "token",
+			user:     "token",
+			ok:       true,
+		},
+		{
+			name:     "auther",
+			auther:   newAuther(map[string]string{"alice": "a", "bob": "b"}),
+			password: "b",
+			user:     "bob",
+			ok:       true,
+		},
+		{
+			name:     "wrong password",
+			auth:     &credential{user: "admin", password: "pass"},
+			auther:   newAuther(map[string]string{"alice": "a"}),
+			password: "b",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := &users{auth: tt.auth, auther: tt.auther}
+			user, _, ok := u.authenticate(trojan.Hash(tt.password))
+			if ok != tt.ok || user != tt.user {
+				t.Errorf("got %q, %v, want %q, %v", user, ok, tt.user, tt.ok)
+			}
+		})
+	}
+}
+
+func TestUsersReload(t *testing.T) {
+	a := &changingAuther{users: map[string]string{"alice": "a"}}
+	u := &users{auther: a}
+
+	if _, _, ok := u.authenticate(trojan.Hash("a")); !ok {
+		t.Fatal("alice is not authenticated")
+	}
+
+	// the removed user is rejected by the auther before the hashes are reloaded.
+	a.users = map[string]string{"bob": "b"}
+	if _, _, ok := u.authenticate(trojan.Hash("a")); ok {
+		t.Error("the removed user is authenticated")
+	}
+
+	// the added user is found after the reload interval.
+	if _, _, ok := u.authenticate(trojan.Hash("b")); ok {
+		t.Error("the hashes are reloaded within the reload interval")
+	}
+	u.updated = u.updated.Add(-usersReloadInterval)
+	if user, _, ok := u.authenticate(trojan.Hash("b")); !ok || user != "bob" {
+		t.Errorf("got %q, %v, want the added user bob", user, ok)
+	}
+}
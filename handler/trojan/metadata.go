@@ -0,0 +1,41 @@
+package trojan
+
+import (
+	"math"
+	"time"
+
+	mdata "github.com/go-gost/core/metadata"
+	mdutil "github.com/go-gost/core/metadata/util"
+)
+
+type metadata struct {
+	readTimeout   time.Duration
+	enableUDP     bool
+	udpBufferSize int
+	hash          string
+}
+
+func (h *trojanHandler) parseMetadata(md mdata.Metadata) (err error) {
+	const (
+		readTimeout   = "readTimeout"
+		enableUDP     = "udp"
+		udpBufferSize = "udpBufferSize"
+		hash          = "hash"
+	)
+
+	h.md.readTimeout = mdutil.GetDuration(md, readTimeout)
+	h.md.enableUDP = true
+	if md != nil && md.IsExists(enableUDP) {
+		h.md.enableUDP = mdutil.GetBool(md, enableUDP)
+	}
+
+	if bs := mdutil.GetInt(md, udpBufferSize); bs > 0 {
+		h.md.udpBufferSize = int(math.Min(math.Max(float64(bs), 512), 64*1024))
+	} else {
+		h.md.udpBufferSize = 1500
+	}
+
+	h.md.hash = mdutil.GetString(md, hash)
+
+	return
+}
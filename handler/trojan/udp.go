@@ -0,0 +1,58 @@
+package trojan
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/go-gost/core/logger"
+	xctx "github.com/go-gost/x/ctx"
+	"github.com/go-gost/x/internal/net/udp"
+	"github.com/go-gost/x/internal/util/trojan"
+)
+
+func (h *trojanHandler) handleUDP(ctx context.Context, conn net.Conn, log logger.Logger) error {
+	log = log.WithFields(map[string]any{
+		"cmd": "udp",
+	})
+
+	if !h.md.enableUDP {
+		log.Error(ErrUDPDisabled)
+		return ErrUDPDisabled
+	}
+
+	// obtain a udp connection
+	c, err := h.getRouter(ctx).Dial(ctx, "udp", "") // UDP association
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	defer c.Close()
+
+	cc, ok := c.(net.PacketConn)
+	if !ok {
+		err := errors.New("trojan: wrong connection type")
+		log.Error(err)
+		return err
+	}
+
+	clientIP, _, _ := net.SplitHostPort(conn.RemoteAddr().String())
+	r := udp.NewRelay(trojan.UDPServerConn(conn), cc).
+		WithBypass(h.getBypass(ctx)).
+		WithClient(clientIP, string(xctx.ClientIDFromContext(ctx))).
+		WithLogger(log)
+	if h.options.Stun != nil {
+		r.WithStun(*h.options.Stun)
+	}
+	r.SetBufferSize(h.md.udpBufferSize)
+
+	t := time.Now()
+	log.Debugf("%s <-> %s", conn.RemoteAddr(), cc.LocalAddr())
+	r.Run()
+	log.WithFields(map[string]any{
+		"duration": time.Since(t),
+	}).Debugf("%s >-< %s", conn.RemoteAddr(), cc.LocalAddr())
+
+	return nil
+}
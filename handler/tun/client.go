@@ -9,6 +9,7 @@ import (
 	"github.com/go-gost/core/common/bufpool"
 	"github.com/go-gost/core/logger"
 	tun_util "github.com/go-gost/x/internal/util/tun"
+	xrecorder "github.com/go-gost/x/recorder"
 	"github.com/songgao/water/waterutil"
 	"golang.org/x/net/ipv4"
 	"golang.org/x/net/ipv6"
@@ -29,6 +30,7 @@ func (h *tunHandler) handleClient(ctx context.Context, conn net.Conn, addr net.A
 		return err
 	}
 
+	xrecorder.Record(ctx, xrecorder.RecorderServiceHandlerDstAddress, []byte(addr.String()))
 	cc, err := h.router.Dial(ctx, addr.Network(), addr.String())
 	if err != nil {
 		return err
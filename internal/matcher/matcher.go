@@ -1,7 +1,9 @@
 package matcher
 
 import (
+	"fmt"
 	"net"
+	"strconv"
 	"strings"
 
 	"github.com/gobwas/glob"
@@ -127,6 +129,12 @@ func WildcardMatcher(patterns []string) Matcher {
 	return matcher
 }
 
+// CheckWildcard reports whether the wildcard pattern can be used by WildcardMatcher.
+func CheckWildcard(pattern string) error {
+	_, err := glob.Compile(pattern)
+	return err
+}
+
 func (m *wildcardMatcher) Match(domain string) bool {
 	if m == nil || len(m.patterns) == 0 {
 		return false
@@ -140,3 +148,111 @@ func (m *wildcardMatcher) Match(domain string) bool {
 
 	return false
 }
+
+type portRange struct {
+	min, max int
+}
+
+type portMatcher struct {
+	ranges []portRange
+}
+
+// PortMatcher creates a Matcher for a list of ports or port ranges,
+// the pattern should be a port such as '80', or a port range such as '8000-9000'.
+func PortMatcher(patterns []string) (Matcher, error) {
+	matcher := &portMatcher{}
+	for _, pattern := range patterns {
+		r, err := parsePortRange(pattern)
+		if err != nil {
+			return nil, err
+		}
+		matcher.ranges = append(matcher.ranges, r)
+	}
+	return matcher, nil
+}
+
+func parsePortRange(s string) (r portRange, err error) {
+	minPort, maxPort, found := strings.Cut(strings.TrimSpace(s), "-")
+	if r.min, err = strconv.Atoi(strings.TrimSpace(minPort)); err != nil {
+		return
+	}
+	r.max = r.min
+	if found {
+		if r.max, err = strconv.Atoi(strings.TrimSpace(maxPort)); err != nil {
+			return
+		}
+	}
+	if r.min < 0 || r.max > 65535 || r.min > r.max {
+		err = fmt.Errorf("invalid port range: %s", s)
+	}
+	return
+}
+
+func (m *portMatcher) Match(port string) bool {
+	if m == nil || len(m.ranges) == 0 {
+		return false
+	}
+
+	v, err := strconv.Atoi(port)
+	if err != nil {
+		return false
+	}
+	for _, r := range m.ranges {
+		if v >= r.min && v <= r.max {
+			return true
+		}
+	}
+	return false
+}
+
+type hostPortMatcher struct {
+	host Matcher
+	port Matcher
+}
+
+// HostPortMatcher creates a Matcher for the address in the form of host:port,
+// it matches if both the host and port match. A nil host Matcher matches any host.
+func HostPortMatcher(host Matcher, port Matcher) Matcher {
+	return &hostPortMatcher{
+		host: host,
+		port: port,
+	}
+}
+
+func (m *hostPortMatcher) Match(addr string) bool {
+	if m == nil || m.port == nil {
+		return false
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	return m.port.Match(port) &&
+		(m.host == nil || m.host.Match(host))
+}
+
+// SplitHostPort splits the pattern in the form of host:port into host and port parts,
+// such as ':25', '*.example.com:8000-9000', '10.0.0.0/8:22,80' or '[2001:db8::/32]:22'.
+// The port part can be a comma separated list of ports or port ranges.
+// ok is false if the pattern does not contain a port part.
+func SplitHostPort(pattern string) (host, port string, ok bool) {
+	if net.ParseIP(pattern) != nil {
+		return
+	}
+	if _, _, err := net.ParseCIDR(pattern); err == nil {
+		return
+	}
+
+	i := strings.LastIndexByte(pattern, ':')
+	if i < 0 {
+		return
+	}
+	host, port = pattern[:i], pattern[i+1:]
+	if port == "" || strings.Trim(port, "0123456789-,") != "" {
+		return "", "", false
+	}
+	if strings.HasPrefix(host, "[") && strings.HasSuffix(host, "]") {
+		host = host[1 : len(host)-1]
+	}
+	return host, port, true
+}
@@ -0,0 +1,100 @@
+package matcher
+
+import (
+	"testing"
+)
+
+func TestSplitHostPort(t *testing.T) {
+	tests := []struct {
+		pattern string
+		host    string
+		port    string
+		ok      bool
+	}{
+		{pattern: "example.com"},
+		{pattern: "2001:db8::1"},
+		{pattern: "2001:db8::/32"},
+		{pattern: "10.0.0.0/8"},
+		{pattern: "example.com:"},
+		{pattern: "example.com:http"},
+		{pattern: ":25", port: "25", ok: true},
+		{pattern: "*.example.com:8000-9000", host: "*.example.com", port: "8000-9000", ok: true},
+		{pattern: "10.0.0.0/8:22,80", host: "10.0.0.0/8", port: "22,80", ok: true},
+		{pattern: "[2001:db8::/32]:22", host: "2001:db8::/32", port: "22", ok: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			host, port, ok := SplitHostPort(tt.pattern)
+			if host != tt.host || port != tt.port || ok != tt.ok {
+				t.Errorf("got %q, %q, %v, want %q, %q, %v", host, port, ok, tt.host, tt.port, tt.ok)
+			}
+		})
+	}
+}
+
+func TestPortMatcher(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		err      bool
+		match    []string
+		mismatch []string
+	}{
+		{name: "port", patterns: []string{"80"}, match: []string{"80"}, mismatch: []string{"81", "http", ""}},
+		{
+			name:     "ranges",
+			patterns: []string{"22", "8000-9000"},
+			match:    []string{"22", "8000", "8500", "9000"},
+			mismatch: []string{"7999", "9001"},
+		},
+		{name: "spaces", patterns: []string{" 1000 - 2000 "}, match: []string{"1500"}},
+		{name: "reversed", patterns: []string{"9000-8000"}, err: true},
+		{name: "out of range", patterns: []string{"65536"}, err: true},
+		{name: "not a number", patterns: []string{"http"}, err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := PortMatcher(tt.patterns)
+			if (err != nil) != tt.err {
+				t.Fatalf("got error %v, want error %v", err, tt.err)
+			}
+			for _, port := range tt.match {
+				if !m.Match(port) {
+					t.Errorf("port %q is not matched", port)
+				}
+			}
+			for _, port := range tt.mismatch {
+				if m.Match(port) {
+					t.Errorf("port %q is matched", port)
+				}
+			}
+		})
+	}
+}
+
+func TestHostPortMatcher(t *testing.T) {
+	pm, err := PortMatcher([]string{"443"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		host Matcher
+		addr string
+		want bool
+	}{
+		{name: "any host", addr: "example.com:443", want: true},
+		{name: "host", host: DomainMatcher([]string{"example.com"}), addr: "example.com:443", want: true},
+		{name: "host not matched", host: DomainMatcher([]string{"example.com"}), addr: "example.org:443"},
+		{name: "port not matched", addr: "example.com:80"},
+		{name: "no port", addr: "example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HostPortMatcher(tt.host, pm).Match(tt.addr); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package net
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+)
+
+const (
+	// ConnectionAttemptDelay is the delay between the connection attempts, see RFC 8305 section 5.
+	ConnectionAttemptDelay = 250 * time.Millisecond
+)
+
+type DialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// SortAddrs interleaves the IPv6 and IPv4 addresses as RFC 8305 section 4,
+// starting with the family of the first address which is the preferred one.
+// The addresses with domain name host keep their positions relative to the family of the first address.
+func SortAddrs(addrs []string) []string {
+	if len(addrs) <= 1 {
+		return addrs
+	}
+
+	var first, second []string
+	v4 := isIPv4Addr(addrs[0])
+	for _, addr := range addrs {
+		if isIPv4Addr(addr) == v4 {
+			first = append(first, addr)
+		} else {
+			second = append(second, addr)
+		}
+	}
+
+	sorted := make([]string, 0, len(addrs))
+	for i := 0; i < len(first) || i < len(second); i++ {
+		if i < len(first) {
+			sorted = append(sorted, first[i])
+		}
+		if i < len(second) {
+			sorted = append(sorted, second[i])
+		}
+	}
+	return sorted
+}
+
+func isIPv4Addr(addr string) bool {
+	host, _, _ := net.SplitHostPort(addr)
+	ip := net.ParseIP(host)
+	return ip == nil || ip.To4() != nil
+}
+
+// DialParallel dials the addresses in order with the Happy Eyeballs algorithm (RFC 8305):
+// a connection attempt is started every ConnectionAttemptDelay, or immediately when the previous one fails.
+// The first established connection is returned and the others are closed.
+func DialParallel(ctx context.Context, network string, addrs []string, dial DialFunc) (net.Conn, error) {
+	if len(addrs) == 0 {
+		return nil, errors.New("dial: no address")
+	}
+	if len(addrs) == 1 {
+		return dial(ctx, network, addrs[0])
+	}
+
+	type result struct {
+		conn net.Conn
+		err  error
+	}
+	results := make(chan result)
+	done := make(chan struct{})
+	defer close(done)
+
+	// each attempt has its own context which only scopes the dial,
+	// they are all canceled once the connection is established or all the attempts fail.
+	cancels := make([]context.CancelFunc, 0, len(addrs))
+	defer func() {
+		for _, cancel := range cancels {
+			cancel()
+		}
+	}()
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	var firstErr error
+	next, pending := 0, 0
+	for next < len(addrs) || pending > 0 {
+		var timeout <-chan time.Time
+		if next < len(addrs) {
+			timeout = timer.C
+		}
+
+		select {
+		case <-timeout:
+			idx := next
+			actx, cancel := context.WithCancel(ctx)
+			cancels = append(cancels, cancel)
+			next++
+			pending++
+			go func() {
+				conn, err := dial(actx, network, addrs[idx])
+				select {
+				case results <- result{conn: conn, err: err}:
+				case <-done:
+					if conn != nil {
+						conn.Close()
+					}
+				}
+			}()
+			timer.Reset(ConnectionAttemptDelay)
+
+		case r := <-results:
+			pending--
+			if r.err == nil {
+				return r.conn, nil
+			}
+			if firstErr == nil {
+				firstErr = r.err
+			}
+			// the next attempt starts immediately.
+			if next < len(addrs) {
+				timer.Reset(0)
+			}
+
+		case <-ctx.Done():
+			if firstErr == nil {
+				firstErr = ctx.Err()
+			}
+			return nil, firstErr
+		}
+	}
+	return nil, firstErr
+}
+
+// IPNetwork returns the IP network to resolve the host of the TCP network,
+// or an empty string for the other networks.
+func IPNetwork(network string) string {
+	switch network {
+	case "tcp":
+		return "ip"
+	case "tcp4":
+		return "ip4"
+	case "tcp6":
+		return "ip6"
+	default:
+		return ""
+	}
+}
+
+// DialHappyEyeballs dials the TCP address whose host is resolved by the system resolver
+// to all its addresses, which are dialed by DialParallel.
+// The address of other networks or with IP host is dialed directly.
+func DialHappyEyeballs(ctx context.Context, network, addr string, dial DialFunc) (net.Conn, error) {
+	ipNetwork := IPNetwork(network)
+	if ipNetwork == "" {
+		return dial(ctx, network, addr)
+	}
+
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || host == "" || net.ParseIP(host) != nil {
+		return dial(ctx, network, addr)
+	}
+
+	ips, err := net.DefaultResolver.LookupIP(ctx, ipNetwork, host)
+	if err != nil {
+		return nil, err
+	}
+	addrs := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		addrs = append(addrs, net.JoinHostPort(ip.String(), port))
+	}
+	return DialParallel(ctx, network, SortAddrs(addrs), dial)
+}
@@ -0,0 +1,283 @@
+package net
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSortAddrs(t *testing.T) {
+	tests := []struct {
+		name  string
+		addrs []string
+		want  []string
+	}{
+		{name: "empty"},
+		{name: "single", addrs: []string{"1.1.1.1:80"}, want: []string{"1.1.1.1:80"}},
+		{
+			name:  "ipv4 first",
+			addrs: []string{"1.1.1.1:80", "1.1.1.2:80", "[::1]:80", "[::2]:80"},
+			want:  []string{"1.1.1.1:80", "[::1]:80", "1.1.1.2:80", "[::2]:80"},
+		},
+		{
+			name:  "ipv6 first",
+			addrs: []string{"[::1]:80", "1.1.1.1:80", "1.1.1.2:80", "1.1.1.3:80"},
+			want:  []string{"[::1]:80", "1.1.1.1:80", "1.1.1.2:80", "1.1.1.3:80"},
+		},
+		{
+			name:  "single family",
+			addrs: []string{"[::1]:80", "[::2]:80"},
+			want:  []string{"[::1]:80", "[::2]:80"},
+		},
+		{
+			name:  "domain name",
+			addrs: []string{"example.com:80", "[::1]:80", "1.1.1.1:80"},
+			want:  []string{"example.com:80", "[::1]:80", "1.1.1.1:80"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SortAddrs(tt.addrs); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIPNetwork(t *testing.T) {
+	tests := []struct {
+		network string
+		want    string
+	}{
+		{"tcp", "ip"},
+		{"tcp4", "ip4"},
+		{"tcp6", "ip6"},
+		{"udp", ""},
+		{"unix", ""},
+	}
+	for _, tt := range tests {
+		if got := IPNetwork(tt.network); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.network, got, tt.want)
+		}
+	}
+}
+
+// fakeAttempt is the behaviour of the dial attempt to an address.
+type fakeAttempt struct {
+	delay time.Duration
+	err   error
+}
+
+type fakeConn struct {
+	net.Conn
+	addr   string
+	closed chan struct{}
+}
+
+func (c *fakeConn) Close() error {
+	close(c.closed)
+	return nil
+}
+
+type fakeDialer struct {
+	attempts map[string]fakeAttempt
+	mu       sync.Mutex
+	dialed   []string
+	ctxs     []context.Context
+	conns    []*fakeConn
+}
+
+func (d *fakeDialer) dial(ctx context.Context, network, addr string) (net.Conn, error) {
+	d.mu.Lock()
+	d.dialed = append(d.dialed, addr)
+	d.ctxs = append(d.ctxs, ctx)
+	d.mu.Unlock()
+
+	a := d.attempts[addr]
+	select {
+	case <-time.After(a.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if a.err != nil {
+		return nil, a.err
+	}
+
+	conn := &fakeConn{addr: addr, closed: make(chan struct{})}
+	d.mu.Lock()
+	d.conns = append(d.conns, conn)
+	d.mu.Unlock()
+	return conn, nil
+}
+
+func TestDialParallel(t *testing.T) {
+	errRefused := errors.New("connection refused")
+	delay := ConnectionAttemptDelay
+
+	tests := []struct {
+		name     string
+		addrs    []string
+		attempts map[string]fakeAttempt
+		want     string // the address of the returned connection.
+		err      error
+		dialed   []string
+		elapsed  time.Duration // the upper bound of the dial time.
+	}{
+		{
+			name:  "no address",
+			addrs: nil,
+			err:   errors.New("dial: no address"),
+		},
+		{
+			name:    "first succeeds",
+			addrs:   []string{"a", "b"},
+			want:    "a",
+			dialed:  []string{"a"},
+			elapsed: delay / 2,
+		},
+		{
+			name:     "failure starts the next attempt immediately",
+			addrs:    []string{"a", "b", "c"},
+			attempts: map[string]fakeAttempt{"a": {err: errRefused}, "b": {err: errRefused}},
+			want:     "c",
+			dialed:   []string{"a", "b", "c"},
+			elapsed:  delay / 2,
+		},
+		{
+			name:     "slow first attempt",
+			addrs:    []string{"a", "b"},
+			attempts: map[string]fakeAttempt{"a": {delay: 10 * delay}},
+			want:     "b",
+			dialed:   []string{"a", "b"},
+			elapsed:  2 * delay,
+		},
+		{
+			name:     "first wins after the next attempt starts",
+			addrs:    []string{"a", "b"},
+			attempts: map[string]fakeAttempt{"a": {delay: delay + delay/2}, "b": {delay: 10 * delay}},
+			want:     "a",
+			dialed:   []string{"a", "b"},
+			elapsed:  3 * delay,
+		},
+		{
+			name:     "all fail",
+			addrs:    []string{"a", "b"},
+			attempts: map[string]fakeAttempt{"a": {err: errRefused}, "b": {err: errors.New("timeout")}},
+			err:      errRefused,
+			dialed:   []string{"a", "b"},
+			elapsed:  delay / 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &fakeDialer{attempts: tt.attempts}
+			start := time.Now()
+			conn, err := DialParallel(context.Background(), "tcp", tt.addrs, d.dial)
+			elapsed := time.Since(start)
+
+			if tt.err != nil {
+				if err == nil || err.Error() != tt.err.Error() {
+					t.Fatalf("got error %v, want %v", err, tt.err)
+				}
+			} else {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := conn.(*fakeConn).addr; got != tt.want {
+					t.Errorf("got connection to %s, want %s", got, tt.want)
+				}
+			}
+			if tt.elapsed > 0 && elapsed > tt.elapsed {
+				t.Errorf("dial took %v, want at most %v", elapsed, tt.elapsed)
+			}
+
+			d.mu.Lock()
+			dialed, ctxs := d.dialed, d.ctxs
+			d.mu.Unlock()
+			if !reflect.DeepEqual(dialed, tt.dialed) {
+				t.Errorf("dialed %v, want %v", dialed, tt.dialed)
+			}
+			// the contexts of all the attempts including the established one are canceled.
+			for i, ctx := range ctxs {
+				if len(tt.addrs) > 1 && ctx.Err() == nil {
+					t.Errorf("the context of attempt %d is not canceled", i)
+				}
+			}
+		})
+	}
+}
+
+func TestDialParallelClosesLosers(t *testing.T) {
+	d := &fakeDialer{
+		attempts: map[string]fakeAttempt{
+			"a": {delay: 10 * ConnectionAttemptDelay},
+			"b": {delay: 20 * time.Millisecond},
+		},
+	}
+	conn, err := DialParallel(context.Background(), "tcp", []string{"a", "b"}, d.dial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := conn.(*fakeConn).addr; got != "b" {
+		t.Fatalf("got connection to %s, want b", got)
+	}
+
+	// the attempt to a is canceled by its context.
+	time.Sleep(2 * ConnectionAttemptDelay)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.conns) != 1 {
+		t.Errorf("got %d connections, want 1", len(d.conns))
+	}
+	select {
+	case <-conn.(*fakeConn).closed:
+		t.Error("the established connection is closed")
+	default:
+	}
+}
+
+func TestDialParallelContextCanceled(t *testing.T) {
+	d := &fakeDialer{attempts: map[string]fakeAttempt{"a": {delay: time.Hour}}}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := DialParallel(ctx, "tcp", []string{"a", "b"}, func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if addr == "b" {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return d.dial(ctx, network, addr)
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got error %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestDialHappyEyeballsDirect(t *testing.T) {
+	tests := []struct {
+		name    string
+		network string
+		addr    string
+	}{
+		{name: "udp", network: "udp", addr: "example.com:53"},
+		{name: "ip host", network: "tcp", addr: "127.0.0.1:80"},
+		{name: "ipv6 host", network: "tcp6", addr: "[::1]:80"},
+		{name: "no port", network: "tcp", addr: "example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var dialed []string
+			DialHappyEyeballs(context.Background(), tt.network, tt.addr, func(ctx context.Context, network, addr string) (net.Conn, error) {
+				dialed = append(dialed, network+"/"+addr)
+				return nil, errors.New("refused")
+			})
+			if want := []string{tt.network + "/" + tt.addr}; !reflect.DeepEqual(dialed, want) {
+				t.Errorf("dialed %v, want %v", dialed, want)
+			}
+		})
+	}
+}
@@ -1,12 +1,15 @@
 package udp
 
 import (
+	"context"
 	"net"
 
 	"github.com/go-gost/core/bypass"
 	"github.com/go-gost/core/common/bufpool"
 	"github.com/go-gost/core/logger"
 	"github.com/go-gost/core/sniff/stun"
+	xbypass "github.com/go-gost/x/bypass"
+	xrecorder "github.com/go-gost/x/recorder"
 )
 
 type Relay struct {
@@ -15,8 +18,11 @@ type Relay struct {
 	stun stun.Spoof
 
 	bypass     bypass.Bypass
+	clientIP   string
+	user       string
 	bufferSize int
 	logger     logger.Logger
+	ctx        context.Context
 }
 
 func NewRelay(pc1, pc2 net.PacketConn) *Relay {
@@ -31,6 +37,20 @@ func (r *Relay) WithBypass(bp bypass.Bypass) *Relay {
 	return r
 }
 
+// WithClient sets the client information used by the bypass.
+func (r *Relay) WithClient(clientIP string, user string) *Relay {
+	r.clientIP = clientIP
+	r.user = user
+	return r
+}
+
+// WithContext sets the context of the client connection,
+// the destination address of the packets is recorded by the recorders in ctx once for each address.
+func (r *Relay) WithContext(ctx context.Context) *Relay {
+	r.ctx = ctx
+	return r
+}
+
 func (r *Relay) WithStun(stun stun.Spoof) *Relay {
 	r.stun = stun
 	return r
@@ -54,6 +74,8 @@ func (r *Relay) Run() (err error) {
 	errc := make(chan error, 2)
 
 	go func() {
+		// the destination addresses recorded.
+		dsts := make(map[string]bool)
 		for {
 			err := func() error {
 				b := bufpool.Get(bufSize)
@@ -64,13 +86,18 @@ func (r *Relay) Run() (err error) {
 					return err
 				}
 
-				if r.bypass != nil && r.bypass.Contains(raddr.String()) {
+				if r.bypass != nil && r.isBypassed(raddr) {
 					if r.logger != nil {
 						r.logger.Warn("bypass: ", raddr)
 					}
 					return nil
 				}
 
+				if r.ctx != nil && !dsts[raddr.String()] {
+					dsts[raddr.String()] = true
+					xrecorder.Record(r.ctx, xrecorder.RecorderServiceHandlerDstAddress, []byte(raddr.String()))
+				}
+
 				// STUN
 
 				var sss *stun.Spoof
@@ -114,7 +141,7 @@ func (r *Relay) Run() (err error) {
 					return err
 				}
 
-				if r.bypass != nil && r.bypass.Contains(raddr.String()) {
+				if r.bypass != nil && r.isBypassed(raddr) {
 					if r.logger != nil {
 						r.logger.Warn("bypass: ", raddr)
 					}
@@ -143,3 +170,12 @@ func (r *Relay) Run() (err error) {
 
 	return <-errc
 }
+
+func (r *Relay) isBypassed(addr net.Addr) bool {
+	return xbypass.Contains(r.bypass, &xbypass.Request{
+		Network:  "udp",
+		Addr:     addr.String(),
+		ClientIP: r.clientIP,
+		User:     r.user,
+	})
+}
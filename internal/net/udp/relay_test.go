@@ -0,0 +1,99 @@
+package udp
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-gost/core/recorder"
+	xrecorder "github.com/go-gost/x/recorder"
+)
+
+type packet struct {
+	data []byte
+	addr net.Addr
+}
+
+// clientConn is the packet conn of the client reading the packets to the destinations.
+type clientConn struct {
+	net.PacketConn
+	packets chan packet
+}
+
+func (c *clientConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	p, ok := <-c.packets
+	if !ok {
+		return 0, nil, net.ErrClosed
+	}
+	return copy(b, p.data), p.addr, nil
+}
+
+func (c *clientConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	return len(b), nil
+}
+
+type dstRecorder struct {
+	mu   sync.Mutex
+	dsts []string
+}
+
+func (r *dstRecorder) Record(ctx context.Context, b []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.dsts = append(r.dsts, string(b))
+	return nil
+}
+
+func TestRelayRecordDst(t *testing.T) {
+	listen := func() net.PacketConn {
+		pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { pc.Close() })
+		return pc
+	}
+	dst1, dst2, pc := listen(), listen(), listen()
+
+	rec := &dstRecorder{}
+	ctx := xrecorder.ContextWithRecorders(context.Background(), []recorder.RecorderObject{
+		{Recorder: rec, Record: xrecorder.RecorderServiceHandlerDstAddress},
+	}, nil)
+
+	client := &clientConn{packets: make(chan packet, 3)}
+	for _, addr := range []net.Addr{dst1.LocalAddr(), dst2.LocalAddr(), dst1.LocalAddr()} {
+		client.packets <- packet{data: []byte("ping"), addr: addr}
+	}
+	close(client.packets)
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- NewRelay(client, pc).WithContext(ctx).Run()
+	}()
+
+	// the packets are relayed to the destinations.
+	b := make([]byte, 16)
+	for _, dst := range []net.PacketConn{dst1, dst2, dst1} {
+		dst.SetReadDeadline(time.Now().Add(time.Second))
+		if _, _, err := dst.ReadFrom(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	select {
+	case <-errc:
+	case <-time.After(time.Second):
+		t.Fatal("the relay is not finished")
+	}
+
+	// each destination is recorded once.
+	want := []string{dst1.LocalAddr().String(), dst2.LocalAddr().String()}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if !reflect.DeepEqual(rec.dsts, want) {
+		t.Errorf("got %q recorded, want %q", rec.dsts, want)
+	}
+}
@@ -1,85 +1,276 @@
 package mux
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"net"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/hashicorp/yamux"
 	smux "github.com/xtaci/smux"
 )
 
-type Session struct {
-	conn    net.Conn
-	session *smux.Session
+const (
+	ProtocolSmux  = "smux"
+	ProtocolYamux = "yamux"
+)
+
+var (
+	ErrTooManyStreams = errors.New("mux: too many streams")
+)
+
+// Config is the configuration of the multiplexing session.
+// The zero value of each field means the default of the protocol.
+type Config struct {
+	// Protocol is the multiplexing protocol, smux (default) or yamux.
+	Protocol string
+	// Version is the smux protocol version, 1 or 2.
+	Version int
+
+	KeepAliveDisabled bool
+	KeepAliveInterval time.Duration
+	// KeepAliveTimeout is the time the smux session is closed after if no data is received.
+	// yamux has no such timeout, it is used as the ConnectionWriteTimeout of yamux instead,
+	// the time the session is closed after if a write (including the keepalive ping) is blocked.
+	KeepAliveTimeout time.Duration
+
+	// MaxFrameSize is the maximum frame size of smux.
+	MaxFrameSize int
+	// MaxReceiveBuffer is the receive buffer of the smux session.
+	MaxReceiveBuffer int
+	// MaxStreamBuffer is the window size of each stream, for smux version 2 and yamux.
+	MaxStreamBuffer int
+
+	// MaxStreams is the maximum number of concurrent streams in a session, 0 means unlimited.
+	MaxStreams int
 }
 
-func ClientSession(conn net.Conn) (*Session, error) {
-	s, err := smux.Client(conn, smux.DefaultConfig())
-	if err != nil {
-		return nil, err
+func (c *Config) smuxConfig() (*smux.Config, error) {
+	cfg := smux.DefaultConfig()
+	if c.Version > 0 {
+		cfg.Version = c.Version
 	}
-	return &Session{
-		conn:    conn,
-		session: s,
-	}, nil
+	cfg.KeepAliveDisabled = c.KeepAliveDisabled
+	if c.KeepAliveInterval > 0 {
+		cfg.KeepAliveInterval = c.KeepAliveInterval
+	}
+	if c.KeepAliveTimeout > 0 {
+		cfg.KeepAliveTimeout = c.KeepAliveTimeout
+	}
+	if c.MaxFrameSize > 0 {
+		cfg.MaxFrameSize = c.MaxFrameSize
+	}
+	if c.MaxReceiveBuffer > 0 {
+		cfg.MaxReceiveBuffer = c.MaxReceiveBuffer
+	}
+	if c.MaxStreamBuffer > 0 {
+		cfg.MaxStreamBuffer = c.MaxStreamBuffer
+	}
+	return cfg, smux.VerifyConfig(cfg)
 }
 
-func ServerSession(conn net.Conn) (*Session, error) {
-	s, err := smux.Server(conn, smux.DefaultConfig())
-	if err != nil {
-		return nil, err
+func (c *Config) yamuxConfig() (*yamux.Config, error) {
+	cfg := yamux.DefaultConfig()
+	cfg.LogOutput = io.Discard
+	cfg.EnableKeepAlive = !c.KeepAliveDisabled
+	if c.KeepAliveInterval > 0 {
+		cfg.KeepAliveInterval = c.KeepAliveInterval
+	}
+	// yamux detects the dead connection by the write timeout of the keepalive ping.
+	if c.KeepAliveTimeout > 0 {
+		cfg.ConnectionWriteTimeout = c.KeepAliveTimeout
+	}
+	if c.MaxStreamBuffer > 0 {
+		cfg.MaxStreamWindowSize = uint32(c.MaxStreamBuffer)
 	}
+	return cfg, yamux.VerifyConfig(cfg)
+}
+
+type session interface {
+	open() (net.Conn, error)
+	accept() (net.Conn, error)
+	Close() error
+	IsClosed() bool
+	NumStreams() int
+}
+
+type smuxSession struct {
+	*smux.Session
+}
+
+func (s smuxSession) open() (net.Conn, error) {
+	return s.OpenStream()
+}
+
+func (s smuxSession) accept() (net.Conn, error) {
+	return s.AcceptStream()
+}
+
+type yamuxSession struct {
+	*yamux.Session
+}
+
+func (s yamuxSession) open() (net.Conn, error) {
+	return s.Open()
+}
+
+func (s yamuxSession) accept() (net.Conn, error) {
+	return s.Accept()
+}
+
+type Session struct {
+	conn       net.Conn
+	session    session
+	maxStreams int
+	// streams is the number of the streams reserved by GetConn and Accept.
+	streams atomic.Int64
+}
+
+// ClientSession creates a client side session on conn, the default config is used if cfg is nil.
+func ClientSession(conn net.Conn, cfg *Config) (*Session, error) {
+	return newSession(conn, cfg, true)
+}
+
+// ServerSession creates a server side session on conn, the default config is used if cfg is nil.
+func ServerSession(conn net.Conn, cfg *Config) (*Session, error) {
+	return newSession(conn, cfg, false)
+}
+
+func newSession(conn net.Conn, cfg *Config, client bool) (*Session, error) {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+
+	var s session
+	switch cfg.Protocol {
+	case "", ProtocolSmux:
+		config, err := cfg.smuxConfig()
+		if err != nil {
+			return nil, err
+		}
+		var ss *smux.Session
+		if client {
+			ss, err = smux.Client(conn, config)
+		} else {
+			ss, err = smux.Server(conn, config)
+		}
+		if err != nil {
+			return nil, err
+		}
+		s = smuxSession{ss}
+	case ProtocolYamux:
+		config, err := cfg.yamuxConfig()
+		if err != nil {
+			return nil, err
+		}
+		var ys *yamux.Session
+		if client {
+			ys, err = yamux.Client(conn, config)
+		} else {
+			ys, err = yamux.Server(conn, config)
+		}
+		if err != nil {
+			return nil, err
+		}
+		s = yamuxSession{ys}
+	default:
+		return nil, fmt.Errorf("mux: unknown protocol %s", cfg.Protocol)
+	}
+
 	return &Session{
-		conn:    conn,
-		session: s,
+		conn:       conn,
+		session:    s,
+		maxStreams: cfg.MaxStreams,
 	}, nil
 }
 
+// GetConn opens a new stream, the slot of the stream is reserved before opening
+// so that the concurrent callers do not exceed the limit.
 func (session *Session) GetConn() (net.Conn, error) {
-	stream, err := session.session.OpenStream()
+	if !session.reserve() {
+		return nil, ErrTooManyStreams
+	}
+	stream, err := session.session.open()
 	if err != nil {
+		session.release()
 		return nil, err
 	}
-	return &streamConn{Conn: session.conn, stream: stream}, nil
+	return session.wrapStream(stream), nil
 }
 
+// Accept waits for the next stream, the streams exceeding the limit are rejected.
 func (session *Session) Accept() (net.Conn, error) {
-	stream, err := session.session.AcceptStream()
-	if err != nil {
-		return nil, err
+	for {
+		stream, err := session.session.accept()
+		if err != nil {
+			return nil, err
+		}
+		if !session.reserve() {
+			stream.Close()
+			continue
+		}
+		return session.wrapStream(stream), nil
 	}
-	return &streamConn{Conn: session.conn, stream: stream}, nil
 }
 
-func (session *Session) Close() error {
-	if session.session == nil {
-		return nil
+// reserve takes a slot for a new stream, it returns false if the limit is reached.
+func (session *Session) reserve() bool {
+	if session.maxStreams <= 0 {
+		return true
 	}
-	return session.session.Close()
+	if session.streams.Add(1) > int64(session.maxStreams) {
+		session.streams.Add(-1)
+		return false
+	}
+	return true
 }
 
-func (session *Session) IsClosed() bool {
-	if session.session == nil {
-		return true
+func (session *Session) release() {
+	if session.maxStreams > 0 {
+		session.streams.Add(-1)
 	}
-	return session.session.IsClosed()
 }
 
-func (session *Session) NumStreams() int {
-	return session.session.NumStreams()
+func (session *Session) wrapStream(stream net.Conn) net.Conn {
+	if session.maxStreams <= 0 {
+		return stream
+	}
+	return &streamConn{
+		Conn:    stream,
+		release: session.release,
+	}
 }
 
+// streamConn releases the slot of the stream when it is closed.
 type streamConn struct {
 	net.Conn
-	stream *smux.Stream
+	release func()
+	once    sync.Once
+}
+
+func (c *streamConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(c.release)
+	return err
 }
 
-func (c *streamConn) Read(b []byte) (n int, err error) {
-	return c.stream.Read(b)
+func (session *Session) Close() error {
+	if session.session == nil {
+		return nil
+	}
+	return session.session.Close()
 }
 
-func (c *streamConn) Write(b []byte) (n int, err error) {
-	return c.stream.Write(b)
+func (session *Session) IsClosed() bool {
+	if session.session == nil {
+		return true
+	}
+	return session.session.IsClosed()
 }
 
-func (c *streamConn) Close() error {
-	return c.stream.Close()
+func (session *Session) NumStreams() int {
+	return session.session.NumStreams()
 }
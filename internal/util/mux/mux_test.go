@@ -0,0 +1,91 @@
+package mux
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"testing"
+)
+
+func sessionPair(t *testing.T, cfg *Config) (client, server *Session) {
+	t.Helper()
+
+	c1, c2 := net.Pipe()
+	client, err := ClientSession(c1, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, err = ServerSession(c2, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+	return
+}
+
+func TestSessionMaxStreams(t *testing.T) {
+	const maxStreams = 4
+
+	for _, protocol := range []string{ProtocolSmux, ProtocolYamux} {
+		t.Run(protocol, func(t *testing.T) {
+			client, server := sessionPair(t, &Config{
+				Protocol:   protocol,
+				MaxStreams: maxStreams,
+			})
+			go func() {
+				for {
+					conn, err := server.Accept()
+					if err != nil {
+						return
+					}
+					defer conn.Close()
+				}
+			}()
+
+			var mu sync.Mutex
+			var conns []net.Conn
+			var wg sync.WaitGroup
+			for i := 0; i < 32; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+
+					conn, err := client.GetConn()
+					if err != nil {
+						if !errors.Is(err, ErrTooManyStreams) {
+							t.Error(err)
+						}
+						return
+					}
+					mu.Lock()
+					conns = append(conns, conn)
+					mu.Unlock()
+				}()
+			}
+			wg.Wait()
+
+			if len(conns) != maxStreams {
+				t.Fatalf("got %d streams, want %d", len(conns), maxStreams)
+			}
+
+			// the slot is released when the stream is closed.
+			conns[0].Close()
+			conns[0].Close()
+			conn, err := client.GetConn()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+			if _, err := client.GetConn(); !errors.Is(err, ErrTooManyStreams) {
+				t.Errorf("got error %v, want %v", err, ErrTooManyStreams)
+			}
+
+			for _, c := range conns[1:] {
+				c.Close()
+			}
+		})
+	}
+}
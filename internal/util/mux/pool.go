@@ -0,0 +1,220 @@
+package mux
+
+import (
+	"runtime"
+	"sync"
+	"time"
+	"weak"
+)
+
+const (
+	// defaultReapInterval is the interval of evicting the closed sessions if IdleTimeout is not set.
+	defaultReapInterval = 30 * time.Second
+	minReapInterval     = 100 * time.Millisecond
+)
+
+// PoolSession is a multiplexing session managed by Pool.
+type PoolSession interface {
+	IsClosed() bool
+	NumStreams() int
+	Close() error
+}
+
+// PoolOptions are the options of the session pool.
+type PoolOptions struct {
+	// MinSessions is the number of sessions kept for each address, default is 1.
+	MinSessions int
+	// MaxSessions is the maximum number of sessions for each address, default is 1.
+	MaxSessions int
+	// MaxStreams is the maximum number of streams in a session, 0 means unlimited.
+	MaxStreams int
+	// IdleTimeout is the duration after which the session without streams is closed,
+	// the sessions within MinSessions are kept. 0 means no timeout.
+	IdleTimeout time.Duration
+}
+
+type poolEntry[T PoolSession] struct {
+	session   T
+	idleSince time.Time
+}
+
+// Pool is a pool of multiplexing sessions grouped by the address.
+// A new stream is opened on the session with the least streams,
+// and a new session is created if all the sessions are busy and the limit is not reached.
+// The closed and idle sessions are evicted when the pool is accessed and periodically
+// by a reaper, which exits when the pool is garbage collected.
+type Pool[T PoolSession] struct {
+	options  PoolOptions
+	sessions map[string][]*poolEntry[T]
+	mu       sync.Mutex
+}
+
+func NewPool[T PoolSession](opts PoolOptions) *Pool[T] {
+	if opts.MaxSessions <= 0 {
+		opts.MaxSessions = 1
+	}
+	if opts.MinSessions <= 0 {
+		opts.MinSessions = 1
+	}
+	if opts.MinSessions > opts.MaxSessions {
+		opts.MinSessions = opts.MaxSessions
+	}
+
+	p := &Pool[T]{
+		options:  opts,
+		sessions: make(map[string][]*poolEntry[T]),
+	}
+
+	interval := defaultReapInterval
+	if opts.IdleTimeout > 0 {
+		interval = max(opts.IdleTimeout/2, minReapInterval)
+	}
+	// the reaper holds the pool weakly so that the pool can be collected.
+	done := make(chan struct{})
+	go reap(weak.Make(p), interval, done)
+	runtime.AddCleanup(p, func(done chan struct{}) { close(done) }, done)
+
+	return p
+}
+
+func reap[T PoolSession](wp weak.Pointer[Pool[T]], interval time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			p := wp.Value()
+			if p == nil {
+				return
+			}
+			p.reap()
+		case <-done:
+			return
+		}
+	}
+}
+
+// reap evicts the closed and idle sessions of all the addresses.
+func (p *Pool[T]) reap() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for addr := range p.sessions {
+		p.evict(addr)
+	}
+}
+
+// Get returns a session for addr, ok is false if a new session is required.
+// ErrTooManyStreams is returned if all the sessions are full and no more session can be created.
+func (p *Pool[T]) Get(addr string) (session T, ok bool, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	entries := p.evict(addr)
+
+	var best *poolEntry[T]
+	var bestStreams int
+	for _, e := range entries {
+		n := e.session.NumStreams()
+		if p.options.MaxStreams > 0 && n >= p.options.MaxStreams {
+			continue
+		}
+		if best == nil || n < bestStreams {
+			best, bestStreams = e, n
+		}
+	}
+
+	if len(entries) < p.options.MaxSessions {
+		// grow to the minimum, or spread the load if all sessions are busy.
+		if best == nil || len(entries) < p.options.MinSessions || bestStreams > 0 {
+			return
+		}
+	}
+	if best == nil {
+		err = ErrTooManyStreams
+		return
+	}
+
+	return best.session, true, nil
+}
+
+// Add adds the new session for addr to the pool.
+func (p *Pool[T]) Add(addr string, session T) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.sessions[addr] = append(p.sessions[addr], &poolEntry[T]{session: session})
+}
+
+// Find returns the session for addr which matches f.
+func (p *Pool[T]) Find(addr string, f func(T) bool) (session T, ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, e := range p.sessions[addr] {
+		if f(e.session) {
+			return e.session, true
+		}
+	}
+	return
+}
+
+// Remove closes the session and removes it from the pool.
+func (p *Pool[T]) Remove(addr string, session T) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	entries := p.sessions[addr]
+	for i, e := range entries {
+		if any(e.session) == any(session) {
+			p.sessions[addr] = append(entries[:i:i], entries[i+1:]...)
+			break
+		}
+	}
+	if len(p.sessions[addr]) == 0 {
+		delete(p.sessions, addr)
+	}
+	session.Close()
+}
+
+// evict removes the closed sessions and the idle sessions exceeding MinSessions.
+func (p *Pool[T]) evict(addr string) []*poolEntry[T] {
+	now := time.Now()
+
+	var entries []*poolEntry[T]
+	for _, e := range p.sessions[addr] {
+		if e.session.IsClosed() {
+			e.session.Close()
+			continue
+		}
+		if e.session.NumStreams() > 0 {
+			e.idleSince = time.Time{}
+		} else if e.idleSince.IsZero() {
+			e.idleSince = now
+		}
+		entries = append(entries, e)
+	}
+
+	if p.options.IdleTimeout > 0 {
+		alive := entries[:0]
+		n := len(entries)
+		for _, e := range entries {
+			if n > p.options.MinSessions && !e.idleSince.IsZero() &&
+				now.Sub(e.idleSince) >= p.options.IdleTimeout {
+				e.session.Close()
+				n--
+				continue
+			}
+			alive = append(alive, e)
+		}
+		entries = alive
+	}
+
+	if len(entries) == 0 {
+		delete(p.sessions, addr)
+	} else {
+		p.sessions[addr] = entries
+	}
+	return entries
+}
@@ -0,0 +1,134 @@
+package mux
+
+import (
+	"errors"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type fakeSession struct {
+	streams atomic.Int32
+	closed  atomic.Bool
+}
+
+func (s *fakeSession) IsClosed() bool  { return s.closed.Load() }
+func (s *fakeSession) NumStreams() int { return int(s.streams.Load()) }
+func (s *fakeSession) Close() error {
+	s.closed.Store(true)
+	return nil
+}
+
+func TestPoolGet(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    PoolOptions
+		streams []int32 // the streams of the existing sessions.
+		ok      bool
+		index   int // the index of the returned session if ok.
+		err     error
+	}{
+		{name: "empty", opts: PoolOptions{}, ok: false},
+		{name: "single", opts: PoolOptions{}, streams: []int32{3}, ok: true, index: 0},
+		{name: "grow to min", opts: PoolOptions{MinSessions: 2, MaxSessions: 2}, streams: []int32{0}, ok: false},
+		{name: "spread busy", opts: PoolOptions{MaxSessions: 3}, streams: []int32{1, 2}, ok: false},
+		{name: "idle session", opts: PoolOptions{MaxSessions: 3}, streams: []int32{1, 0}, ok: true, index: 1},
+		{name: "least streams", opts: PoolOptions{MaxSessions: 2}, streams: []int32{5, 2}, ok: true, index: 1},
+		{name: "skip full", opts: PoolOptions{MaxSessions: 2, MaxStreams: 2}, streams: []int32{1, 2}, ok: true, index: 0},
+		{name: "all full", opts: PoolOptions{MaxSessions: 2, MaxStreams: 2}, streams: []int32{2, 2}, err: ErrTooManyStreams},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewPool[*fakeSession](tt.opts)
+			var sessions []*fakeSession
+			for _, n := range tt.streams {
+				s := &fakeSession{}
+				s.streams.Store(n)
+				sessions = append(sessions, s)
+				p.Add("addr", s)
+			}
+
+			s, ok, err := p.Get("addr")
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got error %v, want %v", err, tt.err)
+			}
+			if ok != tt.ok {
+				t.Fatalf("got ok %v, want %v", ok, tt.ok)
+			}
+			if ok && s != sessions[tt.index] {
+				t.Errorf("got a wrong session, want session %d", tt.index)
+			}
+		})
+	}
+}
+
+func TestPoolEvict(t *testing.T) {
+	p := NewPool[*fakeSession](PoolOptions{MaxSessions: 2})
+	closed := &fakeSession{}
+	alive := &fakeSession{}
+	p.Add("addr", closed)
+	p.Add("addr", alive)
+	closed.Close()
+
+	s, ok, _ := p.Get("addr")
+	if !ok || s != alive {
+		t.Fatal("the closed session should be evicted")
+	}
+}
+
+func TestPoolReapIdle(t *testing.T) {
+	p := NewPool[*fakeSession](PoolOptions{
+		MinSessions: 1,
+		MaxSessions: 3,
+		IdleTimeout: 50 * time.Millisecond,
+	})
+	busy := &fakeSession{}
+	busy.streams.Store(1)
+	idle1 := &fakeSession{}
+	idle2 := &fakeSession{}
+	dead := &fakeSession{}
+	dead.Close()
+	p.Add("a", busy)
+	p.Add("a", idle1)
+	p.Add("b", idle2)
+	p.Add("c", dead)
+
+	// the pool is not accessed, the sessions are reaped in the background.
+	deadline := time.Now().Add(3 * time.Second)
+	for !idle1.IsClosed() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !idle1.IsClosed() {
+		t.Fatal("the idle session exceeding MinSessions should be closed")
+	}
+	if busy.IsClosed() {
+		t.Error("the busy session should not be closed")
+	}
+	if idle2.IsClosed() {
+		t.Error("the idle session within MinSessions should not be closed")
+	}
+
+	p.mu.Lock()
+	_, ok := p.sessions["c"]
+	p.mu.Unlock()
+	if ok {
+		t.Error("the closed session should be evicted")
+	}
+}
+
+func TestPoolReaperExits(t *testing.T) {
+	n := runtime.NumGoroutine()
+	for i := 0; i < 10; i++ {
+		NewPool[*fakeSession](PoolOptions{IdleTimeout: time.Millisecond})
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > n && time.Now().Before(deadline) {
+		runtime.GC()
+		time.Sleep(10 * time.Millisecond)
+	}
+	if g := runtime.NumGoroutine(); g > n {
+		t.Errorf("%d reapers are still running after the pools are collected", g-n)
+	}
+}
@@ -17,8 +17,8 @@ import (
 	"github.com/go-gost/core/common/bufpool"
 	"github.com/go-gost/core/logger"
 	xnet "github.com/go-gost/x/internal/net"
-	"github.com/lucas-clemente/quic-go"
-	"github.com/lucas-clemente/quic-go/http3"
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
 	"github.com/rs/xid"
 )
 
@@ -123,7 +123,7 @@ func NewHTTP3Server(addr string, quicConfig *quic.Config, opts ...ServerOption)
 		http3Server: &http3.Server{
 			Addr:       addr,
 			TLSConfig:  options.tlsConfig,
-			QuicConfig: quicConfig,
+			QUICConfig: quicConfig,
 		},
 		cqueue:  make(chan net.Conn, options.backlog),
 		closed:  make(chan struct{}),
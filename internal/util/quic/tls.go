@@ -0,0 +1,26 @@
+package quic
+
+import (
+	"crypto/tls"
+	"net"
+)
+
+// ClientTLSConfig returns a copy of cfg with the application protocols,
+// the server name is set to the host of addr if it is not specified.
+func ClientTLSConfig(cfg *tls.Config, addr string, nextProtos ...string) *tls.Config {
+	if cfg == nil {
+		cfg = &tls.Config{}
+	}
+	cfg = cfg.Clone()
+	if len(nextProtos) > 0 {
+		cfg.NextProtos = nextProtos
+	}
+	if cfg.ServerName == "" {
+		if host, _, err := net.SplitHostPort(addr); err == nil {
+			cfg.ServerName = host
+		} else {
+			cfg.ServerName = addr
+		}
+	}
+	return cfg
+}
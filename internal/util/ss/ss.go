@@ -25,6 +25,14 @@ func ShadowCipher(method, password string, key string) (core.Cipher, error) {
 		return nil, nil
 	}
 
+	if Is2022Method(method) {
+		c, err := newCipher2022(method, password)
+		if err != nil {
+			return nil, err
+		}
+		return c, nil
+	}
+
 	c, _ := ss.NewCipher(method, password)
 	if c != nil {
 		return &shadowCipher{cipher: c}, nil
@@ -33,6 +41,29 @@ func ShadowCipher(method, password string, key string) (core.Cipher, error) {
 	return core.PickCipher(method, []byte(key), password)
 }
 
+// ServerCipher is implemented by the ciphers whose server side differs from the client side,
+// such as the Shadowsocks 2022 ciphers.
+type ServerCipher interface {
+	ServerStreamConn(conn net.Conn) net.Conn
+	ServerPacketConn(pc net.PacketConn) net.PacketConn
+}
+
+// ServerStreamConn wraps the server side stream connection with the cipher.
+func ServerStreamConn(cipher core.Cipher, conn net.Conn) net.Conn {
+	if c, ok := cipher.(ServerCipher); ok {
+		return c.ServerStreamConn(conn)
+	}
+	return cipher.StreamConn(conn)
+}
+
+// ServerPacketConn wraps the server side packet connection with the cipher.
+func ServerPacketConn(cipher core.Cipher, pc net.PacketConn) net.PacketConn {
+	if c, ok := cipher.(ServerCipher); ok {
+		return c.ServerPacketConn(pc)
+	}
+	return cipher.PacketConn(pc)
+}
+
 // Due to in/out byte length is inconsistent of the shadowsocks.Conn.Write,
 // we wrap around it to make io.Copy happy.
 type shadowConn struct {
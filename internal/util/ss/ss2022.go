@@ -0,0 +1,351 @@
+package ss
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	xauth "github.com/go-gost/x/auth"
+	"golang.org/x/crypto/chacha20poly1305"
+	"lukechampine.com/blake3"
+)
+
+// The Shadowsocks 2022 Edition (SIP022) methods.
+const (
+	Method2022AES128GCM        = "2022-blake3-aes-128-gcm"
+	Method2022AES256GCM        = "2022-blake3-aes-256-gcm"
+	Method2022ChaCha20Poly1305 = "2022-blake3-chacha20-poly1305"
+)
+
+const (
+	headerTypeClient = 0
+	headerTypeServer = 1
+
+	// maxTimeDiff is the maximum difference between the timestamp in the header and the local time.
+	maxTimeDiff = 30 * time.Second
+	// saltTTL is the duration a salt is remembered for the replay protection.
+	saltTTL = 60 * time.Second
+
+	// maxPaddingLength is the maximum length of the padding in the request header.
+	maxPaddingLength = 900
+	// maxPayloadSize is the maximum size of a payload chunk.
+	maxPayloadSize = 0xffff
+
+	identityHeaderSize = aes.BlockSize
+
+	// usersReloadInterval is the minimum interval of reloading the users
+	// when an unknown identity is received or the users are counted.
+	usersReloadInterval = time.Second
+)
+
+var (
+	ErrBadTimestamp  = errors.New("ss2022: bad timestamp")
+	ErrReplay        = errors.New("ss2022: salt or packet replayed")
+	ErrBadHeaderType = errors.New("ss2022: bad header type")
+	ErrUnknownUser   = errors.New("ss2022: unknown user")
+)
+
+// Is2022Method reports whether the method is one of the SIP022 methods.
+func Is2022Method(method string) bool {
+	switch strings.ToLower(method) {
+	case Method2022AES128GCM, Method2022AES256GCM, Method2022ChaCha20Poly1305:
+		return true
+	}
+	return false
+}
+
+// Cipher2022 is the cipher of the Shadowsocks 2022 Edition.
+// The StreamConn and PacketConn methods create the client side connections,
+// while the ServerStreamConn and ServerPacketConn methods create the server side ones.
+//
+// On the client side the password is a list of base64 encoded keys separated by colon,
+// the keys before the last one are the identity PSKs (iPSK) of the servers,
+// and the last one is the user PSK (uPSK). On the server side the password is the PSK of the server,
+// and the users with their uPSKs can be provided by SetUsers, which requires the AES methods.
+type Cipher2022 struct {
+	method  string
+	keySize int
+	psk     []byte
+	ipsks   [][]byte
+	salts   *saltFilter
+	users   *users2022
+}
+
+func newCipher2022(method, password string) (*Cipher2022, error) {
+	method = strings.ToLower(method)
+
+	c := &Cipher2022{
+		method: method,
+		salts:  newSaltFilter(),
+	}
+	switch method {
+	case Method2022AES128GCM:
+		c.keySize = 16
+	case Method2022AES256GCM, Method2022ChaCha20Poly1305:
+		c.keySize = 32
+	default:
+		return nil, fmt.Errorf("ss2022: unknown method %s", method)
+	}
+
+	var keys [][]byte
+	for _, s := range strings.Split(password, ":") {
+		key, err := c.decodeKey(s)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) > 1 && c.method == Method2022ChaCha20Poly1305 {
+		return nil, fmt.Errorf("ss2022: identity headers are not supported by %s", c.method)
+	}
+	c.psk = keys[len(keys)-1]
+	c.ipsks = keys[:len(keys)-1]
+
+	return c, nil
+}
+
+func (c *Cipher2022) decodeKey(s string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("ss2022: bad key: %v", err)
+	}
+	if len(key) != c.keySize {
+		return nil, fmt.Errorf("ss2022: bad key length %d for %s, the key must be %d bytes encoded in base64",
+			len(key), c.method, c.keySize)
+	}
+	return key, nil
+}
+
+// SetUsers sets the users of the server, the passwords of the users are their uPSKs.
+// The users are ignored by the ChaCha20-Poly1305 method which does not support the identity headers.
+func (c *Cipher2022) SetUsers(lister xauth.Lister) {
+	if lister == nil || c.method == Method2022ChaCha20Poly1305 {
+		return
+	}
+	c.users = &users2022{
+		cipher: c,
+		lister: lister,
+	}
+}
+
+func (c *Cipher2022) saltSize() int {
+	return c.keySize
+}
+
+func (c *Cipher2022) sessionAEAD(key, salt []byte) (cipher.AEAD, error) {
+	material := make([]byte, 0, len(key)+len(salt))
+	material = append(material, key...)
+	material = append(material, salt...)
+
+	subkey := make([]byte, c.keySize)
+	blake3.DeriveKey(subkey, "shadowsocks 2022 session subkey", material)
+	return c.aead(subkey)
+}
+
+func (c *Cipher2022) aead(key []byte) (cipher.AEAD, error) {
+	if c.method == Method2022ChaCha20Poly1305 {
+		return chacha20poly1305.New(key)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// identityHeader returns the identity header of the key for the identity PSK ipsk.
+// The identity header of TCP is encrypted with the identity subkey derived from ipsk and salt,
+// while that of UDP is encrypted with ipsk and XORed with the separate header.
+func (c *Cipher2022) identityHeader(ipsk, key, salt, xor []byte) ([]byte, error) {
+	blockKey := ipsk
+	if salt != nil {
+		blockKey = identitySubkey(ipsk, salt)
+	}
+	block, err := aes.NewCipher(blockKey)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := blake3.Sum256(key)
+	b := make([]byte, identityHeaderSize)
+	copy(b, hash[:identityHeaderSize])
+	if xor != nil {
+		subtle.XORBytes(b, b, xor)
+	}
+	block.Encrypt(b, b)
+	return b, nil
+}
+
+// openIdentityHeader decrypts the identity header b and returns the user.
+func (c *Cipher2022) openIdentityHeader(b, salt, xor []byte) (*user2022, error) {
+	blockKey := c.psk
+	if salt != nil {
+		blockKey = identitySubkey(c.psk, salt)
+	}
+	block, err := aes.NewCipher(blockKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var hash [identityHeaderSize]byte
+	block.Decrypt(hash[:], b)
+	if xor != nil {
+		subtle.XORBytes(hash[:], hash[:], xor)
+	}
+
+	u := c.users.lookup(hash)
+	if u == nil {
+		return nil, ErrUnknownUser
+	}
+	return u, nil
+}
+
+func identitySubkey(ipsk, salt []byte) []byte {
+	material := make([]byte, 0, len(ipsk)+len(salt))
+	material = append(material, ipsk...)
+	material = append(material, salt...)
+
+	subkey := make([]byte, len(ipsk))
+	blake3.DeriveKey(subkey, "shadowsocks 2022 identity subkey", material)
+	return subkey
+}
+
+func (c *Cipher2022) isMultiUser() bool {
+	return c.users != nil && c.users.count() > 0
+}
+
+func checkTimestamp(ts uint64) error {
+	d := time.Since(time.Unix(int64(ts), 0))
+	if d < -maxTimeDiff || d > maxTimeDiff {
+		return ErrBadTimestamp
+	}
+	return nil
+}
+
+// increaseNonce increases the little endian counter nonce.
+func increaseNonce(nonce []byte) {
+	for i := range nonce {
+		nonce[i]++
+		if nonce[i] != 0 {
+			return
+		}
+	}
+}
+
+// saltFilter remembers the salts in the recent saltTTL to reject the replayed requests.
+type saltFilter struct {
+	salts  map[string]time.Time
+	purged time.Time
+	mu     sync.Mutex
+}
+
+func newSaltFilter() *saltFilter {
+	return &saltFilter{
+		salts: make(map[string]time.Time),
+	}
+}
+
+// check adds the salt to the filter, it returns false if the salt has been seen.
+func (f *saltFilter) check(salt []byte) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(f.purged) >= time.Second {
+		for k, t := range f.salts {
+			if now.Sub(t) >= saltTTL {
+				delete(f.salts, k)
+			}
+		}
+		f.purged = now
+	}
+
+	if t, ok := f.salts[string(salt)]; ok && now.Sub(t) < saltTTL {
+		return false
+	}
+	f.salts[string(salt)] = now
+	return true
+}
+
+type user2022 struct {
+	name string
+	key  []byte
+}
+
+// users2022 maps the identity hashes to the users of the server.
+type users2022 struct {
+	cipher  *Cipher2022
+	lister  xauth.Lister
+	hashes  map[[identityHeaderSize]byte]*user2022
+	updated time.Time
+	mu      sync.Mutex
+}
+
+func (u *users2022) count() int {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	// the users may be added to or removed from the server without users.
+	if u.hashes == nil || time.Since(u.updated) >= usersReloadInterval {
+		u.reload()
+	}
+	return len(u.hashes)
+}
+
+func (u *users2022) lookup(hash [identityHeaderSize]byte) *user2022 {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	v := u.hashes[hash]
+	if v == nil && time.Since(u.updated) >= usersReloadInterval {
+		// the users may be updated.
+		u.reload()
+		v = u.hashes[hash]
+	}
+	return v
+}
+
+func (u *users2022) reload() {
+	hashes := make(map[[identityHeaderSize]byte]*user2022)
+	for name, password := range u.lister.Users() {
+		key, err := u.cipher.decodeKey(password)
+		if err != nil || subtle.ConstantTimeCompare(key, u.cipher.psk) == 1 {
+			// not a user of this server.
+			continue
+		}
+
+		var hash [identityHeaderSize]byte
+		sum := blake3.Sum256(key)
+		copy(hash[:], sum[:])
+		hashes[hash] = &user2022{name: name, key: key}
+	}
+
+	u.hashes = hashes
+	u.updated = time.Now()
+}
+
+// User returns the user authenticated by the identity header of the connection,
+// the password is the uPSK of the user. It returns empty strings if the server has no users.
+func User(conn net.Conn) (user, password string) {
+	for conn != nil {
+		switch c := conn.(type) {
+		case *streamConn2022:
+			if c.user != nil {
+				return c.user.name, base64.StdEncoding.EncodeToString(c.user.key)
+			}
+			return
+		case *shadowConn:
+			conn = c.Conn
+		default:
+			return
+		}
+	}
+	return
+}
@@ -0,0 +1,397 @@
+package ss
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/go-gost/core/common/bufpool"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+const (
+	sessionIDSize       = 8
+	separateHeaderSize  = 16
+	maxPacketSize       = 64 * 1024
+	maxServerSessions   = 64
+	packetFilterBlocks  = 128
+	packetFilterWindow  = (packetFilterBlocks - 1) * 64
+	packetFilterBitMask = 63
+)
+
+var (
+	ErrShortPacket = errors.New("ss2022: short packet")
+)
+
+// PacketConn creates the client side packet connection,
+// the packets written and read are in the form of SOCKS5 address and payload.
+func (c *Cipher2022) PacketConn(pc net.PacketConn) net.PacketConn {
+	pconn := &packetConn2022{
+		PacketConn: pc,
+		cipher:     c,
+		local: &udpSession2022{
+			key: c.psk,
+		},
+	}
+	rand.Read(pconn.local.id[:])
+	return pconn
+}
+
+// ServerPacketConn creates the server side packet connection,
+// the packets written and read are in the form of SOCKS5 address and payload.
+// The packets are written to the client session of the last packet read.
+func (c *Cipher2022) ServerPacketConn(pc net.PacketConn) net.PacketConn {
+	return &packetConn2022{
+		PacketConn: pc,
+		cipher:     c,
+		server:     true,
+		sessions:   make(map[[sessionIDSize]byte]*udpSession2022),
+	}
+}
+
+type udpSession2022 struct {
+	id       [sessionIDSize]byte
+	key      []byte
+	aead     cipher.AEAD
+	packetID uint64
+	filter   packetFilter
+	// the server session of the client session on the server side,
+	// or the last server session on the client side.
+	peer *udpSession2022
+}
+
+func (s *udpSession2022) sessionAEAD(c *Cipher2022) (cipher.AEAD, error) {
+	if s.aead == nil && c.method != Method2022ChaCha20Poly1305 {
+		aead, err := c.sessionAEAD(s.key, s.id[:])
+		if err != nil {
+			return nil, err
+		}
+		s.aead = aead
+	}
+	return s.aead, nil
+}
+
+// packetConn2022 is the UDP connection of the Shadowsocks 2022 Edition.
+//
+// For the AES methods:
+//
+//	packet: AES(session ID, packet ID) | [identity header] | AEAD(type, timestamp, [client session ID], padding length, padding, address, payload)
+//
+// For the ChaCha20-Poly1305 method:
+//
+//	packet: nonce | XChaCha20-Poly1305(session ID, packet ID, type, timestamp, [client session ID], padding length, padding, address, payload)
+type packetConn2022 struct {
+	net.PacketConn
+	cipher *Cipher2022
+	server bool
+
+	// the client session on the client side.
+	local *udpSession2022
+	// the client sessions on the server side.
+	sessions map[[sessionIDSize]byte]*udpSession2022
+	// the client session of the last packet read on the server side.
+	last *udpSession2022
+	mu   sync.Mutex
+}
+
+func (c *packetConn2022) ReadFrom(b []byte) (n int, addr net.Addr, err error) {
+	buf := bufpool.Get(maxPacketSize)
+	defer bufpool.Put(buf)
+
+	for {
+		var nn int
+		nn, addr, err = c.PacketConn.ReadFrom(*buf)
+		if err != nil {
+			return
+		}
+
+		var payload []byte
+		payload, err = c.open((*buf)[:nn])
+		if err != nil {
+			// the invalid packets are dropped.
+			continue
+		}
+
+		n = copy(b, payload)
+		return
+	}
+}
+
+func (c *packetConn2022) WriteTo(b []byte, addr net.Addr) (n int, err error) {
+	buf := bufpool.Get(maxPacketSize)
+	defer bufpool.Put(buf)
+
+	packet, err := c.seal((*buf)[:0], b)
+	if err != nil {
+		return
+	}
+	if _, err = c.PacketConn.WriteTo(packet, addr); err != nil {
+		return
+	}
+	return len(b), nil
+}
+
+// blockCipher returns the cipher of the separate header of the packet sent to the server,
+// or the one of the packet sent to the client with key.
+func (c *packetConn2022) blockCipher(key []byte) (cipher.Block, error) {
+	if !c.server && len(c.cipher.ipsks) > 0 {
+		key = c.cipher.ipsks[0]
+	}
+	return aes.NewCipher(key)
+}
+
+func (c *packetConn2022) seal(dst, b []byte) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	session := c.local
+	var clientSessionID []byte
+	if c.server {
+		if c.last == nil {
+			return nil, errors.New("ss2022: no client session")
+		}
+		if c.last.peer == nil {
+			peer := &udpSession2022{
+				key: c.last.key,
+			}
+			rand.Read(peer.id[:])
+			c.last.peer = peer
+		}
+		session = c.last.peer
+		clientSessionID = c.last.id[:]
+	}
+
+	header := make([]byte, separateHeaderSize)
+	copy(header, session.id[:])
+	binary.BigEndian.PutUint64(header[sessionIDSize:], session.packetID)
+	session.packetID++
+
+	body := make([]byte, 0, 1+8+len(clientSessionID)+2+len(b))
+	if c.server {
+		body = append(body, headerTypeServer)
+	} else {
+		body = append(body, headerTypeClient)
+	}
+	body = binary.BigEndian.AppendUint64(body, uint64(time.Now().Unix()))
+	body = append(body, clientSessionID...)
+	body = binary.BigEndian.AppendUint16(body, 0) // no padding
+	body = append(body, b...)
+
+	if c.cipher.method == Method2022ChaCha20Poly1305 {
+		aead, err := chacha20poly1305.NewX(c.cipher.psk)
+		if err != nil {
+			return nil, err
+		}
+		nonce := make([]byte, aead.NonceSize())
+		rand.Read(nonce)
+		dst = append(dst, nonce...)
+		return aead.Seal(dst, nonce, append(header, body...), nil), nil
+	}
+
+	block, err := c.blockCipher(session.key)
+	if err != nil {
+		return nil, err
+	}
+	encHeader := make([]byte, separateHeaderSize)
+	block.Encrypt(encHeader, header)
+	dst = append(dst, encHeader...)
+
+	if !c.server {
+		for i, ipsk := range c.cipher.ipsks {
+			key := c.cipher.psk
+			if i+1 < len(c.cipher.ipsks) {
+				key = c.cipher.ipsks[i+1]
+			}
+			eih, err := c.cipher.identityHeader(ipsk, key, nil, header)
+			if err != nil {
+				return nil, err
+			}
+			dst = append(dst, eih...)
+		}
+	}
+
+	aead, err := session.sessionAEAD(c.cipher)
+	if err != nil {
+		return nil, err
+	}
+	return aead.Seal(dst, header[4:], body, nil), nil
+}
+
+// open decrypts the packet b and returns the SOCKS5 address and payload.
+func (c *packetConn2022) open(b []byte) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var header, body []byte
+	var session *udpSession2022
+
+	if c.cipher.method == Method2022ChaCha20Poly1305 {
+		aead, err := chacha20poly1305.NewX(c.cipher.psk)
+		if err != nil {
+			return nil, err
+		}
+		if len(b) < aead.NonceSize()+separateHeaderSize+aead.Overhead() {
+			return nil, ErrShortPacket
+		}
+		plain, err := aead.Open(b[aead.NonceSize():aead.NonceSize()], b[:aead.NonceSize()], b[aead.NonceSize():], nil)
+		if err != nil {
+			return nil, err
+		}
+		header, body = plain[:separateHeaderSize], plain[separateHeaderSize:]
+		if session, err = c.session(header, c.cipher.psk); err != nil {
+			return nil, err
+		}
+	} else {
+		if len(b) < separateHeaderSize {
+			return nil, ErrShortPacket
+		}
+		key := c.cipher.psk
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		header = make([]byte, separateHeaderSize)
+		block.Decrypt(header, b[:separateHeaderSize])
+		b = b[separateHeaderSize:]
+
+		if c.server && c.cipher.isMultiUser() {
+			if len(b) < identityHeaderSize {
+				return nil, ErrShortPacket
+			}
+			user, err := c.cipher.openIdentityHeader(b[:identityHeaderSize], nil, header)
+			if err != nil {
+				return nil, err
+			}
+			key = user.key
+			b = b[identityHeaderSize:]
+		}
+
+		if session, err = c.session(header, key); err != nil {
+			return nil, err
+		}
+		aead, err := session.sessionAEAD(c.cipher)
+		if err != nil {
+			return nil, err
+		}
+		if body, err = aead.Open(b[:0], header[4:], b, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(body) < 1+8 {
+		return nil, ErrShortPacket
+	}
+	headerType := byte(headerTypeServer)
+	if c.server {
+		headerType = headerTypeClient
+	}
+	if body[0] != headerType {
+		return nil, ErrBadHeaderType
+	}
+	if err := checkTimestamp(binary.BigEndian.Uint64(body[1:])); err != nil {
+		return nil, err
+	}
+	body = body[1+8:]
+
+	if !c.server {
+		if len(body) < sessionIDSize {
+			return nil, ErrShortPacket
+		}
+		if string(body[:sessionIDSize]) != string(c.local.id[:]) {
+			return nil, errors.New("ss2022: client session ID mismatch")
+		}
+		body = body[sessionIDSize:]
+	}
+
+	if len(body) < 2 {
+		return nil, ErrShortPacket
+	}
+	paddingLen := int(binary.BigEndian.Uint16(body))
+	if len(body) < 2+paddingLen {
+		return nil, ErrShortPacket
+	}
+	body = body[2+paddingLen:]
+
+	// the packet ID is accepted only after the packet is authenticated.
+	if !session.filter.check(binary.BigEndian.Uint64(header[sessionIDSize:])) {
+		return nil, ErrReplay
+	}
+	c.addSession(session)
+
+	return body, nil
+}
+
+// session returns the session of the packet with the separate header,
+// the packets from the server are of the server sessions.
+// The new session is added by addSession after the packet is authenticated.
+func (c *packetConn2022) session(header []byte, key []byte) (*udpSession2022, error) {
+	var id [sessionIDSize]byte
+	copy(id[:], header)
+
+	var s *udpSession2022
+	if c.server {
+		s = c.sessions[id]
+	} else if peer := c.local.peer; peer != nil && peer.id == id {
+		s = peer
+	}
+	if s == nil {
+		return &udpSession2022{id: id, key: key}, nil
+	}
+	if string(s.key) != string(key) {
+		return nil, ErrUnknownUser
+	}
+	return s, nil
+}
+
+func (c *packetConn2022) addSession(s *udpSession2022) {
+	if !c.server {
+		// a new server session, e.g. the server restarts.
+		c.local.peer = s
+		return
+	}
+
+	if c.sessions[s.id] == nil && len(c.sessions) >= maxServerSessions {
+		for k, v := range c.sessions {
+			if v != c.last {
+				delete(c.sessions, k)
+			}
+		}
+	}
+	c.sessions[s.id] = s
+	c.last = s
+}
+
+// packetFilter is the sliding window filter of the packet IDs.
+type packetFilter struct {
+	last uint64
+	ring [packetFilterBlocks]uint64
+}
+
+// check returns false if the packet ID is replayed or too old.
+func (f *packetFilter) check(id uint64) bool {
+	blockIndex := id >> 6
+	if id > f.last {
+		current := f.last >> 6
+		diff := blockIndex - current
+		if diff > packetFilterBlocks {
+			diff = packetFilterBlocks
+		}
+		for i := current + 1; i <= current+diff; i++ {
+			f.ring[i%packetFilterBlocks] = 0
+		}
+		f.last = id
+	} else if f.last-id > packetFilterWindow {
+		return false
+	}
+
+	blockIndex %= packetFilterBlocks
+	bit := uint64(1) << (id & packetFilterBitMask)
+	old := f.ring[blockIndex]
+	f.ring[blockIndex] = old | bit
+	return old&bit == 0
+}
@@ -0,0 +1,415 @@
+package ss
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	mrand "math/rand"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/go-gost/gosocks5"
+)
+
+// StreamConn creates the client side stream connection.
+// The first write must start with the target address in the SOCKS5 form.
+func (c *Cipher2022) StreamConn(conn net.Conn) net.Conn {
+	return &streamConn2022{
+		Conn:    conn,
+		cipher:  c,
+		key:     c.psk,
+		written: make(chan struct{}),
+		closed:  make(chan struct{}),
+	}
+}
+
+// ServerStreamConn creates the server side stream connection.
+// The target address in the SOCKS5 form is read first from the connection.
+func (c *Cipher2022) ServerStreamConn(conn net.Conn) net.Conn {
+	return &streamConn2022{
+		Conn:   conn,
+		cipher: c,
+		key:    c.psk,
+		server: true,
+		closed: make(chan struct{}),
+	}
+}
+
+// streamConn2022 is the TCP connection of the Shadowsocks 2022 Edition:
+//
+//	request:  salt | [identity headers] | AEAD(type, timestamp, length) | AEAD(address, padding length, padding, payload) | chunks
+//	response: salt | AEAD(type, timestamp, request salt, length) | AEAD(payload) | chunks
+//	chunk:    AEAD(length) | AEAD(payload)
+type streamConn2022 struct {
+	net.Conn
+	cipher *Cipher2022
+	server bool
+	key    []byte
+	user   *user2022
+
+	// the salt of the request.
+	requestSalt []byte
+	// closed after the request header is written by the client.
+	written   chan struct{}
+	closed    chan struct{}
+	closeOnce sync.Once
+
+	rAEAD  cipher.AEAD
+	rNonce []byte
+	rbuf   bytes.Buffer
+	rmu    sync.Mutex
+
+	wAEAD  cipher.AEAD
+	wNonce []byte
+	wmu    sync.Mutex
+}
+
+func (c *streamConn2022) Read(b []byte) (n int, err error) {
+	c.rmu.Lock()
+	defer c.rmu.Unlock()
+
+	if c.rbuf.Len() > 0 {
+		return c.rbuf.Read(b)
+	}
+
+	if c.rAEAD == nil {
+		if c.server {
+			err = c.readRequestHeader()
+		} else {
+			err = c.readResponseHeader()
+		}
+	} else {
+		err = c.readChunk()
+	}
+	if err != nil {
+		return
+	}
+
+	return c.rbuf.Read(b)
+}
+
+func (c *streamConn2022) Write(b []byte) (n int, err error) {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	if c.wAEAD == nil {
+		if c.server {
+			return c.writeResponseHeader(b)
+		}
+		return c.writeRequestHeader(b)
+	}
+
+	return c.writeChunks(b)
+}
+
+func (c *streamConn2022) writeChunks(b []byte) (n int, err error) {
+	for len(b) > 0 {
+		nn := len(b)
+		if nn > maxPayloadSize {
+			nn = maxPayloadSize
+		}
+		if err = c.writeChunk(b[:nn]); err != nil {
+			return
+		}
+		n += nn
+		b = b[nn:]
+	}
+	return
+}
+
+func (c *streamConn2022) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+	})
+	return c.Conn.Close()
+}
+
+func (c *streamConn2022) writeRequestHeader(b []byte) (n int, err error) {
+	addrLen, err := socksAddrLen(b)
+	if err != nil {
+		return
+	}
+
+	salt := make([]byte, c.cipher.saltSize())
+	if _, err = rand.Read(salt); err != nil {
+		return
+	}
+	if c.wAEAD, err = c.cipher.sessionAEAD(c.key, salt); err != nil {
+		return
+	}
+	c.wNonce = make([]byte, c.wAEAD.NonceSize())
+
+	var buf bytes.Buffer
+	buf.Write(salt)
+	for i, ipsk := range c.cipher.ipsks {
+		key := c.key
+		if i+1 < len(c.cipher.ipsks) {
+			key = c.cipher.ipsks[i+1]
+		}
+		eih, err := c.cipher.identityHeader(ipsk, key, salt, nil)
+		if err != nil {
+			return 0, err
+		}
+		buf.Write(eih)
+	}
+
+	payload := b[addrLen:]
+	if max := maxPayloadSize - addrLen - 2; len(payload) > max {
+		payload = payload[:max]
+	}
+	paddingLen := 0
+	if len(payload) == 0 {
+		paddingLen = 1 + mrand.Intn(maxPaddingLength)
+	}
+
+	header := make([]byte, addrLen+2+paddingLen+len(payload))
+	copy(header, b[:addrLen])
+	binary.BigEndian.PutUint16(header[addrLen:], uint16(paddingLen))
+	rand.Read(header[addrLen+2 : addrLen+2+paddingLen])
+	copy(header[addrLen+2+paddingLen:], payload)
+
+	fixed := make([]byte, 1+8+2)
+	fixed[0] = headerTypeClient
+	binary.BigEndian.PutUint64(fixed[1:], uint64(time.Now().Unix()))
+	binary.BigEndian.PutUint16(fixed[9:], uint16(len(header)))
+
+	buf.Write(c.seal(fixed))
+	buf.Write(c.seal(header))
+	if _, err = c.Conn.Write(buf.Bytes()); err != nil {
+		return
+	}
+
+	c.requestSalt = salt
+	close(c.written)
+
+	n = addrLen + len(payload)
+	if n < len(b) {
+		var nn int
+		nn, err = c.writeChunks(b[n:])
+		n += nn
+	}
+	return
+}
+
+func (c *streamConn2022) writeResponseHeader(b []byte) (n int, err error) {
+	if c.requestSalt == nil {
+		return 0, errors.New("ss2022: request is not received")
+	}
+
+	salt := make([]byte, c.cipher.saltSize())
+	if _, err = rand.Read(salt); err != nil {
+		return
+	}
+	if c.wAEAD, err = c.cipher.sessionAEAD(c.key, salt); err != nil {
+		return
+	}
+	c.wNonce = make([]byte, c.wAEAD.NonceSize())
+
+	payload := b
+	if len(payload) > maxPayloadSize {
+		payload = payload[:maxPayloadSize]
+	}
+
+	fixed := make([]byte, 1+8+len(c.requestSalt)+2)
+	fixed[0] = headerTypeServer
+	binary.BigEndian.PutUint64(fixed[1:], uint64(time.Now().Unix()))
+	copy(fixed[9:], c.requestSalt)
+	binary.BigEndian.PutUint16(fixed[9+len(c.requestSalt):], uint16(len(payload)))
+
+	var buf bytes.Buffer
+	buf.Write(salt)
+	buf.Write(c.seal(fixed))
+	buf.Write(c.seal(payload))
+	if _, err = c.Conn.Write(buf.Bytes()); err != nil {
+		return
+	}
+
+	n = len(payload)
+	if n < len(b) {
+		var nn int
+		nn, err = c.writeChunks(b[n:])
+		n += nn
+	}
+	return
+}
+
+func (c *streamConn2022) writeChunk(b []byte) error {
+	var length [2]byte
+	binary.BigEndian.PutUint16(length[:], uint16(len(b)))
+
+	buf := make([]byte, 0, len(length)+len(b)+2*c.wAEAD.Overhead())
+	buf = append(buf, c.seal(length[:])...)
+	buf = append(buf, c.seal(b)...)
+	_, err := c.Conn.Write(buf)
+	return err
+}
+
+func (c *streamConn2022) seal(b []byte) []byte {
+	out := c.wAEAD.Seal(nil, c.wNonce, b, nil)
+	increaseNonce(c.wNonce)
+	return out
+}
+
+func (c *streamConn2022) readRequestHeader() error {
+	salt := make([]byte, c.cipher.saltSize())
+	if _, err := io.ReadFull(c.Conn, salt); err != nil {
+		return err
+	}
+
+	if c.cipher.isMultiUser() {
+		eih := make([]byte, identityHeaderSize)
+		if _, err := io.ReadFull(c.Conn, eih); err != nil {
+			return err
+		}
+		user, err := c.cipher.openIdentityHeader(eih, salt, nil)
+		if err != nil {
+			return err
+		}
+		c.user = user
+		c.key = user.key
+	}
+
+	var err error
+	if c.rAEAD, err = c.cipher.sessionAEAD(c.key, salt); err != nil {
+		return err
+	}
+	c.rNonce = make([]byte, c.rAEAD.NonceSize())
+
+	fixed, err := c.open(1 + 8 + 2)
+	if err != nil {
+		return err
+	}
+	if fixed[0] != headerTypeClient {
+		return ErrBadHeaderType
+	}
+	if err := checkTimestamp(binary.BigEndian.Uint64(fixed[1:])); err != nil {
+		return err
+	}
+	// the salt is remembered only for the authenticated requests,
+	// so that the probes with the salts seen can not get the genuine requests rejected.
+	if !c.cipher.salts.check(salt) {
+		return ErrReplay
+	}
+
+	header, err := c.open(int(binary.BigEndian.Uint16(fixed[9:])))
+	if err != nil {
+		return err
+	}
+	addrLen, err := socksAddrLen(header)
+	if err != nil {
+		return err
+	}
+	if len(header) < addrLen+2 {
+		return io.ErrUnexpectedEOF
+	}
+	paddingLen := int(binary.BigEndian.Uint16(header[addrLen:]))
+	if len(header) < addrLen+2+paddingLen {
+		return io.ErrUnexpectedEOF
+	}
+
+	c.requestSalt = salt
+	// the padding is stripped from the stream.
+	c.rbuf.Write(header[:addrLen])
+	c.rbuf.Write(header[addrLen+2+paddingLen:])
+
+	return nil
+}
+
+func (c *streamConn2022) readResponseHeader() error {
+	// the response is verified against the request salt.
+	select {
+	case <-c.written:
+	case <-c.closed:
+		return net.ErrClosed
+	}
+
+	salt := make([]byte, c.cipher.saltSize())
+	if _, err := io.ReadFull(c.Conn, salt); err != nil {
+		return err
+	}
+
+	var err error
+	if c.rAEAD, err = c.cipher.sessionAEAD(c.key, salt); err != nil {
+		return err
+	}
+	c.rNonce = make([]byte, c.rAEAD.NonceSize())
+
+	fixed, err := c.open(1 + 8 + len(c.requestSalt) + 2)
+	if err != nil {
+		return err
+	}
+	if fixed[0] != headerTypeServer {
+		return ErrBadHeaderType
+	}
+	if err := checkTimestamp(binary.BigEndian.Uint64(fixed[1:])); err != nil {
+		return err
+	}
+	if !bytes.Equal(fixed[9:9+len(c.requestSalt)], c.requestSalt) {
+		return errors.New("ss2022: request salt mismatch")
+	}
+
+	payload, err := c.open(int(binary.BigEndian.Uint16(fixed[9+len(c.requestSalt):])))
+	if err != nil {
+		return err
+	}
+	c.rbuf.Write(payload)
+
+	return nil
+}
+
+func (c *streamConn2022) readChunk() error {
+	length, err := c.open(2)
+	if err != nil {
+		return err
+	}
+	payload, err := c.open(int(binary.BigEndian.Uint16(length)))
+	if err != nil {
+		return err
+	}
+	c.rbuf.Write(payload)
+	return nil
+}
+
+// open reads and decrypts the sealed data of size n.
+func (c *streamConn2022) open(n int) ([]byte, error) {
+	b := make([]byte, n+c.rAEAD.Overhead())
+	if _, err := io.ReadFull(c.Conn, b); err != nil {
+		return nil, err
+	}
+	b, err := c.rAEAD.Open(b[:0], c.rNonce, b, nil)
+	if err != nil {
+		return nil, err
+	}
+	increaseNonce(c.rNonce)
+	return b, nil
+}
+
+// socksAddrLen returns the length of the SOCKS5 address at the beginning of b.
+func socksAddrLen(b []byte) (int, error) {
+	if len(b) < 1 {
+		return 0, io.ErrUnexpectedEOF
+	}
+
+	var n int
+	switch b[0] {
+	case gosocks5.AddrIPv4:
+		n = 1 + net.IPv4len + 2
+	case gosocks5.AddrIPv6:
+		n = 1 + net.IPv6len + 2
+	case gosocks5.AddrDomain:
+		if len(b) < 2 {
+			return 0, io.ErrUnexpectedEOF
+		}
+		n = 1 + 1 + int(b[1]) + 2
+	default:
+		return 0, gosocks5.ErrBadAddrType
+	}
+	if len(b) < n {
+		return 0, io.ErrUnexpectedEOF
+	}
+	return n, nil
+}
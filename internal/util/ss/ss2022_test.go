@@ -0,0 +1,457 @@
+package ss
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/go-gost/gosocks5"
+	"golang.org/x/crypto/chacha20poly1305"
+	"lukechampine.com/blake3"
+)
+
+type users map[string]string
+
+func (u users) Users() map[string]string {
+	return u
+}
+
+func newKey(t *testing.T, size int) string {
+	t.Helper()
+
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+func tcpPipe(t *testing.T) (net.Conn, net.Conn) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	c1, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c2, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		c1.Close()
+		c2.Close()
+	})
+	return c1, c2
+}
+
+func socksAddr(t *testing.T, addr string) []byte {
+	t.Helper()
+
+	a, err := gosocks5.NewAddr(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if _, err := a.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// serve reads the target address and the request payload on the server side and echoes the payload.
+func serve(conn net.Conn, size int) (addr string, err error) {
+	a := &gosocks5.Addr{}
+	if _, err := a.ReadFrom(conn); err != nil {
+		return "", err
+	}
+	b := make([]byte, size)
+	if _, err := io.ReadFull(conn, b); err != nil {
+		return "", err
+	}
+	if _, err := conn.Write(b); err != nil {
+		return "", err
+	}
+	return a.String(), nil
+}
+
+func TestNewCipher2022(t *testing.T) {
+	tests := []struct {
+		method   string
+		password string
+		wantErr  bool
+	}{
+		{Method2022AES128GCM, newKey(t, 16), false},
+		{Method2022AES256GCM, newKey(t, 32), false},
+		{Method2022ChaCha20Poly1305, newKey(t, 32), false},
+		{Method2022AES128GCM, newKey(t, 32), true},
+		{Method2022AES256GCM, "not base64", true},
+		{Method2022AES128GCM, newKey(t, 16) + ":" + newKey(t, 16), false},
+		{Method2022ChaCha20Poly1305, newKey(t, 32) + ":" + newKey(t, 32), true},
+		{"2022-blake3-unknown", newKey(t, 32), true},
+	}
+	for _, tt := range tests {
+		if _, err := newCipher2022(tt.method, tt.password); (err != nil) != tt.wantErr {
+			t.Errorf("%s %q: got error %v, want error %v", tt.method, tt.password, err, tt.wantErr)
+		}
+	}
+}
+
+// TestSessionSubkey checks the session subkey against the derivation in SIP022 by
+// writing the request header by hand.
+func TestSessionSubkey(t *testing.T) {
+	for _, method := range []string{Method2022AES128GCM, Method2022AES256GCM, Method2022ChaCha20Poly1305} {
+		t.Run(method, func(t *testing.T) {
+			server, err := newCipher2022(method, newKey(t, keySize(method)))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			salt := make([]byte, server.keySize)
+			rand.Read(salt)
+			subkey := make([]byte, server.keySize)
+			blake3.DeriveKey(subkey, "shadowsocks 2022 session subkey", append(append([]byte{}, server.psk...), salt...))
+
+			var aead cipher.AEAD
+			if method == Method2022ChaCha20Poly1305 {
+				aead, err = chacha20poly1305.New(subkey)
+			} else {
+				var block cipher.Block
+				block, err = aes.NewCipher(subkey)
+				if err == nil {
+					aead, err = cipher.NewGCM(block)
+				}
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			nonce := make([]byte, aead.NonceSize())
+
+			payload := []byte("hello")
+			variable := socksAddr(t, "example.com:443")
+			variable = binary.BigEndian.AppendUint16(variable, 4)
+			variable = append(variable, 0, 0, 0, 0)
+			variable = append(variable, payload...)
+
+			fixed := []byte{headerTypeClient}
+			fixed = binary.BigEndian.AppendUint64(fixed, uint64(time.Now().Unix()))
+			fixed = binary.BigEndian.AppendUint16(fixed, uint16(len(variable)))
+
+			req := append([]byte{}, salt...)
+			req = aead.Seal(req, nonce, fixed, nil)
+			increaseNonce(nonce)
+			req = aead.Seal(req, nonce, variable, nil)
+
+			c1, c2 := tcpPipe(t)
+			go c1.Write(req)
+
+			conn := server.ServerStreamConn(c2)
+			conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+			a := &gosocks5.Addr{}
+			if _, err := a.ReadFrom(conn); err != nil {
+				t.Fatal(err)
+			}
+			if a.String() != "example.com:443" {
+				t.Errorf("got address %s, want example.com:443", a)
+			}
+			b := make([]byte, len(payload))
+			if _, err := io.ReadFull(conn, b); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(b, payload) {
+				t.Errorf("got payload %q, want %q", b, payload)
+			}
+		})
+	}
+}
+
+func keySize(method string) int {
+	if method == Method2022AES128GCM {
+		return 16
+	}
+	return 32
+}
+
+func TestStreamConn2022(t *testing.T) {
+	alice, bob := newKey(t, 16), newKey(t, 16)
+	psk := newKey(t, 16)
+
+	tests := []struct {
+		name     string
+		method   string
+		server   string
+		users    users
+		client   string
+		wantUser string
+		wantErr  bool
+	}{
+		{name: "aes-128", method: Method2022AES128GCM, server: psk, client: psk},
+		{name: "chacha20", method: Method2022ChaCha20Poly1305, server: "AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8=", client: "AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8="},
+		{name: "wrong key", method: Method2022AES128GCM, server: psk, client: newKey(t, 16), wantErr: true},
+		{name: "user", method: Method2022AES128GCM, server: psk, users: users{"alice": alice, "bob": bob}, client: psk + ":" + bob, wantUser: "bob"},
+		{name: "unknown user", method: Method2022AES128GCM, server: psk, users: users{"alice": alice}, client: psk + ":" + bob, wantErr: true},
+		{name: "wrong identity key", method: Method2022AES128GCM, server: psk, users: users{"alice": alice}, client: newKey(t, 16) + ":" + alice, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, err := newCipher2022(tt.method, tt.server)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.users != nil {
+				server.SetUsers(tt.users)
+			}
+			client, err := newCipher2022(tt.method, tt.client)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			c1, c2 := tcpPipe(t)
+			c1.SetDeadline(time.Now().Add(5 * time.Second))
+			c2.SetDeadline(time.Now().Add(5 * time.Second))
+
+			payload := []byte("hello, world")
+			cc := client.StreamConn(c1)
+			go cc.Write(append(socksAddr(t, "example.com:80"), payload...))
+
+			sc := server.ServerStreamConn(c2)
+			addr, err := serve(sc, len(payload))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if addr != "example.com:80" {
+				t.Errorf("got address %s, want example.com:80", addr)
+			}
+			if user, _ := User(sc); user != tt.wantUser {
+				t.Errorf("got user %q, want %q", user, tt.wantUser)
+			}
+
+			b := make([]byte, len(payload))
+			if _, err := io.ReadFull(cc, b); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(b, payload) {
+				t.Errorf("got response %q, want %q", b, payload)
+			}
+		})
+	}
+}
+
+func TestStreamConn2022Replay(t *testing.T) {
+	psk := newKey(t, 32)
+	server, _ := newCipher2022(Method2022AES256GCM, psk)
+	client, _ := newCipher2022(Method2022AES256GCM, psk)
+
+	// capture the request of the client.
+	c1, c2 := tcpPipe(t)
+	c2.SetReadDeadline(time.Now().Add(5 * time.Second))
+	payload := []byte("hello")
+	go func() {
+		cc := client.StreamConn(c1)
+		cc.Write(append(socksAddr(t, "example.com:80"), payload...))
+		cc.Close()
+	}()
+	req, err := io.ReadAll(c2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, wantErr := range []error{nil, ErrReplay} {
+		c1, c2 := tcpPipe(t)
+		go c1.Write(req)
+
+		sc := server.ServerStreamConn(c2)
+		sc.SetReadDeadline(time.Now().Add(5 * time.Second))
+		a := &gosocks5.Addr{}
+		_, err := a.ReadFrom(sc)
+		if wantErr == nil && err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+		if wantErr != nil && !errors.Is(err, wantErr) {
+			t.Fatalf("request %d: got error %v, want %v", i, err, wantErr)
+		}
+	}
+}
+
+func TestStreamConn2022Probe(t *testing.T) {
+	psk := newKey(t, 32)
+	server, _ := newCipher2022(Method2022AES256GCM, psk)
+	client, _ := newCipher2022(Method2022AES256GCM, psk)
+
+	c1, c2 := tcpPipe(t)
+	c2.SetReadDeadline(time.Now().Add(5 * time.Second))
+	go func() {
+		cc := client.StreamConn(c1)
+		cc.Write(socksAddr(t, "example.com:80"))
+		cc.Close()
+	}()
+	req, err := io.ReadAll(c2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the probe with the salt of the request fails to be authenticated,
+	// and the salt is not remembered for it.
+	probe := append([]byte{}, req[:server.saltSize()]...)
+	probe = append(probe, make([]byte, 64)...)
+	for i, b := range [][]byte{probe, req} {
+		c1, c2 := tcpPipe(t)
+		go c1.Write(b)
+
+		sc := server.ServerStreamConn(c2)
+		sc.SetReadDeadline(time.Now().Add(5 * time.Second))
+		_, err := (&gosocks5.Addr{}).ReadFrom(sc)
+		if i == 0 && (err == nil || errors.Is(err, ErrReplay)) {
+			t.Fatalf("got error %v for the probe", err)
+		}
+		if i == 1 && err != nil {
+			t.Fatalf("the request after the probe: %v", err)
+		}
+	}
+}
+
+func TestUsers2022Reload(t *testing.T) {
+	psk := newKey(t, 16)
+	lister := users{}
+	server, _ := newCipher2022(Method2022AES128GCM, psk)
+	server.SetUsers(lister)
+
+	if server.isMultiUser() {
+		t.Fatal("the server without users is multi-user")
+	}
+
+	// the users added are counted after the reload interval.
+	lister["alice"] = newKey(t, 16)
+	server.users.updated = time.Now().Add(-usersReloadInterval)
+	if !server.isMultiUser() {
+		t.Fatal("the added user is not counted")
+	}
+
+	delete(lister, "alice")
+	if !server.isMultiUser() {
+		t.Error("the users are reloaded within the interval")
+	}
+	server.users.updated = time.Now().Add(-usersReloadInterval)
+	if server.isMultiUser() {
+		t.Error("the removed user is counted")
+	}
+}
+
+func TestSaltFilter(t *testing.T) {
+	f := newSaltFilter()
+	a, b := []byte("salt-a"), []byte("salt-b")
+
+	tests := []struct {
+		salt []byte
+		want bool
+	}{
+		{a, true},
+		{a, false},
+		{b, true},
+		{b, false},
+		{a, false},
+	}
+	for i, tt := range tests {
+		if got := f.check(tt.salt); got != tt.want {
+			t.Errorf("%d: check(%s) = %v, want %v", i, tt.salt, got, tt.want)
+		}
+	}
+
+	// the salt is forgotten after saltTTL.
+	f.salts[string(a)] = time.Now().Add(-saltTTL)
+	f.purged = time.Time{}
+	if !f.check(a) {
+		t.Error("expired salt should be accepted")
+	}
+	if _, ok := f.salts[string(b)]; !ok {
+		t.Error("unexpired salt should be kept")
+	}
+}
+
+func TestCheckTimestamp(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		ts      time.Time
+		wantErr bool
+	}{
+		{now, false},
+		{now.Add(-maxTimeDiff / 2), false},
+		{now.Add(maxTimeDiff / 2), false},
+		{now.Add(-2 * maxTimeDiff), true},
+		{now.Add(2 * maxTimeDiff), true},
+	}
+	for _, tt := range tests {
+		if err := checkTimestamp(uint64(tt.ts.Unix())); (err != nil) != tt.wantErr {
+			t.Errorf("timestamp %v: got error %v, want error %v", tt.ts.Sub(now), err, tt.wantErr)
+		}
+	}
+}
+
+func TestPacketFilter(t *testing.T) {
+	var f packetFilter
+
+	tests := []struct {
+		id   uint64
+		want bool
+	}{
+		{0, true},
+		{1, true},
+		{1, false},
+		{0, false},
+		{100, true},
+		{50, true},
+		{50, false},
+		{100, false},
+		// the window slides forward.
+		{100 + packetFilterWindow, true},
+		{100, false},
+		{101, true},
+		{101, false},
+		{99, false},
+		// a big jump clears the window.
+		{1 << 20, true},
+		{1<<20 - 1, true},
+		{1<<20 - packetFilterWindow - 1, false},
+		{1 << 20, false},
+	}
+	for i, tt := range tests {
+		if got := f.check(tt.id); got != tt.want {
+			t.Errorf("%d: check(%d) = %v, want %v", i, tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestIncreaseNonce(t *testing.T) {
+	tests := []struct {
+		nonce []byte
+		want  []byte
+	}{
+		{[]byte{0, 0, 0}, []byte{1, 0, 0}},
+		{[]byte{0xff, 0, 0}, []byte{0, 1, 0}},
+		{[]byte{0xff, 0xff, 0}, []byte{0, 0, 1}},
+		{[]byte{0xff, 0xff, 0xff}, []byte{0, 0, 0}},
+	}
+	for _, tt := range tests {
+		nonce := append([]byte{}, tt.nonce...)
+		increaseNonce(nonce)
+		if !bytes.Equal(nonce, tt.want) {
+			t.Errorf("increaseNonce(%v) = %v, want %v", tt.nonce, nonce, tt.want)
+		}
+	}
+}
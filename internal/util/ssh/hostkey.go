@@ -0,0 +1,127 @@
+package ssh
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// HostKeyOptions are the options for verifying the host key of the SSH server.
+type HostKeyOptions struct {
+	// KnownHosts is the path of the known_hosts file.
+	KnownHosts string
+	// HostKey is the pinned SHA256 fingerprint of the host key, e.g. SHA256:xxxx.
+	HostKey string
+	// TOFU (trust on first use) records the key of the unknown host to the KnownHosts file.
+	TOFU bool
+}
+
+// HostKeyCallback creates a ssh.HostKeyCallback with the options.
+// The host key is not verified if neither KnownHosts nor HostKey is specified,
+// and it is an error if TOFU is enabled without KnownHosts.
+func HostKeyCallback(opts HostKeyOptions) (ssh.HostKeyCallback, error) {
+	if opts.TOFU && opts.KnownHosts == "" {
+		return nil, errors.New("ssh: tofu requires the known hosts file")
+	}
+	if opts.HostKey == "" && opts.KnownHosts == "" {
+		return ssh.InsecureIgnoreHostKey(), nil
+	}
+
+	var callbacks []ssh.HostKeyCallback
+	if opts.HostKey != "" {
+		callbacks = append(callbacks, fingerprintCallback(opts.HostKey))
+	}
+	if opts.KnownHosts != "" {
+		kh := &knownHosts{
+			file: opts.KnownHosts,
+			tofu: opts.TOFU,
+		}
+		if err := kh.load(); err != nil {
+			return nil, err
+		}
+		callbacks = append(callbacks, kh.check)
+	}
+
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		for _, cb := range callbacks {
+			if err := cb(hostname, remote, key); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, nil
+}
+
+func fingerprintCallback(fingerprint string) ssh.HostKeyCallback {
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		if fp := ssh.FingerprintSHA256(key); fp != fingerprint {
+			return fmt.Errorf("ssh: host key mismatch for %s: got %s, want %s", hostname, fp, fingerprint)
+		}
+		return nil
+	}
+}
+
+type knownHosts struct {
+	file     string
+	tofu     bool
+	callback ssh.HostKeyCallback
+	mu       sync.Mutex
+}
+
+func (kh *knownHosts) load() error {
+	if kh.tofu {
+		if _, err := os.Stat(kh.file); errors.Is(err, os.ErrNotExist) {
+			if err := os.MkdirAll(filepath.Dir(kh.file), 0700); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(kh.file, os.O_CREATE|os.O_WRONLY, 0600)
+			if err != nil {
+				return err
+			}
+			f.Close()
+		}
+	}
+
+	cb, err := knownhosts.New(kh.file)
+	if err != nil {
+		return err
+	}
+	kh.callback = cb
+	return nil
+}
+
+func (kh *knownHosts) check(hostname string, remote net.Addr, key ssh.PublicKey) error {
+	kh.mu.Lock()
+	defer kh.mu.Unlock()
+
+	err := kh.callback(hostname, remote, key)
+	if err == nil || !kh.tofu {
+		return err
+	}
+
+	// a host with no known key is trusted on first use,
+	// while a changed key is always rejected.
+	var ke *knownhosts.KeyError
+	if !errors.As(err, &ke) || len(ke.Want) > 0 {
+		return err
+	}
+
+	f, err := os.OpenFile(kh.file, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	line := knownhosts.Line([]string{knownhosts.Normalize(hostname)}, key)
+	if _, err := fmt.Fprintln(f, line); err != nil {
+		return err
+	}
+
+	return kh.load()
+}
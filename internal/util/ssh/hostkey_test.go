@@ -0,0 +1,102 @@
+package ssh
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func newHostKey(t *testing.T) ssh.PublicKey {
+	t.Helper()
+
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestHostKeyCallbackOptions(t *testing.T) {
+	key := newHostKey(t)
+	other := newHostKey(t)
+	remote := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 22}
+
+	tests := []struct {
+		name    string
+		opts    HostKeyOptions
+		key     ssh.PublicKey
+		wantErr bool
+	}{
+		{"no verification", HostKeyOptions{}, key, false},
+		{"fingerprint match", HostKeyOptions{HostKey: ssh.FingerprintSHA256(key)}, key, false},
+		{"fingerprint mismatch", HostKeyOptions{HostKey: ssh.FingerprintSHA256(key)}, other, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cb, err := HostKeyCallback(tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := cb("example.com:22", remote, tt.key); (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHostKeyCallbackTOFUWithoutKnownHosts(t *testing.T) {
+	if _, err := HostKeyCallback(HostKeyOptions{TOFU: true}); err == nil {
+		t.Fatal("tofu without known hosts file should be an error")
+	}
+}
+
+func TestHostKeyCallbackTOFU(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "ssh", "known_hosts")
+	key := newHostKey(t)
+	remote := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 22}
+
+	// unknown host is rejected without tofu.
+	if _, err := HostKeyCallback(HostKeyOptions{KnownHosts: file}); err == nil {
+		t.Fatal("missing known hosts file should be an error without tofu")
+	}
+
+	cb, err := HostKeyCallback(HostKeyOptions{KnownHosts: file, TOFU: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cb("example.com:22", remote, key); err != nil {
+		t.Fatalf("first use: %v", err)
+	}
+	data, _ := os.ReadFile(file)
+	if !strings.Contains(string(data), "example.com") {
+		t.Fatalf("host key is not recorded: %q", data)
+	}
+	if err := cb("example.com:22", remote, key); err != nil {
+		t.Fatalf("known key: %v", err)
+	}
+	if err := cb("example.com:22", remote, newHostKey(t)); err == nil {
+		t.Fatal("changed key should be rejected")
+	}
+
+	// the recorded key is verified without tofu.
+	cb, err = HostKeyCallback(HostKeyOptions{KnownHosts: file})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cb("example.com:22", remote, key); err != nil {
+		t.Fatalf("recorded key: %v", err)
+	}
+	if err := cb("other.example.com:22", remote, key); err == nil {
+		t.Fatal("unknown host should be rejected without tofu")
+	}
+}
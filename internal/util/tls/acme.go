@@ -0,0 +1,200 @@
+package tls
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/go-gost/core/logger"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// ACMEOptions are the options for obtaining certificates from an ACME server.
+type ACMEOptions struct {
+	// Domains are the domain names the certificates are requested for.
+	Domains []string
+	// Email is the contact email of the ACME account.
+	Email string
+	// Directory is the ACME directory URL, Let's Encrypt is used if empty.
+	Directory string
+	// CacheDir is the directory for storing the account key and certificates.
+	CacheDir string
+	// CAFile is the CA certificate for verifying the ACME server, e.g. the root of Pebble.
+	CAFile string
+}
+
+var (
+	acmeManagers   = make(map[string]*acmeManager)
+	acmeManagersMu sync.Mutex
+)
+
+// acmeManager is an autocert.Manager shared by the services using the same
+// ACME directory, account and cache.
+type acmeManager struct {
+	*autocert.Manager
+	domains map[string]struct{}
+	mu      sync.RWMutex
+}
+
+func (m *acmeManager) addDomains(domains ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, domain := range domains {
+		m.domains[strings.ToLower(domain)] = struct{}{}
+	}
+}
+
+func (m *acmeManager) hasDomain(domain string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	_, ok := m.domains[strings.ToLower(domain)]
+	return ok
+}
+
+func (m *acmeManager) hostPolicy(ctx context.Context, host string) error {
+	if !m.hasDomain(host) {
+		return fmt.Errorf("acme: host %q not configured", host)
+	}
+	return nil
+}
+
+// NewACMEConfig creates a server TLS config with the certificates obtained and renewed
+// from the ACME server and optional client CA file. The challenges are answered by TLS-ALPN-01
+// on the listeners using this config, or by HTTP-01 through ACMEHTTPHandler.
+//
+// The services with the same directory, email and cache directory share the account and certificates.
+func NewACMEConfig(opts ACMEOptions, caFile string, log logger.Logger) (*tls.Config, error) {
+	if len(opts.Domains) == 0 {
+		return nil, errors.New("acme: no domain specified")
+	}
+	if opts.Directory == "" {
+		opts.Directory = autocert.DefaultACMEDirectory
+	}
+
+	m, err := getACMEManager(&opts)
+	if err != nil {
+		return nil, err
+	}
+	m.addDomains(opts.Domains...)
+
+	if log != nil {
+		log.Debugf("acme: %s, domains: %v", opts.Directory, opts.Domains)
+	}
+
+	pool, err := loadCA(caFile)
+	if err != nil {
+		return nil, err
+	}
+
+	defaultDomain := opts.Domains[0]
+	cfg := &tls.Config{
+		GetCertificate: func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			// the client without SNI gets the certificate of the first domain.
+			if hello.ServerName == "" {
+				hello.ServerName = defaultDomain
+			}
+			return m.GetCertificate(hello)
+		},
+		// the NextProtos of the listener are kept for the other clients.
+		GetConfigForClient: func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+			if !isACMEChallenge(hello) {
+				return nil, nil
+			}
+			return &tls.Config{
+				GetCertificate: m.GetCertificate,
+				NextProtos:     []string{acme.ALPNProto},
+			}, nil
+		},
+	}
+	if pool != nil {
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return cfg, nil
+}
+
+// isACMEChallenge reports whether the client hello is a TLS-ALPN-01 challenge from the ACME server.
+func isACMEChallenge(hello *tls.ClientHelloInfo) bool {
+	for _, proto := range hello.SupportedProtos {
+		if proto == acme.ALPNProto {
+			return true
+		}
+	}
+	return false
+}
+
+func getACMEManager(opts *ACMEOptions) (*acmeManager, error) {
+	// the managers of different CAs do not share the HTTP client trusting the CA.
+	key := strings.Join([]string{opts.Directory, opts.Email, opts.CacheDir, opts.CAFile}, "|")
+
+	acmeManagersMu.Lock()
+	defer acmeManagersMu.Unlock()
+
+	if m := acmeManagers[key]; m != nil {
+		return m, nil
+	}
+
+	client := &acme.Client{
+		DirectoryURL: opts.Directory,
+	}
+	if opts.CAFile != "" {
+		pool, err := loadCA(opts.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		client.HTTPClient = &http.Client{
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: &tls.Config{RootCAs: pool},
+			},
+		}
+	}
+
+	m := &acmeManager{
+		domains: make(map[string]struct{}),
+	}
+	m.Manager = &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: m.hostPolicy,
+		Email:      opts.Email,
+		Client:     client,
+	}
+	if opts.CacheDir != "" {
+		m.Manager.Cache = autocert.DirCache(opts.CacheDir)
+	}
+	// enable the HTTP-01 challenge in addition to TLS-ALPN-01.
+	m.Manager.HTTPHandler(nil)
+
+	acmeManagers[key] = m
+	return m, nil
+}
+
+// ACMEHTTPHandler returns the handler for the ACME HTTP-01 challenge request of the host and path,
+// it returns nil if the request is not a challenge for any of the configured domains.
+func ACMEHTTPHandler(host, path string) http.Handler {
+	if !strings.HasPrefix(path, "/.well-known/acme-challenge/") {
+		return nil
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	acmeManagersMu.Lock()
+	defer acmeManagersMu.Unlock()
+
+	for _, m := range acmeManagers {
+		if m.hasDomain(host) {
+			return m.HTTPHandler(nil)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package tls
+
+import (
+	"crypto/tls"
+	"os"
+	"testing"
+
+	"golang.org/x/crypto/acme"
+)
+
+func TestACMEConfigNextProtos(t *testing.T) {
+	cfg, err := NewACMEConfig(ACMEOptions{
+		Domains:   []string{"example.com"},
+		Directory: "https://127.0.0.1:14000/dir",
+		CacheDir:  t.TempDir(),
+	}, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.NextProtos) > 0 {
+		t.Fatalf("NextProtos should be left to the listener, got %v", cfg.NextProtos)
+	}
+
+	tests := []struct {
+		protos []string
+		want   []string
+	}{
+		{nil, nil},
+		{[]string{"h2", "http/1.1"}, nil},
+		{[]string{acme.ALPNProto}, []string{acme.ALPNProto}},
+	}
+	for _, tt := range tests {
+		c, err := cfg.GetConfigForClient(&tls.ClientHelloInfo{
+			ServerName:      "example.com",
+			SupportedProtos: tt.protos,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tt.want == nil {
+			if c != nil {
+				t.Errorf("protos %v: got config with NextProtos %v, want the listener config", tt.protos, c.NextProtos)
+			}
+			continue
+		}
+		if c == nil || len(c.NextProtos) != 1 || c.NextProtos[0] != tt.want[0] {
+			t.Errorf("protos %v: want NextProtos %v", tt.protos, tt.want)
+		}
+	}
+}
+
+func TestACMEManagerCA(t *testing.T) {
+	dir := t.TempDir()
+	opts := ACMEOptions{
+		Directory: "https://127.0.0.1:14000/dir",
+		CacheDir:  dir,
+	}
+
+	m, err := getACMEManager(&opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m2, _ := getACMEManager(&opts); m2 != m {
+		t.Error("the manager of the same options is not shared")
+	}
+
+	// the managers of different CAs do not share the client.
+	opts.CAFile = writeCertPair(t, dir, "ca1").CertFile
+	m1, err := getACMEManager(&opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts.CAFile = writeCertPair(t, dir, "ca2").CertFile
+	m2, err := getACMEManager(&opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m1 == m || m2 == m || m1 == m2 {
+		t.Error("the manager is shared by different CAs")
+	}
+	if m1.Client.HTTPClient == nil || m1.Client.HTTPClient == m2.Client.HTTPClient {
+		t.Error("the client of the CA is shared")
+	}
+}
+
+// TestACMEPebble obtains a certificate from a Pebble server, such as:
+//
+//	PEBBLE_VA_ALWAYS_VALID=1 pebble -config test/config/pebble-config.json
+//	GOST_TEST_ACME_DIRECTORY=https://127.0.0.1:14000/dir GOST_TEST_ACME_CA=test/certs/pebble.minica.pem go test
+func TestACMEPebble(t *testing.T) {
+	directory := os.Getenv("GOST_TEST_ACME_DIRECTORY")
+	if directory == "" {
+		t.Skip("GOST_TEST_ACME_DIRECTORY is not set")
+	}
+
+	const domain = "gost.test"
+	cfg, err := NewACMEConfig(ACMEOptions{
+		Domains:   []string{domain},
+		Email:     "admin@gost.test",
+		Directory: directory,
+		CacheDir:  t.TempDir(),
+		CAFile:    os.Getenv("GOST_TEST_ACME_CA"),
+	}, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the listener speaks HTTP/1.1 only.
+	cfg = cfg.Clone()
+	cfg.NextProtos = []string{"http/1.1"}
+
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				conn.(*tls.Conn).Handshake()
+				conn.Close()
+			}()
+		}
+	}()
+
+	for _, serverName := range []string{domain, ""} {
+		conn, err := tls.Dial("tcp", ln.Addr().String(), &tls.Config{
+			ServerName: serverName,
+			// the root of Pebble is generated on startup.
+			InsecureSkipVerify: true,
+			NextProtos:         []string{"h2", "http/1.1"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		state := conn.ConnectionState()
+		conn.Close()
+
+		if err := state.PeerCertificates[0].VerifyHostname(domain); err != nil {
+			t.Errorf("server name %q: %v", serverName, err)
+		}
+		if state.NegotiatedProtocol != "http/1.1" {
+			t.Errorf("server name %q: negotiated protocol %q, want http/1.1", serverName, state.NegotiatedProtocol)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package tls
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	dissector "github.com/go-gost/tls-dissector"
+	utls "github.com/refraction-networking/utls"
+)
+
+// ClientHelloOptions controls the ClientHello sent by the TLS client.
+type ClientHelloOptions struct {
+	// Fingerprint is the browser-like ClientHello profile:
+	// chrome, firefox, safari, edge, ios, android or random.
+	// The standard crypto/tls ClientHello is used if empty.
+	Fingerprint string
+	// ALPN overrides the application protocols of the ClientHello.
+	ALPN []string
+	// SNI is the fake server name sent in the ClientHello,
+	// the certificate is still verified against the real server name.
+	SNI string
+	// DisableSNI omits the server name extension.
+	DisableSNI bool
+}
+
+// IsZero reports whether the default crypto/tls ClientHello is used.
+func (opts *ClientHelloOptions) IsZero() bool {
+	return opts == nil ||
+		(opts.Fingerprint == "" && len(opts.ALPN) == 0 && opts.SNI == "" && !opts.DisableSNI)
+}
+
+// ValidateFingerprint checks if the fingerprint is supported.
+func ValidateFingerprint(fingerprint string) error {
+	if fingerprint == "" {
+		return nil
+	}
+	if _, ok := parseFingerprint(fingerprint); !ok {
+		return fmt.Errorf("unknown TLS fingerprint: %s", fingerprint)
+	}
+	return nil
+}
+
+func parseFingerprint(fingerprint string) (utls.ClientHelloID, bool) {
+	switch strings.ToLower(fingerprint) {
+	case "", "golang":
+		return utls.HelloGolang, true
+	case "chrome":
+		return utls.HelloChrome_Auto, true
+	case "firefox":
+		return utls.HelloFirefox_Auto, true
+	case "safari":
+		return utls.HelloSafari_Auto, true
+	case "edge":
+		return utls.HelloEdge_Auto, true
+	case "ios":
+		return utls.HelloIOS_Auto, true
+	case "android":
+		return utls.HelloAndroid_11_OkHttp, true
+	case "random", "randomized":
+		return utls.HelloRandomized, true
+	default:
+		return utls.ClientHelloID{}, false
+	}
+}
+
+// ClientHelloOrder returns the cipher suites and the extension types in the order
+// of the ClientHello of the fingerprint, both are nil for the standard crypto/tls ClientHello.
+func ClientHelloOrder(fingerprint string) (ciphers []uint16, exts []uint16, err error) {
+	id, ok := parseFingerprint(fingerprint)
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown TLS fingerprint: %s", fingerprint)
+	}
+	if id == utls.HelloGolang {
+		return
+	}
+
+	uconn := utls.UClient(nil, &utls.Config{ServerName: "example.com", InsecureSkipVerify: true}, id)
+	if err = uconn.BuildHandshakeState(); err != nil {
+		return
+	}
+
+	hello := dissector.ClientHelloMsg{}
+	if err = hello.Decode(uconn.HandshakeState.Hello.Raw); err != nil {
+		return
+	}
+	ciphers = hello.CipherSuites
+	for _, ext := range hello.Extensions {
+		exts = append(exts, ext.Type())
+	}
+	return
+}
+
+// ClientHandshake performs the TLS client handshake on conn with cfg and the ClientHello options.
+// The returned connection has a method ConnectionState() tls.ConnectionState.
+func ClientHandshake(ctx context.Context, conn net.Conn, cfg *tls.Config, opts *ClientHelloOptions) (net.Conn, error) {
+	if cfg == nil {
+		cfg = &tls.Config{}
+	}
+	if opts.IsZero() {
+		tlsConn := tls.Client(conn, cfg)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			return nil, err
+		}
+		return tlsConn, nil
+	}
+
+	id, ok := parseFingerprint(opts.Fingerprint)
+	if !ok {
+		return nil, fmt.Errorf("unknown TLS fingerprint: %s", opts.Fingerprint)
+	}
+	// the certificate is verified against the real server name, the same as crypto/tls.
+	if !cfg.InsecureSkipVerify && cfg.ServerName == "" {
+		return nil, errors.New("tls: either ServerName or InsecureSkipVerify must be specified in the tls.Config")
+	}
+
+	serverName := cfg.ServerName
+	if opts.SNI != "" {
+		serverName = opts.SNI
+	}
+	alpn := cfg.NextProtos
+	if len(opts.ALPN) > 0 {
+		alpn = opts.ALPN
+	}
+
+	// the standard ClientHello with the overridden server name and ALPN.
+	if id == utls.HelloGolang {
+		c := cfg.Clone()
+		c.NextProtos = alpn
+		c.ServerName = serverName
+		if opts.DisableSNI {
+			c.ServerName = ""
+		}
+		c.InsecureSkipVerify = true
+		c.VerifyConnection = nil
+		c.VerifyPeerCertificate = verifyPeerCertificate(cfg)
+
+		tlsConn := tls.Client(conn, c)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			return nil, err
+		}
+		return tlsConn, nil
+	}
+
+	ucfg := &utls.Config{
+		ServerName:            serverName,
+		NextProtos:            alpn,
+		RootCAs:               cfg.RootCAs,
+		InsecureSkipVerify:    true,
+		VerifyPeerCertificate: verifyPeerCertificate(cfg),
+		MinVersion:            cfg.MinVersion,
+		MaxVersion:            cfg.MaxVersion,
+	}
+	for _, cert := range cfg.Certificates {
+		ucfg.Certificates = append(ucfg.Certificates, utls.Certificate{
+			Certificate:                 cert.Certificate,
+			PrivateKey:                  cert.PrivateKey,
+			OCSPStaple:                  cert.OCSPStaple,
+			SignedCertificateTimestamps: cert.SignedCertificateTimestamps,
+			Leaf:                        cert.Leaf,
+		})
+	}
+
+	var uconn *utls.UConn
+	if id == utls.HelloRandomized {
+		if len(alpn) > 0 {
+			id = utls.HelloRandomizedALPN
+		} else {
+			id = utls.HelloRandomizedNoALPN
+		}
+		uconn = utls.UClient(conn, ucfg, id)
+		if opts.DisableSNI {
+			if err := uconn.RemoveSNIExtension(); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		spec, err := utls.UTLSIdToSpec(id)
+		if err != nil {
+			return nil, err
+		}
+		exts := spec.Extensions[:0]
+		for _, ext := range spec.Extensions {
+			switch e := ext.(type) {
+			case *utls.SNIExtension:
+				if opts.DisableSNI {
+					continue
+				}
+			case *utls.ALPNExtension:
+				if len(opts.ALPN) > 0 {
+					e.AlpnProtocols = opts.ALPN
+				}
+			}
+			exts = append(exts, ext)
+		}
+		spec.Extensions = exts
+
+		uconn = utls.UClient(conn, ucfg, utls.HelloCustom)
+		if err := uconn.ApplyPreset(&spec); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := uconn.HandshakeContext(ctx); err != nil {
+		return nil, err
+	}
+	return &uClientConn{UConn: uconn}, nil
+}
+
+// verifyPeerCertificate verifies the server certificate against the real server name of cfg,
+// as the server name in the ClientHello may be fake or omitted.
+func verifyPeerCertificate(cfg *tls.Config) func([][]byte, [][]*x509.Certificate) error {
+	if cfg.InsecureSkipVerify && cfg.RootCAs == nil {
+		return nil
+	}
+
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return errors.New("tls: server didn't provide a certificate")
+		}
+
+		var certs []*x509.Certificate
+		for _, raw := range rawCerts {
+			cert, err := x509.ParseCertificate(raw)
+			if err != nil {
+				return err
+			}
+			certs = append(certs, cert)
+		}
+
+		opts := x509.VerifyOptions{
+			Roots:         cfg.RootCAs,
+			CurrentTime:   time.Now(),
+			Intermediates: x509.NewCertPool(),
+		}
+		// the host name is not verified if the verification is skipped but the root CA is given.
+		if !cfg.InsecureSkipVerify {
+			opts.DNSName = cfg.ServerName
+		}
+		for _, cert := range certs[1:] {
+			opts.Intermediates.AddCert(cert)
+		}
+
+		_, err := certs[0].Verify(opts)
+		return err
+	}
+}
+
+type uClientConn struct {
+	*utls.UConn
+}
+
+// ConnectionState returns the connection state in the form of crypto/tls.
+func (c *uClientConn) ConnectionState() tls.ConnectionState {
+	cs := c.UConn.ConnectionState()
+	return tls.ConnectionState{
+		Version:                     cs.Version,
+		HandshakeComplete:           cs.HandshakeComplete,
+		DidResume:                   cs.DidResume,
+		CipherSuite:                 cs.CipherSuite,
+		NegotiatedProtocol:          cs.NegotiatedProtocol,
+		NegotiatedProtocolIsMutual:  true,
+		ServerName:                  cs.ServerName,
+		PeerCertificates:            cs.PeerCertificates,
+		VerifiedChains:              cs.VerifiedChains,
+		SignedCertificateTimestamps: cs.SignedCertificateTimestamps,
+		OCSPResponse:                cs.OCSPResponse,
+	}
+}
@@ -0,0 +1,94 @@
+package tls
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"testing"
+)
+
+func TestClientHandshake(t *testing.T) {
+	dir := t.TempDir()
+	pair := writeCertPair(t, dir, "server", "server.example.com")
+	serverCfg, err := NewServerConfig([]CertPair{pair}, "", 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(pair.CertFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(data)
+
+	tests := []struct {
+		name    string
+		cfg     *tls.Config
+		opts    *ClientHelloOptions
+		wantErr bool
+	}{
+		{
+			name: "golang",
+			cfg:  &tls.Config{RootCAs: roots, ServerName: "server.example.com"},
+			opts: &ClientHelloOptions{SNI: "fake.example.com"},
+		},
+		{
+			name: "chrome",
+			cfg:  &tls.Config{RootCAs: roots, ServerName: "server.example.com"},
+			opts: &ClientHelloOptions{Fingerprint: "chrome", ALPN: []string{"http/1.1"}},
+		},
+		{
+			name: "no sni",
+			cfg:  &tls.Config{RootCAs: roots, ServerName: "server.example.com"},
+			opts: &ClientHelloOptions{Fingerprint: "firefox", DisableSNI: true},
+		},
+		{
+			name:    "wrong server name",
+			cfg:     &tls.Config{RootCAs: roots, ServerName: "other.example.com"},
+			opts:    &ClientHelloOptions{Fingerprint: "chrome", SNI: "server.example.com"},
+			wantErr: true,
+		},
+		{
+			name:    "no server name",
+			cfg:     &tls.Config{RootCAs: roots},
+			opts:    &ClientHelloOptions{Fingerprint: "chrome"},
+			wantErr: true,
+		},
+		{
+			name: "insecure",
+			cfg:  &tls.Config{InsecureSkipVerify: true},
+			opts: &ClientHelloOptions{Fingerprint: "safari"},
+		},
+		{
+			name: "root CA without host name",
+			cfg:  &tls.Config{RootCAs: roots, InsecureSkipVerify: true},
+			opts: &ClientHelloOptions{Fingerprint: "random"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c1, c2 := tcpPipe(t)
+			defer c1.Close()
+			defer c2.Close()
+
+			go func() {
+				tls.Server(c2, serverCfg).Handshake()
+				c2.Close()
+			}()
+
+			conn, err := ClientHandshake(context.Background(), c1, tt.cfg, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			cs := conn.(interface{ ConnectionState() tls.ConnectionState }).ConnectionState()
+			if !cs.HandshakeComplete {
+				t.Error("handshake is not complete")
+			}
+		})
+	}
+}
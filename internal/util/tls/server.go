@@ -0,0 +1,213 @@
+package tls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-gost/core/logger"
+)
+
+// CertPair is a pair of certificate and key files.
+type CertPair struct {
+	CertFile string
+	KeyFile  string
+}
+
+// NewServerConfig creates a server TLS config with the certificate pairs and optional client CA file.
+// The certificate is selected by the SNI of the client, and the first one is used if none matches.
+//
+// If reload is positive, the files are checked for changes at most once per reload period
+// during the TLS handshakes, and the certificates and CAs are replaced on the fly when changed.
+func NewServerConfig(pairs []CertPair, caFile string, reload time.Duration, log logger.Logger) (*tls.Config, error) {
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+
+	store := &certStore{
+		pairs:  pairs,
+		caFile: caFile,
+		period: reload,
+		logger: log,
+	}
+	if err := store.reload(); err != nil {
+		return nil, err
+	}
+
+	// the certificates are always served by GetCertificate, including the clients without SNI,
+	// as crypto/tls uses Certificates[0] for them without calling GetCertificate.
+	cfg := &tls.Config{
+		GetCertificate: store.GetCertificate,
+	}
+
+	if pool := store.getClientCAs(); pool != nil {
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+		if reload > 0 {
+			// the client certificate is verified by the current CAs.
+			cfg.ClientAuth = tls.RequireAnyClientCert
+			cfg.VerifyPeerCertificate = store.verifyClientCertificate
+		}
+	}
+
+	return cfg, nil
+}
+
+type certStore struct {
+	pairs  []CertPair
+	caFile string
+	period time.Duration
+	logger logger.Logger
+
+	certs     atomic.Value // []tls.Certificate
+	clientCAs atomic.Value // *x509.CertPool
+
+	mu        sync.Mutex
+	modTime   time.Time
+	lastCheck int64
+}
+
+func (s *certStore) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	s.check()
+
+	certs := s.getCertificates()
+	if len(certs) == 0 {
+		return nil, errors.New("tls: no certificates configured")
+	}
+	if len(certs) > 1 && hello.ServerName != "" {
+		for i := range certs {
+			if hello.SupportsCertificate(&certs[i]) == nil {
+				return &certs[i], nil
+			}
+		}
+	}
+	return &certs[0], nil
+}
+
+func (s *certStore) verifyClientCertificate(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+	if len(rawCerts) == 0 {
+		return errors.New("tls: client didn't provide a certificate")
+	}
+
+	var certs []*x509.Certificate
+	for _, raw := range rawCerts {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return err
+		}
+		certs = append(certs, cert)
+	}
+
+	opts := x509.VerifyOptions{
+		Roots:         s.getClientCAs(),
+		CurrentTime:   time.Now(),
+		Intermediates: x509.NewCertPool(),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	for _, cert := range certs[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+
+	_, err := certs[0].Verify(opts)
+	return err
+}
+
+func (s *certStore) getCertificates() []tls.Certificate {
+	v, _ := s.certs.Load().([]tls.Certificate)
+	return v
+}
+
+func (s *certStore) getClientCAs() *x509.CertPool {
+	v, _ := s.clientCAs.Load().(*x509.CertPool)
+	return v
+}
+
+// check reloads the files if they are changed since last loading.
+func (s *certStore) check() {
+	if s.period <= 0 {
+		return
+	}
+
+	now := time.Now()
+	if now.UnixNano()-atomic.LoadInt64(&s.lastCheck) < int64(s.period) {
+		return
+	}
+	if !s.mu.TryLock() {
+		return
+	}
+	defer s.mu.Unlock()
+
+	atomic.StoreInt64(&s.lastCheck, now.UnixNano())
+	if !s.lastModTime().After(s.modTime) {
+		return
+	}
+
+	if err := s.load(); err != nil {
+		if s.logger != nil {
+			s.logger.Warnf("reload certificates: %v", err)
+		}
+		return
+	}
+	if s.logger != nil {
+		s.logger.Debugf("certificates reloaded")
+	}
+}
+
+func (s *certStore) reload() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	atomic.StoreInt64(&s.lastCheck, time.Now().UnixNano())
+	return s.load()
+}
+
+func (s *certStore) load() error {
+	modTime := s.lastModTime()
+
+	var certs []tls.Certificate
+	for _, pair := range s.pairs {
+		cert, err := tls.LoadX509KeyPair(pair.CertFile, pair.KeyFile)
+		if err != nil {
+			return err
+		}
+		// parse the leaf certificate for SNI matching.
+		if cert.Leaf == nil && len(cert.Certificate) > 0 {
+			cert.Leaf, _ = x509.ParseCertificate(cert.Certificate[0])
+		}
+		certs = append(certs, cert)
+	}
+
+	pool, err := loadCA(s.caFile)
+	if err != nil {
+		return err
+	}
+
+	s.certs.Store(certs)
+	if pool != nil {
+		s.clientCAs.Store(pool)
+	}
+	s.modTime = modTime
+
+	return nil
+}
+
+// lastModTime returns the latest modification time of the files.
+func (s *certStore) lastModTime() (t time.Time) {
+	files := []string{s.caFile}
+	for _, pair := range s.pairs {
+		files = append(files, pair.CertFile, pair.KeyFile)
+	}
+	for _, file := range files {
+		if file == "" {
+			continue
+		}
+		if fi, err := os.Stat(file); err == nil && fi.ModTime().After(t) {
+			t = fi.ModTime()
+		}
+	}
+	return
+}
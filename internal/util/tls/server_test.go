@@ -0,0 +1,173 @@
+package tls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeCertPair writes a self-signed certificate for the DNS names to the directory.
+func writeCertPair(t *testing.T, dir string, cn string, names ...string) CertPair {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		DNSNames:     names,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pair := CertPair{
+		CertFile: filepath.Join(dir, cn+".crt"),
+		KeyFile:  filepath.Join(dir, cn+".key"),
+	}
+	if err := os.WriteFile(pair.CertFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(pair.KeyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return pair
+}
+
+// tcpPipe returns a pair of connected loopback TCP connections,
+// which are buffered unlike net.Pipe so that the alerts of the failed handshakes do not block.
+func tcpPipe(t *testing.T) (net.Conn, net.Conn) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	c1, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c2, err := ln.Accept()
+	if err != nil {
+		c1.Close()
+		t.Fatal(err)
+	}
+	return c1, c2
+}
+
+// peerCommonName returns the common name of the certificate served to the client with the SNI.
+func peerCommonName(t *testing.T, cfg *tls.Config, serverName string) string {
+	t.Helper()
+
+	c1, c2 := tcpPipe(t)
+	defer c1.Close()
+	defer c2.Close()
+
+	go tls.Server(c2, cfg).Handshake()
+
+	conn := tls.Client(c1, &tls.Config{
+		ServerName:         serverName,
+		InsecureSkipVerify: true,
+	})
+	if err := conn.Handshake(); err != nil {
+		t.Fatal(err)
+	}
+	return conn.ConnectionState().PeerCertificates[0].Subject.CommonName
+}
+
+func TestServerConfigSNI(t *testing.T) {
+	dir := t.TempDir()
+	pairs := []CertPair{
+		writeCertPair(t, dir, "a", "a.example.com"),
+		writeCertPair(t, dir, "b", "b.example.com", "*.b.example.com"),
+	}
+	cfg, err := NewServerConfig(pairs, "", 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Certificates) > 0 {
+		t.Fatal("certificates should be served by GetCertificate only")
+	}
+
+	tests := []struct {
+		serverName string
+		cn         string
+	}{
+		{"", "a"},
+		{"a.example.com", "a"},
+		{"b.example.com", "b"},
+		{"x.b.example.com", "b"},
+		{"unknown.example.com", "a"},
+	}
+	for _, tt := range tests {
+		if cn := peerCommonName(t, cfg, tt.serverName); cn != tt.cn {
+			t.Errorf("server name %q: got certificate %s, want %s", tt.serverName, cn, tt.cn)
+		}
+	}
+}
+
+func TestServerConfigReload(t *testing.T) {
+	dir := t.TempDir()
+	pair := writeCertPair(t, dir, "a", "example.com")
+
+	cfg, err := NewServerConfig([]CertPair{pair}, "", time.Millisecond, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, serverName := range []string{"", "example.com"} {
+		if cn := peerCommonName(t, cfg, serverName); cn != "a" {
+			t.Fatalf("server name %q: got certificate %s, want a", serverName, cn)
+		}
+	}
+
+	// replace the files with a new certificate.
+	newPair := writeCertPair(t, dir, "b", "example.com")
+	if err := os.Rename(newPair.CertFile, pair.CertFile); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(newPair.KeyFile, pair.KeyFile); err != nil {
+		t.Fatal(err)
+	}
+	future := time.Now().Add(time.Minute)
+	os.Chtimes(pair.CertFile, future, future)
+	os.Chtimes(pair.KeyFile, future, future)
+	time.Sleep(10 * time.Millisecond)
+
+	// the client without SNI must get the reloaded certificate too.
+	for _, serverName := range []string{"", "example.com"} {
+		if cn := peerCommonName(t, cfg, serverName); cn != "b" {
+			t.Errorf("server name %q: got certificate %s, want b", serverName, cn)
+		}
+	}
+
+	cert, err := GetCertificate(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert.Leaf == nil || cert.Leaf.Subject.CommonName != "b" {
+		t.Error("GetCertificate should return the reloaded certificate")
+	}
+}
@@ -15,23 +15,7 @@ func LoadServerConfig(certFile, keyFile, caFile string) (*tls.Config, error) {
 		return nil, nil
 	}
 
-	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
-	if err != nil {
-		return nil, err
-	}
-
-	cfg := &tls.Config{Certificates: []tls.Certificate{cert}}
-
-	pool, err := loadCA(caFile)
-	if err != nil {
-		return nil, err
-	}
-	if pool != nil {
-		cfg.ClientCAs = pool
-		cfg.ClientAuth = tls.RequireAndVerifyClientCert
-	}
-
-	return cfg, nil
+	return NewServerConfig([]CertPair{{CertFile: certFile, KeyFile: keyFile}}, caFile, 0, nil)
 }
 
 // LoadClientConfig loads the certificate from cert & key files and optional CA file.
@@ -86,6 +70,20 @@ func LoadClientConfig(certFile, keyFile, caFile string, verify bool, serverName
 	return cfg, nil
 }
 
+// GetCertificate returns the certificate served by the server config to the clients without SNI.
+func GetCertificate(cfg *tls.Config) (*tls.Certificate, error) {
+	if cfg == nil {
+		return nil, errors.New("tls: no config")
+	}
+	if len(cfg.Certificates) > 0 {
+		return &cfg.Certificates[0], nil
+	}
+	if cfg.GetCertificate != nil {
+		return cfg.GetCertificate(&tls.ClientHelloInfo{})
+	}
+	return nil, errors.New("tls: no certificates configured")
+}
+
 func loadCA(caFile string) (cp *x509.CertPool, err error) {
 	if caFile == "" {
 		return
@@ -0,0 +1,243 @@
+package trojan
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net"
+
+	"github.com/go-gost/core/common/bufpool"
+	"github.com/go-gost/gosocks5"
+)
+
+const (
+	CmdConnect      uint8 = 0x01
+	CmdUDPAssociate uint8 = 0x03
+)
+
+const (
+	// HashLen is the length of the hex encoded SHA224 hash of the password.
+	HashLen = 56
+	// HeaderLen is the length of the password hash and the CRLF.
+	HeaderLen = HashLen + 2
+)
+
+var (
+	crlf = []byte{'\r', '\n'}
+
+	ErrBadRequest = errors.New("trojan: bad request")
+)
+
+// Hash returns the hex encoded SHA224 hash of the password.
+func Hash(password string) string {
+	h := sha256.Sum224([]byte(password))
+	return hex.EncodeToString(h[:])
+}
+
+// Request is the Trojan request header:
+//
+//	+-----------------------+---------+----------------+---------+----------+
+//	| hex(SHA224(password)) |  CRLF   | CMD | DST.ADDR |  CRLF   | Payload  |
+//	+-----------------------+---------+----------------+---------+----------+
+//	|          56           | X'0D0A' |  1  | Variable | X'0D0A' | Variable |
+//	+-----------------------+---------+----------------+---------+----------+
+type Request struct {
+	Hash string
+	Cmd  uint8
+	Addr *gosocks5.Addr
+}
+
+// PeekHash peeks the password hash and the CRLF following it without consuming them,
+// so that the request can be replayed to the fallback server.
+// The bytes are checked one by one so that a non-Trojan request is detected as soon as possible.
+// The caller should discard HeaderLen bytes from br after the hash is authenticated.
+func PeekHash(br *bufio.Reader) (string, error) {
+	var b []byte
+	for i := 1; i <= HeaderLen; i++ {
+		var err error
+		if b, err = br.Peek(i); err != nil {
+			return "", err
+		}
+
+		c := b[i-1]
+		switch {
+		case i <= HashLen:
+			if !isHex(c) {
+				return "", ErrBadRequest
+			}
+		case c != crlf[i-HashLen-1]:
+			return "", ErrBadRequest
+		}
+	}
+	return string(b[:HashLen]), nil
+}
+
+func isHex(c byte) bool {
+	return ('0' <= c && c <= '9') || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F')
+}
+
+// ReadFrom reads the command and address following the password hash.
+func (r *Request) ReadFrom(rd io.Reader) (n int64, err error) {
+	var b [1]byte
+	if _, err = io.ReadFull(rd, b[:]); err != nil {
+		return
+	}
+	n++
+	r.Cmd = b[0]
+
+	r.Addr = &gosocks5.Addr{}
+	nn, err := r.Addr.ReadFrom(rd)
+	n += nn
+	if err != nil {
+		return
+	}
+
+	var end [2]byte
+	if _, err = io.ReadFull(rd, end[:]); err != nil {
+		return
+	}
+	n += 2
+	if !bytes.Equal(end[:], crlf) {
+		err = ErrBadRequest
+	}
+	return
+}
+
+// WriteTo writes the request header.
+func (r *Request) WriteTo(w io.Writer) (n int64, err error) {
+	buf := bufpool.Get(512)
+	defer bufpool.Put(buf)
+
+	b := *buf
+	nn := copy(b, r.Hash)
+	nn += copy(b[nn:], crlf)
+	b[nn] = r.Cmd
+	nn++
+
+	addr := r.Addr
+	if addr == nil {
+		addr = &gosocks5.Addr{Type: gosocks5.AddrIPv4}
+	}
+	an, err := addr.Encode(b[nn:])
+	if err != nil {
+		return
+	}
+	nn += an
+	nn += copy(b[nn:], crlf)
+
+	nw, err := w.Write(b[:nn])
+	return int64(nw), err
+}
+
+// ReadPacket reads a UDP packet in the form of:
+//
+//	+----------+--------+---------+----------+
+//	| DST.ADDR | Length |  CRLF   | Payload  |
+//	+----------+--------+---------+----------+
+//	| Variable |   2    | X'0D0A' | Variable |
+//	+----------+--------+---------+----------+
+func ReadPacket(r io.Reader, b []byte) (n int, addr *gosocks5.Addr, err error) {
+	addr = &gosocks5.Addr{}
+	if _, err = addr.ReadFrom(r); err != nil {
+		return
+	}
+
+	var header [4]byte
+	if _, err = io.ReadFull(r, header[:]); err != nil {
+		return
+	}
+	if !bytes.Equal(header[2:], crlf) {
+		err = ErrBadRequest
+		return
+	}
+
+	dlen := int(binary.BigEndian.Uint16(header[:2]))
+	if dlen > len(b) {
+		// the oversized packet is truncated.
+		if n, err = io.ReadFull(r, b); err != nil {
+			return
+		}
+		_, err = io.CopyN(io.Discard, r, int64(dlen-len(b)))
+		return
+	}
+	n, err = io.ReadFull(r, b[:dlen])
+	return
+}
+
+// WritePacket writes the UDP packet b to addr.
+func WritePacket(w io.Writer, b []byte, addr *gosocks5.Addr) (n int, err error) {
+	if len(b) > 0xffff {
+		return 0, errors.New("trojan: packet too large")
+	}
+
+	buf := bufpool.Get(len(b) + 512)
+	defer bufpool.Put(buf)
+
+	an, err := addr.Encode(*buf)
+	if err != nil {
+		return
+	}
+	binary.BigEndian.PutUint16((*buf)[an:], uint16(len(b)))
+	copy((*buf)[an+2:], crlf)
+	nn := an + 4
+	nn += copy((*buf)[nn:], b)
+
+	if _, err = w.Write((*buf)[:nn]); err != nil {
+		return
+	}
+	return len(b), nil
+}
+
+type packetConn struct {
+	net.Conn
+	taddr net.Addr
+}
+
+// UDPClientConn wraps the UDP associated connection conn for the client,
+// the Write method sends the packets to taddr.
+func UDPClientConn(conn net.Conn, taddr net.Addr) net.Conn {
+	return &packetConn{
+		Conn:  conn,
+		taddr: taddr,
+	}
+}
+
+// UDPServerConn wraps the UDP associated connection conn for the server.
+func UDPServerConn(conn net.Conn) net.PacketConn {
+	return &packetConn{
+		Conn: conn,
+	}
+}
+
+func (c *packetConn) ReadFrom(b []byte) (n int, addr net.Addr, err error) {
+	n, saddr, err := ReadPacket(c.Conn, b)
+	if err != nil {
+		return
+	}
+	addr, err = net.ResolveUDPAddr("udp", saddr.String())
+	return
+}
+
+func (c *packetConn) Read(b []byte) (n int, err error) {
+	n, _, err = c.ReadFrom(b)
+	return
+}
+
+func (c *packetConn) WriteTo(b []byte, addr net.Addr) (n int, err error) {
+	saddr := &gosocks5.Addr{}
+	if err = saddr.ParseFrom(addr.String()); err != nil {
+		return
+	}
+	return WritePacket(c.Conn, b, saddr)
+}
+
+func (c *packetConn) Write(b []byte) (n int, err error) {
+	if c.taddr == nil {
+		return 0, errors.New("trojan: no target address")
+	}
+	return c.WriteTo(b, c.taddr)
+}
@@ -0,0 +1,173 @@
+package trojan
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/go-gost/gosocks5"
+)
+
+func TestPeekHash(t *testing.T) {
+	hash := Hash("password")
+
+	tests := []struct {
+		name  string
+		input string
+		err   error
+	}{
+		{name: "trojan", input: hash + "\r\n\x01"},
+		{name: "http", input: "GET / HTTP/1.1\r\n", err: ErrBadRequest},
+		// the request is detected before the header is received.
+		{name: "not hex", input: "xyz", err: ErrBadRequest},
+		{name: "no crlf", input: hash + "\n\r", err: ErrBadRequest},
+		{name: "short", input: hash[:10], err: io.EOF},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			br := bufio.NewReader(strings.NewReader(tt.input))
+			got, err := PeekHash(br)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got error %v, want %v", err, tt.err)
+			}
+			if err == nil && got != hash {
+				t.Errorf("got hash %s, want %s", got, hash)
+			}
+			// the bytes are kept for the fallback.
+			if b, _ := br.Peek(br.Buffered()); string(b) != tt.input {
+				t.Errorf("got %q buffered, want %q", b, tt.input)
+			}
+		})
+	}
+}
+
+func TestRequest(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  uint8
+		addr string
+	}{
+		{name: "ipv4", cmd: CmdConnect, addr: "1.2.3.4:80"},
+		{name: "ipv6", cmd: CmdConnect, addr: "[2001:db8::1]:443"},
+		{name: "domain", cmd: CmdUDPAssociate, addr: "example.com:53"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr, err := gosocks5.NewAddr(tt.addr)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var buf bytes.Buffer
+			req := &Request{Hash: Hash("password"), Cmd: tt.cmd, Addr: addr}
+			if _, err := req.WriteTo(&buf); err != nil {
+				t.Fatal(err)
+			}
+			buf.WriteString("payload")
+
+			br := bufio.NewReader(&buf)
+			hash, err := PeekHash(br)
+			if err != nil {
+				t.Fatal(err)
+			}
+			br.Discard(HeaderLen)
+
+			got := &Request{Hash: hash}
+			if _, err := got.ReadFrom(br); err != nil {
+				t.Fatal(err)
+			}
+			if got.Hash != req.Hash || got.Cmd != tt.cmd || got.Addr.String() != tt.addr {
+				t.Errorf("got request %s %d %s, want %s %d %s", got.Hash, got.Cmd, got.Addr, req.Hash, tt.cmd, tt.addr)
+			}
+			if rest, _ := io.ReadAll(br); string(rest) != "payload" {
+				t.Errorf("got payload %q, want %q", rest, "payload")
+			}
+		})
+	}
+}
+
+func TestRequestNoCRLF(t *testing.T) {
+	var buf bytes.Buffer
+	buf.WriteByte(CmdConnect)
+	addr, _ := gosocks5.NewAddr("1.2.3.4:80")
+	addr.WriteTo(&buf)
+	buf.WriteString("\n\n")
+
+	if _, err := (&Request{}).ReadFrom(&buf); !errors.Is(err, ErrBadRequest) {
+		t.Errorf("got error %v, want %v", err, ErrBadRequest)
+	}
+}
+
+func TestPacket(t *testing.T) {
+	addr, err := gosocks5.NewAddr("8.8.8.8:53")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	for _, p := range []string{"first packet", "second"} {
+		if n, err := WritePacket(&buf, []byte(p), addr); err != nil || n != len(p) {
+			t.Fatalf("write packet: %d, %v", n, err)
+		}
+	}
+
+	// the oversized packet is truncated and the next packet is read intact.
+	b := make([]byte, 5)
+	n, got, err := ReadPacket(&buf, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b[:n]) != "first" || got.String() != addr.String() {
+		t.Errorf("got %q from %s, want %q from %s", b[:n], got, "first", addr)
+	}
+
+	b = make([]byte, 64)
+	if n, _, err = ReadPacket(&buf, b); err != nil {
+		t.Fatal(err)
+	}
+	if string(b[:n]) != "second" {
+		t.Errorf("got %q, want %q", b[:n], "second")
+	}
+
+	if _, err := WritePacket(io.Discard, make([]byte, 0x10000), addr); err == nil {
+		t.Error("the packet too large is written")
+	}
+}
+
+func TestPacketConn(t *testing.T) {
+	c1, c2 := net.Pipe()
+	defer c1.Close()
+	defer c2.Close()
+
+	taddr := &net.UDPAddr{IP: net.IPv4(8, 8, 8, 8), Port: 53}
+	client := UDPClientConn(c1, taddr)
+	server := UDPServerConn(c2)
+
+	go client.Write([]byte("query"))
+
+	b := make([]byte, 64)
+	n, addr, err := server.ReadFrom(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b[:n]) != "query" || addr.String() != taddr.String() {
+		t.Errorf("got %q to %s, want %q to %s", b[:n], addr, "query", taddr)
+	}
+
+	go server.WriteTo([]byte("answer"), addr)
+
+	if n, err = client.Read(b); err != nil {
+		t.Fatal(err)
+	}
+	if string(b[:n]) != "answer" {
+		t.Errorf("got %q, want %q", b[:n], "answer")
+	}
+
+	if _, err := UDPClientConn(c1, nil).Write([]byte("query")); err == nil {
+		t.Error("the packet without the target address is written")
+	}
+}
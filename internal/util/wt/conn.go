@@ -0,0 +1,30 @@
+package wt
+
+import (
+	"net"
+
+	"github.com/quic-go/webtransport-go"
+)
+
+type conn struct {
+	*webtransport.Stream
+	laddr net.Addr
+	raddr net.Addr
+}
+
+// Conn wraps the WebTransport stream of the session as net.Conn.
+func Conn(session *webtransport.Session, stream *webtransport.Stream) net.Conn {
+	return &conn{
+		Stream: stream,
+		laddr:  session.LocalAddr(),
+		raddr:  session.RemoteAddr(),
+	}
+}
+
+func (c *conn) LocalAddr() net.Addr {
+	return c.laddr
+}
+
+func (c *conn) RemoteAddr() net.Addr {
+	return c.raddr
+}
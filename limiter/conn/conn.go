@@ -3,6 +3,7 @@ package conn
 import (
 	"bufio"
 	"context"
+	"fmt"
 	"io"
 	"net"
 	"sort"
@@ -22,6 +23,36 @@ const (
 	IPLimitKey     = "$$"
 )
 
+// ValidateLimit checks the limit in the form of "key limit",
+// the key is $, $$, an IP address or a CIDR, and the limit is a positive number of connections.
+func ValidateLimit(s string) error {
+	ss := strings.Fields(s)
+	if len(ss) != 2 {
+		return fmt.Errorf("invalid limit %s", s)
+	}
+	if err := validateLimitKey(ss[0]); err != nil {
+		return fmt.Errorf("limit %s: %v", s, err)
+	}
+	if v, err := strconv.Atoi(ss[1]); err != nil || v <= 0 {
+		return fmt.Errorf("limit %s: invalid number %s", s, ss[1])
+	}
+	return nil
+}
+
+func validateLimitKey(key string) error {
+	switch key {
+	case GlobalLimitKey, IPLimitKey:
+		return nil
+	}
+	if net.ParseIP(key) != nil {
+		return nil
+	}
+	if _, _, err := net.ParseCIDR(key); err == nil {
+		return nil
+	}
+	return fmt.Errorf("invalid key %s", key)
+}
+
 type limiterGroup struct {
 	limiters []limiter.Limiter
 }
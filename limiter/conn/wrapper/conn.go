@@ -6,6 +6,7 @@ import (
 	"syscall"
 
 	limiter "github.com/go-gost/core/limiter/conn"
+	xctx "github.com/go-gost/x/ctx"
 )
 
 var (
@@ -28,6 +29,14 @@ func WrapConn(limiter limiter.Limiter, c net.Conn) net.Conn {
 	}
 }
 
+// CountConn implements xctx.ConnCounter by the wrapped connection.
+func (c *serverConn) CountConn(info *xctx.ConnInfo) bool {
+	if cc, ok := c.Conn.(xctx.ConnCounter); ok {
+		return cc.CountConn(info)
+	}
+	return false
+}
+
 func (c *serverConn) SyscallConn() (rc syscall.RawConn, err error) {
 	if sc, ok := c.Conn.(syscall.Conn); ok {
 		rc, err = sc.SyscallConn()
@@ -3,6 +3,7 @@ package traffic
 import (
 	"bufio"
 	"context"
+	"fmt"
 	"io"
 	"net"
 	"sort"
@@ -22,6 +23,38 @@ const (
 	ConnLimitKey   = "$$"
 )
 
+// ValidateLimit checks the limit in the form of "key in [out]",
+// the key is $, $$, an IP address or a CIDR, and the limits are sizes such as 100KB.
+func ValidateLimit(s string) error {
+	ss := strings.Fields(s)
+	if len(ss) < 2 || len(ss) > 3 {
+		return fmt.Errorf("invalid limit %s", s)
+	}
+	if err := validateLimitKey(ss[0]); err != nil {
+		return fmt.Errorf("limit %s: %v", s, err)
+	}
+	for _, v := range ss[1:] {
+		if _, err := units.ParseBase2Bytes(v); err != nil {
+			return fmt.Errorf("limit %s: %v", s, err)
+		}
+	}
+	return nil
+}
+
+func validateLimitKey(key string) error {
+	switch key {
+	case GlobalLimitKey, ConnLimitKey:
+		return nil
+	}
+	if net.ParseIP(key) != nil {
+		return nil
+	}
+	if _, _, err := net.ParseCIDR(key); err == nil {
+		return nil
+	}
+	return fmt.Errorf("invalid key %s", key)
+}
+
 type limiterGroup struct {
 	limiters []limiter.Limiter
 }
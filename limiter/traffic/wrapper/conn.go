@@ -9,6 +9,7 @@ import (
 	"syscall"
 
 	limiter "github.com/go-gost/core/limiter/traffic"
+	xctx "github.com/go-gost/x/ctx"
 	xnet "github.com/go-gost/x/internal/net"
 	"github.com/go-gost/x/internal/net/udp"
 )
@@ -23,6 +24,8 @@ type serverConn struct {
 	rbuf    bytes.Buffer
 	raddr   string
 	limiter limiter.TrafficLimiter
+	// info is the info of the client connection counted by CountConn.
+	info *xctx.ConnInfo
 }
 
 func WrapConn(rlimiter limiter.TrafficLimiter, c net.Conn) net.Conn {
@@ -38,6 +41,14 @@ func WrapConn(rlimiter limiter.TrafficLimiter, c net.Conn) net.Conn {
 }
 
 func (c *serverConn) Read(b []byte) (n int, err error) {
+	n, err = c.read(b)
+	if c.info != nil {
+		c.info.AddOutput(int64(n))
+	}
+	return
+}
+
+func (c *serverConn) read(b []byte) (n int, err error) {
 	if c.limiter == nil ||
 		c.limiter.In(c.raddr) == nil {
 		return c.Conn.Read(b)
@@ -70,6 +81,20 @@ func (c *serverConn) Read(b []byte) (n int, err error) {
 }
 
 func (c *serverConn) Write(b []byte) (n int, err error) {
+	n, err = c.write(b)
+	if c.info != nil {
+		c.info.AddInput(int64(n))
+	}
+	return
+}
+
+// CountConn implements xctx.ConnCounter.
+func (c *serverConn) CountConn(info *xctx.ConnInfo) bool {
+	c.info = info
+	return true
+}
+
+func (c *serverConn) write(b []byte) (n int, err error) {
 	if c.limiter == nil ||
 		c.limiter.Out(c.raddr) == nil {
 		return c.Conn.Write(b)
@@ -0,0 +1,35 @@
+package wrapper
+
+import (
+	"net"
+	"testing"
+
+	xctx "github.com/go-gost/x/ctx"
+)
+
+func TestCountConn(t *testing.T) {
+	c, peer := net.Pipe()
+	defer peer.Close()
+
+	info := &xctx.ConnInfo{}
+	conn := &serverConn{Conn: c}
+	defer conn.Close()
+	if !conn.CountConn(info) {
+		t.Fatal("the connection is not counted")
+	}
+
+	go peer.Write([]byte("hello"))
+	b := make([]byte, 16)
+	if _, err := conn.Read(b); err != nil {
+		t.Fatal(err)
+	}
+	go peer.Read(b)
+	if _, err := conn.Write([]byte("hi")); err != nil {
+		t.Fatal(err)
+	}
+
+	stat := info.Stat()
+	if stat.OutputBytes != 5 || stat.InputBytes != 2 {
+		t.Errorf("got input %d, output %d bytes, want 2, 5", stat.InputBytes, stat.OutputBytes)
+	}
+}
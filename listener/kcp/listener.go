@@ -2,7 +2,6 @@ package kcp
 
 import (
 	"net"
-	"time"
 
 	"github.com/go-gost/core/listener"
 	"github.com/go-gost/core/logger"
@@ -10,11 +9,11 @@ import (
 	admission "github.com/go-gost/x/admission/wrapper"
 	xnet "github.com/go-gost/x/internal/net"
 	kcp_util "github.com/go-gost/x/internal/util/kcp"
+	"github.com/go-gost/x/internal/util/mux"
 	limiter "github.com/go-gost/x/limiter/traffic/wrapper"
 	metrics "github.com/go-gost/x/metrics/wrapper"
 	"github.com/go-gost/x/registry"
 	"github.com/xtaci/kcp-go/v5"
-	"github.com/xtaci/smux"
 	"github.com/xtaci/tcpraw"
 )
 
@@ -156,25 +155,19 @@ func (l *kcpListener) listenLoop() {
 func (l *kcpListener) mux(conn net.Conn) {
 	defer conn.Close()
 
-	smuxConfig := smux.DefaultConfig()
-	smuxConfig.Version = l.md.config.SmuxVer
-	smuxConfig.MaxReceiveBuffer = l.md.config.SmuxBuf
-	smuxConfig.MaxStreamBuffer = l.md.config.StreamBuf
-	smuxConfig.KeepAliveInterval = time.Duration(l.md.config.KeepAlive) * time.Second
-
 	if !l.md.config.NoComp {
 		conn = kcp_util.CompStreamConn(conn)
 	}
 
-	mux, err := smux.Server(conn, smuxConfig)
+	session, err := mux.ServerSession(conn, l.md.muxCfg)
 	if err != nil {
 		l.logger.Error(err)
 		return
 	}
-	defer mux.Close()
+	defer session.Close()
 
 	for {
-		stream, err := mux.AcceptStream()
+		stream, err := session.Accept()
 		if err != nil {
 			l.logger.Error("accept stream: ", err)
 			return
@@ -182,8 +175,6 @@ func (l *kcpListener) mux(conn net.Conn) {
 
 		select {
 		case l.cqueue <- stream:
-		case <-stream.GetDieCh():
-			stream.Close()
 		default:
 			stream.Close()
 			l.logger.Warnf("connection queue is full, client %s discarded", stream.RemoteAddr())
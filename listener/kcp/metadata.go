@@ -2,10 +2,12 @@ package kcp
 
 import (
 	"encoding/json"
+	"time"
 
 	mdata "github.com/go-gost/core/metadata"
 	mdutil "github.com/go-gost/core/metadata/util"
 	kcp_util "github.com/go-gost/x/internal/util/kcp"
+	"github.com/go-gost/x/internal/util/mux"
 )
 
 const (
@@ -14,6 +16,7 @@ const (
 
 type metadata struct {
 	config  *kcp_util.Config
+	muxCfg  *mux.Config
 	backlog int
 }
 
@@ -22,6 +25,9 @@ func (l *kcpListener) parseMetadata(md mdata.Metadata) (err error) {
 		backlog    = "backlog"
 		config     = "config"
 		configFile = "c"
+
+		muxProtocol   = "muxProtocol"
+		muxMaxStreams = "muxMaxStreams"
 	)
 
 	if file := mdutil.GetString(md, configFile); file != "" {
@@ -47,6 +53,16 @@ func (l *kcpListener) parseMetadata(md mdata.Metadata) (err error) {
 		l.md.config = kcp_util.DefaultConfig
 	}
 
+	// the multiplexing parameters except the protocol and stream limit come from the KCP config.
+	l.md.muxCfg = &mux.Config{
+		Protocol:          mdutil.GetString(md, muxProtocol),
+		Version:           l.md.config.SmuxVer,
+		KeepAliveInterval: time.Duration(l.md.config.KeepAlive) * time.Second,
+		MaxReceiveBuffer:  l.md.config.SmuxBuf,
+		MaxStreamBuffer:   l.md.config.StreamBuf,
+		MaxStreams:        mdutil.GetInt(md, muxMaxStreams),
+	}
+
 	l.md.backlog = mdutil.GetInt(md, backlog)
 	if l.md.backlog <= 0 {
 		l.md.backlog = defaultBacklog
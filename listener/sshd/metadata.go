@@ -6,6 +6,7 @@ import (
 	mdata "github.com/go-gost/core/metadata"
 	mdutil "github.com/go-gost/core/metadata/util"
 	ssh_util "github.com/go-gost/x/internal/util/ssh"
+	tls_util "github.com/go-gost/x/internal/util/tls"
 	"golang.org/x/crypto/ssh"
 )
 
@@ -44,7 +45,11 @@ func (l *sshdListener) parseMetadata(md mdata.Metadata) (err error) {
 		}
 	}
 	if l.md.signer == nil {
-		signer, err := ssh.NewSignerFromKey(l.options.TLSConfig.Certificates[0].PrivateKey)
+		cert, err := tls_util.GetCertificate(l.options.TLSConfig)
+		if err != nil {
+			return err
+		}
+		signer, err := ssh.NewSignerFromKey(cert.PrivateKey)
 		if err != nil {
 			return err
 		}
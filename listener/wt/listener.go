@@ -0,0 +1,191 @@
+package wt
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"strings"
+
+	"github.com/go-gost/core/listener"
+	"github.com/go-gost/core/logger"
+	md "github.com/go-gost/core/metadata"
+	admission "github.com/go-gost/x/admission/wrapper"
+	xnet "github.com/go-gost/x/internal/net"
+	wt_util "github.com/go-gost/x/internal/util/wt"
+	limiter "github.com/go-gost/x/limiter/traffic/wrapper"
+	metrics "github.com/go-gost/x/metrics/wrapper"
+	"github.com/go-gost/x/registry"
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+	"github.com/quic-go/webtransport-go"
+)
+
+func init() {
+	registry.ListenerRegistry().Register("wt", NewListener)
+}
+
+type wtListener struct {
+	addr    net.Addr
+	conn    net.PacketConn
+	srv     *webtransport.Server
+	cqueue  chan net.Conn
+	errChan chan error
+	logger  logger.Logger
+	md      metadata
+	options listener.Options
+}
+
+func NewListener(opts ...listener.Option) listener.Listener {
+	options := listener.Options{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return &wtListener{
+		logger:  options.Logger,
+		options: options,
+	}
+}
+
+func (l *wtListener) Init(md md.Metadata) (err error) {
+	if err = l.parseMetadata(md); err != nil {
+		return
+	}
+
+	addr := l.options.Addr
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "0")
+	}
+
+	network := "udp"
+	if xnet.IsIPv4(l.options.Addr) {
+		network = "udp4"
+	}
+	laddr, err := net.ResolveUDPAddr(network, addr)
+	if err != nil {
+		return
+	}
+	conn, err := net.ListenUDP(network, laddr)
+	if err != nil {
+		return
+	}
+	l.conn = conn
+	l.addr = conn.LocalAddr()
+
+	mux := http.NewServeMux()
+	mux.Handle(l.md.path, http.HandlerFunc(l.upgrade))
+
+	h3 := &http3.Server{
+		Handler:   mux,
+		TLSConfig: http3.ConfigureTLSConfig(l.options.TLSConfig),
+		QUICConfig: &quic.Config{
+			KeepAlivePeriod:      l.md.keepAlivePeriod,
+			HandshakeIdleTimeout: l.md.handshakeTimeout,
+			MaxIdleTimeout:       l.md.maxIdleTimeout,
+			MaxIncomingStreams:   int64(l.md.maxStreams),
+		},
+	}
+	webtransport.ConfigureHTTP3Server(h3)
+
+	l.srv = &webtransport.Server{
+		H3:          h3,
+		CheckOrigin: l.checkOrigin,
+	}
+
+	l.cqueue = make(chan net.Conn, l.md.backlog)
+	l.errChan = make(chan error, 1)
+
+	go func() {
+		err := l.srv.Serve(conn)
+		if err != nil {
+			l.errChan <- err
+		}
+		close(l.errChan)
+	}()
+
+	return
+}
+
+func (l *wtListener) Accept() (conn net.Conn, err error) {
+	var ok bool
+	select {
+	case conn = <-l.cqueue:
+		conn = metrics.WrapConn(l.options.Service, conn)
+		conn = admission.WrapConn(l.options.Admission, conn)
+		conn = limiter.WrapConn(l.options.TrafficLimiter, conn)
+	case err, ok = <-l.errChan:
+		if !ok {
+			err = listener.ErrClosed
+		}
+	}
+	return
+}
+
+func (l *wtListener) Close() error {
+	err := l.srv.Close()
+	l.conn.Close()
+	return err
+}
+
+func (l *wtListener) Addr() net.Addr {
+	return l.addr
+}
+
+func (l *wtListener) upgrade(w http.ResponseWriter, r *http.Request) {
+	log := l.logger.WithFields(map[string]any{
+		"local":  l.addr.String(),
+		"remote": r.RemoteAddr,
+	})
+	if l.logger.IsLevelEnabled(logger.TraceLevel) {
+		dump, _ := httputil.DumpRequest(r, false)
+		log.Trace(string(dump))
+	}
+
+	for k, v := range l.md.header {
+		w.Header()[k] = v
+	}
+
+	session, err := l.srv.Upgrade(w, r)
+	if err != nil {
+		log.Error(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	go l.mux(session, log)
+}
+
+// checkOrigin allows the origins matching the origins option, or any origin if the option is not set.
+// The requests without the origin are not sent by the browsers and always allowed.
+func (l *wtListener) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if len(l.md.origins) == 0 || origin == "" {
+		return true
+	}
+	for _, g := range l.md.origins {
+		if g.Match(strings.ToLower(origin)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *wtListener) mux(session *webtransport.Session, log logger.Logger) {
+	defer session.CloseWithError(0, "closed")
+
+	for {
+		stream, err := session.AcceptStream(context.Background())
+		if err != nil {
+			log.Debug("accept stream: ", err)
+			return
+		}
+
+		conn := wt_util.Conn(session, stream)
+		select {
+		case l.cqueue <- conn:
+		default:
+			conn.Close()
+			log.Warnf("connection queue is full, client %s discarded", session.RemoteAddr())
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package wt
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gobwas/glob"
+	mdata "github.com/go-gost/core/metadata"
+	mdutil "github.com/go-gost/core/metadata/util"
+)
+
+const (
+	defaultPath    = "/wt"
+	defaultBacklog = 128
+)
+
+type metadata struct {
+	path    string
+	backlog int
+	header  http.Header
+	// origins are the patterns of the origins allowed, any origin is allowed if empty.
+	origins []glob.Glob
+
+	keepAlivePeriod  time.Duration
+	handshakeTimeout time.Duration
+	maxIdleTimeout   time.Duration
+	maxStreams       int
+}
+
+func (l *wtListener) parseMetadata(md mdata.Metadata) (err error) {
+	const (
+		path    = "path"
+		backlog = "backlog"
+		header  = "header"
+		origins = "origins"
+
+		keepAlive        = "keepAlive"
+		keepAlivePeriod  = "ttl"
+		handshakeTimeout = "handshakeTimeout"
+		maxIdleTimeout   = "maxIdleTimeout"
+		maxStreams       = "maxStreams"
+	)
+
+	l.md.path = mdutil.GetString(md, path)
+	if l.md.path == "" {
+		l.md.path = defaultPath
+	}
+
+	l.md.backlog = mdutil.GetInt(md, backlog)
+	if l.md.backlog <= 0 {
+		l.md.backlog = defaultBacklog
+	}
+
+	if mm := mdutil.GetStringMapString(md, header); len(mm) > 0 {
+		hd := http.Header{}
+		for k, v := range mm {
+			hd.Add(k, v)
+		}
+		l.md.header = hd
+	}
+
+	for _, s := range mdutil.GetStrings(md, origins) {
+		g, err := glob.Compile(strings.ToLower(s))
+		if err != nil {
+			return err
+		}
+		l.md.origins = append(l.md.origins, g)
+	}
+
+	if mdutil.GetBool(md, keepAlive) {
+		l.md.keepAlivePeriod = mdutil.GetDuration(md, keepAlivePeriod)
+		if l.md.keepAlivePeriod <= 0 {
+			l.md.keepAlivePeriod = 10 * time.Second
+		}
+	}
+	l.md.handshakeTimeout = mdutil.GetDuration(md, handshakeTimeout)
+	l.md.maxIdleTimeout = mdutil.GetDuration(md, maxIdleTimeout)
+	l.md.maxStreams = mdutil.GetInt(md, maxStreams)
+
+	return
+}
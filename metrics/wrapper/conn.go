@@ -7,6 +7,7 @@ import (
 	"syscall"
 
 	"github.com/go-gost/core/metrics"
+	xctx "github.com/go-gost/x/ctx"
 	xnet "github.com/go-gost/x/internal/net"
 	"github.com/go-gost/x/internal/net/udp"
 	xmetrics "github.com/go-gost/x/metrics"
@@ -16,10 +17,47 @@ var (
 	errUnsupport = errors.New("unsupported operation")
 )
 
+// transferCounter counts the bytes transferred in the metrics of the service,
+// or in the info of the client connection for the connection dialed for it.
+type transferCounter struct {
+	service string
+	info    *xctx.ConnInfo
+}
+
+func (c *transferCounter) addInput(n int) {
+	if c.info != nil {
+		c.info.AddInput(int64(n))
+		return
+	}
+	if counter := xmetrics.GetCounter(
+		xmetrics.MetricServiceTransferInputBytesCounter,
+		metrics.Labels{
+			"service": c.service,
+		}); counter != nil {
+		counter.Add(float64(n))
+	}
+}
+
+func (c *transferCounter) addOutput(n int) {
+	if c.info != nil {
+		c.info.AddOutput(int64(n))
+		return
+	}
+	if counter := xmetrics.GetCounter(
+		xmetrics.MetricServiceTransferOutputBytesCounter,
+		metrics.Labels{
+			"service": c.service,
+		}); counter != nil {
+		counter.Add(float64(n))
+	}
+}
+
 // serverConn is a server side Conn with metrics supported.
 type serverConn struct {
 	net.Conn
-	service string
+	transferCounter
+	// client is the info of the client connection counted by CountConn.
+	client *xctx.ConnInfo
 }
 
 func WrapConn(service string, c net.Conn) net.Conn {
@@ -27,35 +65,58 @@ func WrapConn(service string, c net.Conn) net.Conn {
 		return c
 	}
 	return &serverConn{
-		service: service,
-		Conn:    c,
+		Conn:            c,
+		transferCounter: transferCounter{service: service},
+	}
+}
+
+// WrapClientConn counts the bytes of the connection c dialed for the client connection of the info,
+// the bytes read are the input and the bytes written are the output of the client connection.
+// The packet connection keeps the interfaces of the UDP connection.
+// It is not wrapped if the bytes are counted on the client connection.
+func WrapClientConn(info *xctx.ConnInfo, c net.Conn) net.Conn {
+	if info == nil || info.Counted || c == nil {
+		return c
+	}
+	if pc, ok := c.(net.PacketConn); ok {
+		return &udpConn{
+			PacketConn:      pc,
+			transferCounter: transferCounter{info: info},
+		}
+	}
+	return &serverConn{
+		Conn:            c,
+		transferCounter: transferCounter{info: info},
 	}
 }
 
 func (c *serverConn) Read(b []byte) (n int, err error) {
 	n, err = c.Conn.Read(b)
-	if counter := xmetrics.GetCounter(
-		xmetrics.MetricServiceTransferInputBytesCounter,
-		metrics.Labels{
-			"service": c.service,
-		}); counter != nil {
-		counter.Add(float64(n))
+	c.addInput(n)
+	if c.client != nil {
+		c.client.AddOutput(int64(n))
 	}
 	return
 }
 
 func (c *serverConn) Write(b []byte) (n int, err error) {
 	n, err = c.Conn.Write(b)
-	if counter := xmetrics.GetCounter(
-		xmetrics.MetricServiceTransferOutputBytesCounter,
-		metrics.Labels{
-			"service": c.service,
-		}); counter != nil {
-		counter.Add(float64(n))
+	c.addOutput(n)
+	if c.client != nil {
+		c.client.AddInput(int64(n))
 	}
 	return
 }
 
+// CountConn implements xctx.ConnCounter for the connection accepted by the listener.
+func (c *serverConn) CountConn(info *xctx.ConnInfo) bool {
+	if c.info != nil {
+		return false
+	}
+	c.client = info
+	return true
+}
+
 func (c *serverConn) SyscallConn() (rc syscall.RawConn, err error) {
 	if sc, ok := c.Conn.(syscall.Conn); ok {
 		rc, err = sc.SyscallConn()
@@ -67,7 +128,7 @@ func (c *serverConn) SyscallConn() (rc syscall.RawConn, err error) {
 
 type packetConn struct {
 	net.PacketConn
-	service string
+	transferCounter
 }
 
 func WrapPacketConn(service string, pc net.PacketConn) net.PacketConn {
@@ -75,44 +136,32 @@ func WrapPacketConn(service string, pc net.PacketConn) net.PacketConn {
 		return pc
 	}
 	return &packetConn{
-		PacketConn: pc,
-		service:    service,
+		PacketConn:      pc,
+		transferCounter: transferCounter{service: service},
 	}
 }
 
 func (c *packetConn) ReadFrom(p []byte) (n int, addr net.Addr, err error) {
 	n, addr, err = c.PacketConn.ReadFrom(p)
-	if counter := xmetrics.GetCounter(
-		xmetrics.MetricServiceTransferInputBytesCounter,
-		metrics.Labels{
-			"service": c.service,
-		}); counter != nil {
-		counter.Add(float64(n))
-	}
+	c.addInput(n)
 	return
 }
 
 func (c *packetConn) WriteTo(p []byte, addr net.Addr) (n int, err error) {
 	n, err = c.PacketConn.WriteTo(p, addr)
-	if counter := xmetrics.GetCounter(
-		xmetrics.MetricServiceTransferOutputBytesCounter,
-		metrics.Labels{
-			"service": c.service,
-		}); counter != nil {
-		counter.Add(float64(n))
-	}
+	c.addOutput(n)
 	return
 }
 
 type udpConn struct {
 	net.PacketConn
-	service string
+	transferCounter
 }
 
 func WrapUDPConn(service string, pc net.PacketConn) udp.Conn {
 	return &udpConn{
-		PacketConn: pc,
-		service:    service,
+		PacketConn:      pc,
+		transferCounter: transferCounter{service: service},
 	}
 }
 
@@ -140,13 +189,7 @@ func (c *udpConn) SetWriteBuffer(n int) error {
 func (c *udpConn) Read(b []byte) (n int, err error) {
 	if nc, ok := c.PacketConn.(io.Reader); ok {
 		n, err = nc.Read(b)
-		if counter := xmetrics.GetCounter(
-			xmetrics.MetricServiceTransferInputBytesCounter,
-			metrics.Labels{
-				"service": c.service,
-			}); counter != nil {
-			counter.Add(float64(n))
-		}
+		c.addInput(n)
 		return
 	}
 	err = errUnsupport
@@ -155,26 +198,14 @@ func (c *udpConn) Read(b []byte) (n int, err error) {
 
 func (c *udpConn) ReadFrom(p []byte) (n int, addr net.Addr, err error) {
 	n, addr, err = c.PacketConn.ReadFrom(p)
-	if counter := xmetrics.GetCounter(
-		xmetrics.MetricServiceTransferInputBytesCounter,
-		metrics.Labels{
-			"service": c.service,
-		}); counter != nil {
-		counter.Add(float64(n))
-	}
+	c.addInput(n)
 	return
 }
 
 func (c *udpConn) ReadFromUDP(b []byte) (n int, addr *net.UDPAddr, err error) {
 	if nc, ok := c.PacketConn.(udp.ReadUDP); ok {
 		n, addr, err = nc.ReadFromUDP(b)
-		if counter := xmetrics.GetCounter(
-			xmetrics.MetricServiceTransferInputBytesCounter,
-			metrics.Labels{
-				"service": c.service,
-			}); counter != nil {
-			counter.Add(float64(n))
-		}
+		c.addInput(n)
 		return
 	}
 	err = errUnsupport
@@ -184,13 +215,7 @@ func (c *udpConn) ReadFromUDP(b []byte) (n int, addr *net.UDPAddr, err error) {
 func (c *udpConn) ReadMsgUDP(b, oob []byte) (n, oobn, flags int, addr *net.UDPAddr, err error) {
 	if nc, ok := c.PacketConn.(udp.ReadUDP); ok {
 		n, oobn, flags, addr, err = nc.ReadMsgUDP(b, oob)
-		if counter := xmetrics.GetCounter(
-			xmetrics.MetricServiceTransferInputBytesCounter,
-			metrics.Labels{
-				"service": c.service,
-			}); counter != nil {
-			counter.Add(float64(n))
-		}
+		c.addInput(n)
 		return
 	}
 	err = errUnsupport
@@ -200,13 +225,7 @@ func (c *udpConn) ReadMsgUDP(b, oob []byte) (n, oobn, flags int, addr *net.UDPAd
 func (c *udpConn) Write(b []byte) (n int, err error) {
 	if nc, ok := c.PacketConn.(io.Writer); ok {
 		n, err = nc.Write(b)
-		if counter := xmetrics.GetCounter(
-			xmetrics.MetricServiceTransferOutputBytesCounter,
-			metrics.Labels{
-				"service": c.service,
-			}); counter != nil {
-			counter.Add(float64(n))
-		}
+		c.addOutput(n)
 		return
 	}
 	err = errUnsupport
@@ -215,26 +234,14 @@ func (c *udpConn) Write(b []byte) (n int, err error) {
 
 func (c *udpConn) WriteTo(p []byte, addr net.Addr) (n int, err error) {
 	n, err = c.PacketConn.WriteTo(p, addr)
-	if counter := xmetrics.GetCounter(
-		xmetrics.MetricServiceTransferOutputBytesCounter,
-		metrics.Labels{
-			"service": c.service,
-		}); counter != nil {
-		counter.Add(float64(n))
-	}
+	c.addOutput(n)
 	return
 }
 
 func (c *udpConn) WriteToUDP(b []byte, addr *net.UDPAddr) (n int, err error) {
 	if nc, ok := c.PacketConn.(udp.WriteUDP); ok {
 		n, err = nc.WriteToUDP(b, addr)
-		if counter := xmetrics.GetCounter(
-			xmetrics.MetricServiceTransferOutputBytesCounter,
-			metrics.Labels{
-				"service": c.service,
-			}); counter != nil {
-			counter.Add(float64(n))
-		}
+		c.addOutput(n)
 		return
 	}
 	err = errUnsupport
@@ -244,13 +251,7 @@ func (c *udpConn) WriteToUDP(b []byte, addr *net.UDPAddr) (n int, err error) {
 func (c *udpConn) WriteMsgUDP(b, oob []byte, addr *net.UDPAddr) (n, oobn int, err error) {
 	if nc, ok := c.PacketConn.(udp.WriteUDP); ok {
 		n, oobn, err = nc.WriteMsgUDP(b, oob, addr)
-		if counter := xmetrics.GetCounter(
-			xmetrics.MetricServiceTransferOutputBytesCounter,
-			metrics.Labels{
-				"service": c.service,
-			}); counter != nil {
-			counter.Add(float64(n))
-		}
+		c.addOutput(n)
 		return
 	}
 	err = errUnsupport
@@ -0,0 +1,137 @@
+package wrapper
+
+import (
+	"net"
+	"testing"
+
+	xctx "github.com/go-gost/x/ctx"
+)
+
+// udpPeer is the unconnected UDP conn writing to the address raddr.
+type udpPeer struct {
+	*net.UDPConn
+	raddr net.Addr
+}
+
+func (c *udpPeer) Write(b []byte) (int, error) {
+	return c.WriteTo(b, c.raddr)
+}
+
+func TestWrapClientConn(t *testing.T) {
+	tests := []struct {
+		name   string
+		dial   func(t *testing.T) (net.Conn, net.Conn)
+		packet bool
+	}{
+		{
+			name: "tcp",
+			dial: func(t *testing.T) (net.Conn, net.Conn) {
+				ln, err := net.Listen("tcp", "127.0.0.1:0")
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer ln.Close()
+				c, err := net.Dial("tcp", ln.Addr().String())
+				if err != nil {
+					t.Fatal(err)
+				}
+				peer, err := ln.Accept()
+				if err != nil {
+					t.Fatal(err)
+				}
+				return c, peer
+			},
+		},
+		{
+			name: "udp",
+			dial: func(t *testing.T) (net.Conn, net.Conn) {
+				pc, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+				if err != nil {
+					t.Fatal(err)
+				}
+				c, err := net.DialUDP("udp", nil, pc.LocalAddr().(*net.UDPAddr))
+				if err != nil {
+					t.Fatal(err)
+				}
+				return c, &udpPeer{UDPConn: pc, raddr: c.LocalAddr()}
+			},
+			packet: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, peer := tt.dial(t)
+			defer peer.Close()
+
+			info := &xctx.ConnInfo{}
+			conn := WrapClientConn(info, c)
+			defer conn.Close()
+
+			if _, ok := conn.(net.PacketConn); ok != tt.packet {
+				t.Errorf("got packet conn %v, want %v", ok, tt.packet)
+			}
+
+			if _, err := conn.Write([]byte("hello")); err != nil {
+				t.Fatal(err)
+			}
+			b := make([]byte, 16)
+			if _, err := peer.Read(b); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := peer.Write([]byte("hi")); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := conn.Read(b); err != nil {
+				t.Fatal(err)
+			}
+
+			stat := info.Stat()
+			if stat.OutputBytes != 5 || stat.InputBytes != 2 {
+				t.Errorf("got input %d, output %d bytes, want 2, 5", stat.InputBytes, stat.OutputBytes)
+			}
+		})
+	}
+
+	if c := WrapClientConn(nil, &net.TCPConn{}); c == nil {
+		t.Error("the conn without the info is not returned")
+	} else if _, ok := c.(*net.TCPConn); !ok {
+		t.Error("the conn without the info is wrapped")
+	}
+}
+
+func TestServerConnCountConn(t *testing.T) {
+	c, peer := net.Pipe()
+	defer peer.Close()
+
+	info := &xctx.ConnInfo{}
+	conn := &serverConn{Conn: c, transferCounter: transferCounter{service: "test"}}
+	defer conn.Close()
+	if !conn.CountConn(info) {
+		t.Fatal("the connection accepted is not counted")
+	}
+
+	go peer.Write([]byte("hello"))
+	b := make([]byte, 16)
+	if _, err := conn.Read(b); err != nil {
+		t.Fatal(err)
+	}
+	go peer.Read(b)
+	if _, err := conn.Write([]byte("hi")); err != nil {
+		t.Fatal(err)
+	}
+
+	// the bytes from the client are sent to the destination.
+	stat := info.Stat()
+	if stat.OutputBytes != 5 || stat.InputBytes != 2 {
+		t.Errorf("got input %d, output %d bytes, want 2, 5", stat.InputBytes, stat.OutputBytes)
+	}
+
+	// the connection dialed is not counted again.
+	info.Counted = true
+	if _, ok := WrapClientConn(info, c).(*serverConn); ok {
+		t.Error("the connection dialed for the counted client connection is wrapped")
+	}
+	if (&serverConn{Conn: c, transferCounter: transferCounter{info: info}}).CountConn(info) {
+		t.Error("the connection dialed counts the client connection")
+	}
+}
@@ -0,0 +1,90 @@
+package recorder
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/go-gost/core/recorder"
+)
+
+var (
+	// records are the record names supported by the group.
+	records = []string{
+		recorder.RecorderServiceClientAddress,
+		recorder.RecorderServiceRouterDialAddress,
+		recorder.RecorderServiceRouterDialAddressError,
+		RecorderServiceHandlerDstAddress,
+		RecorderServiceHandlerDNSQuery,
+		RecorderServiceHandlerHTTPRequest,
+		RecorderServiceHandlerTLSSNI,
+		RecorderServiceHandlerError,
+	}
+)
+
+// Group is a group of recorder objects which can be changed at runtime.
+type Group struct {
+	objects []recorder.RecorderObject
+	mu      sync.RWMutex
+}
+
+func NewGroup(objects ...recorder.RecorderObject) *Group {
+	g := &Group{}
+	g.Set(objects...)
+	return g
+}
+
+// Set replaces the recorder objects of the group.
+func (g *Group) Set(objects ...recorder.RecorderObject) {
+	var v []recorder.RecorderObject
+	for _, obj := range objects {
+		if obj.Recorder != nil && obj.Record != "" {
+			v = append(v, obj)
+		}
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.objects = v
+}
+
+// Get returns the recorder objects of the group.
+func (g *Group) Get() []recorder.RecorderObject {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	return append([]recorder.RecorderObject(nil), g.objects...)
+}
+
+// Objects returns a recorder object for each of the supported records,
+// the data of the record is recorded by all the recorders of the group for the record at the time.
+// The objects can be used in place of the recorder objects of the services and routers.
+func (g *Group) Objects() []recorder.RecorderObject {
+	objects := make([]recorder.RecorderObject, 0, len(records))
+	for _, record := range records {
+		objects = append(objects, recorder.RecorderObject{
+			Recorder: &groupRecorder{group: g, record: record},
+			Record:   record,
+		})
+	}
+	return objects
+}
+
+type groupRecorder struct {
+	group  *Group
+	record string
+}
+
+func (r *groupRecorder) Record(ctx context.Context, b []byte) error {
+	var errs []error
+	for _, obj := range r.group.Get() {
+		if obj.Record != r.record {
+			continue
+		}
+		if err := obj.Recorder.Record(ctx, b); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
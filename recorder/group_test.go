@@ -0,0 +1,103 @@
+package recorder
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/go-gost/core/recorder"
+)
+
+// memRecorder records the data in memory.
+type memRecorder struct {
+	mu   sync.Mutex
+	data []string
+	err  error
+}
+
+func (r *memRecorder) Record(ctx context.Context, b []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.data = append(r.data, string(b))
+	return r.err
+}
+
+func (r *memRecorder) get() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]string(nil), r.data...)
+}
+
+func TestRecord(t *testing.T) {
+	dst, query := &memRecorder{}, &memRecorder{}
+	ctx := ContextWithRecorders(context.Background(), []recorder.RecorderObject{
+		{Recorder: dst, Record: RecorderServiceHandlerDstAddress},
+		{Recorder: query, Record: RecorderServiceHandlerDNSQuery},
+		{Record: RecorderServiceHandlerDstAddress},
+	}, nil)
+
+	Record(ctx, RecorderServiceHandlerDstAddress, []byte("example.com:443"))
+	Record(ctx, RecorderServiceHandlerDNSQuery, []byte("example.com. IN A"))
+	Record(ctx, RecorderServiceHandlerTLSSNI, []byte("example.com"))
+
+	if got := dst.get(); !reflect.DeepEqual(got, []string{"example.com:443"}) {
+		t.Errorf("got %q recorded for the destination", got)
+	}
+	if got := query.get(); !reflect.DeepEqual(got, []string{"example.com. IN A"}) {
+		t.Errorf("got %q recorded for the query", got)
+	}
+
+	// nothing is recorded without the recorders.
+	Record(context.Background(), RecorderServiceHandlerDstAddress, []byte("example.org:443"))
+	if ctx := ContextWithRecorders(context.Background(), nil, nil); ctx != context.Background() {
+		t.Error("the context is changed without the recorders")
+	}
+}
+
+func TestGroup(t *testing.T) {
+	a, b := &memRecorder{}, &memRecorder{err: errors.New("failed")}
+	g := NewGroup(
+		recorder.RecorderObject{Recorder: a, Record: RecorderServiceHandlerDstAddress},
+		// the objects without the recorder or the record are skipped.
+		recorder.RecorderObject{Record: RecorderServiceHandlerDstAddress},
+		recorder.RecorderObject{Recorder: a},
+	)
+	if n := len(g.Get()); n != 1 {
+		t.Fatalf("got %d objects, want 1", n)
+	}
+
+	// the objects of the group are attached to the context once, as the service does.
+	ctx := ContextWithRecorders(context.Background(), g.Objects(), nil)
+
+	Record(ctx, RecorderServiceHandlerDstAddress, []byte("1"))
+	Record(ctx, RecorderServiceHandlerTLSSNI, []byte("sni"))
+
+	// the recorders changed take effect for the context attached before.
+	g.Set(
+		recorder.RecorderObject{Recorder: b, Record: RecorderServiceHandlerDstAddress},
+		recorder.RecorderObject{Recorder: a, Record: RecorderServiceHandlerTLSSNI},
+	)
+	Record(ctx, RecorderServiceHandlerDstAddress, []byte("2"))
+	Record(ctx, RecorderServiceHandlerTLSSNI, []byte("sni"))
+
+	if got := a.get(); !reflect.DeepEqual(got, []string{"1", "sni"}) {
+		t.Errorf("got %q recorded by a", got)
+	}
+	if got := b.get(); !reflect.DeepEqual(got, []string{"2"}) {
+		t.Errorf("got %q recorded by b", got)
+	}
+
+	// the errors of the recorders are returned by the object of the group.
+	for _, obj := range g.Objects() {
+		if obj.Record != RecorderServiceHandlerDstAddress {
+			continue
+		}
+		if err := obj.Recorder.Record(ctx, []byte("3")); err == nil {
+			t.Error("the error of the recorder is not returned")
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package recorder
+
+import (
+	"context"
+
+	"github.com/go-gost/core/logger"
+	"github.com/go-gost/core/recorder"
+)
+
+// The records published by the handlers in addition to the ones of the core.
+const (
+	// RecorderServiceHandlerDstAddress records the destination address requested by the client.
+	RecorderServiceHandlerDstAddress = "recorder.service.handler.dst.address"
+	// RecorderServiceHandlerDNSQuery records the question of the DNS query, such as example.com. IN A.
+	RecorderServiceHandlerDNSQuery = "recorder.service.handler.dns.query"
+	// RecorderServiceHandlerHTTPRequest records the request line of the HTTP request.
+	RecorderServiceHandlerHTTPRequest = "recorder.service.handler.http.request"
+	// RecorderServiceHandlerTLSSNI records the server name of the TLS client hello.
+	RecorderServiceHandlerTLSSNI = "recorder.service.handler.tls.sni"
+	// RecorderServiceHandlerError records the error returned by the handler.
+	RecorderServiceHandlerError = "recorder.service.handler.error"
+)
+
+type recordersKey struct{}
+
+var (
+	keyRecorders = &recordersKey{}
+)
+
+type recorders struct {
+	objects []recorder.RecorderObject
+	logger  logger.Logger
+}
+
+// ContextWithRecorders attaches the recorder objects of the service to ctx for the handlers,
+// the errors of recording are logged by log.
+func ContextWithRecorders(ctx context.Context, objects []recorder.RecorderObject, log logger.Logger) context.Context {
+	if len(objects) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, keyRecorders, &recorders{
+		objects: objects,
+		logger:  log,
+	})
+}
+
+// Record records the data by the recorders of the record attached to ctx.
+func Record(ctx context.Context, record string, b []byte) {
+	rs, _ := ctx.Value(keyRecorders).(*recorders)
+	if rs == nil {
+		return
+	}
+
+	for _, obj := range rs.objects {
+		if obj.Record != record || obj.Recorder == nil {
+			continue
+		}
+		if err := obj.Recorder.Record(ctx, b); err != nil && rs.logger != nil {
+			rs.logger.Errorf("record %s: %v", record, err)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package registry
 
 import (
 	"github.com/go-gost/core/auth"
+	xauth "github.com/go-gost/x/auth"
 )
 
 type autherRegistry struct {
@@ -38,3 +39,11 @@ func (w *autherWrapper) Authenticate(user, password string) bool {
 	}
 	return v.Authenticate(user, password)
 }
+
+// Users implements auth.Lister.
+func (w *autherWrapper) Users() map[string]string {
+	if lister, ok := w.r.get(w.name).(xauth.Lister); ok {
+		return lister.Users()
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package registry
 
 import (
 	"github.com/go-gost/core/bypass"
+	xbypass "github.com/go-gost/x/bypass"
 )
 
 type bypassRegistry struct {
@@ -38,3 +39,22 @@ func (w *bypassWrapper) Contains(addr string) bool {
 	}
 	return bp.Contains(addr)
 }
+
+func (w *bypassWrapper) Patterns() ([]string, bool) {
+	bp := w.r.get(w.name)
+	if bp == nil {
+		return nil, false
+	}
+	if lister, ok := bp.(xbypass.Lister); ok {
+		return lister.Patterns()
+	}
+	return nil, false
+}
+
+func (w *bypassWrapper) ContainsRequest(req *xbypass.Request) bool {
+	bp := w.r.get(w.name)
+	if bp == nil {
+		return false
+	}
+	return xbypass.Contains(bp, req)
+}
@@ -102,7 +102,7 @@ func (r *resolver) Resolve(ctx context.Context, network, host string) (ips []net
 	}
 
 	for _, server := range r.servers {
-		ips, err = r.resolve(ctx, &server, host)
+		ips, err = r.resolve(ctx, &server, network, host)
 		if err != nil {
 			r.options.logger.Error(err)
 			continue
@@ -118,22 +118,45 @@ func (r *resolver) Resolve(ctx context.Context, network, host string) (ips []net
 	return
 }
 
-func (r *resolver) resolve(ctx context.Context, server *NameServer, host string) (ips []net.IP, err error) {
+// resolve returns the addresses of the network, for the network 'ip'
+// the addresses of both families are returned with the preferred family first.
+func (r *resolver) resolve(ctx context.Context, server *NameServer, network, host string) (ips []net.IP, err error) {
 	if server == nil {
 		return
 	}
 
-	if server.Prefer == "ipv6" { // prefer ipv6
-		if ips, err = r.resolve6(ctx, server, host); len(ips) > 0 {
-			return
-		}
+	switch network {
+	case "ip4":
 		return r.resolve4(ctx, server, host)
+	case "ip6":
+		return r.resolve6(ctx, server, host)
 	}
 
-	if ips, err = r.resolve4(ctx, server, host); len(ips) > 0 {
-		return
+	resolvePrefer, resolveOther := r.resolve4, r.resolve6
+	if server.Prefer == "ipv6" { // prefer ipv6
+		resolvePrefer, resolveOther = r.resolve6, r.resolve4
 	}
-	return r.resolve6(ctx, server, host)
+
+	// the queries are sent in parallel, see RFC 8305 section 3.
+	var others []net.IP
+	var otherErr error
+	ch := make(chan struct{})
+	go func() {
+		defer close(ch)
+		others, otherErr = resolveOther(ctx, server, host)
+	}()
+
+	ips, err = resolvePrefer(ctx, server, host)
+	<-ch
+
+	ips = append(ips, others...)
+	if len(ips) > 0 {
+		return ips, nil
+	}
+	if err == nil {
+		err = otherErr
+	}
+	return
 }
 
 func (r *resolver) resolve4(ctx context.Context, server *NameServer, host string) (ips []net.IP, err error) {
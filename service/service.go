@@ -2,7 +2,10 @@ package service
 
 import (
 	"context"
+	"errors"
 	"net"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-gost/core/admission"
@@ -11,16 +14,73 @@ import (
 	"github.com/go-gost/core/logger"
 	"github.com/go-gost/core/metrics"
 	"github.com/go-gost/core/recorder"
+	"github.com/go-gost/core/resolver"
 	"github.com/go-gost/core/service"
 	"github.com/go-gost/core/sniff/stun"
+	xctx "github.com/go-gost/x/ctx"
 	sx "github.com/go-gost/x/internal/util/selector"
 	xmetrics "github.com/go-gost/x/metrics"
+	xrecorder "github.com/go-gost/x/recorder"
 )
 
+var (
+	ErrNoRecorderGroup = errors.New("service: no recorder group")
+)
+
+const (
+	// drainPollInterval is the interval of checking the active connections in draining.
+	drainPollInterval = 500 * time.Millisecond
+)
+
+// DrainService is a service which can be shut down gracefully.
+type DrainService interface {
+	// Shutdown stops accepting connections and waits for the active connections to finish
+	// until the grace period of the service expires or ctx is done, then closes the remaining ones.
+	// It waits only for ctx if the service has no grace period.
+	Shutdown(ctx context.Context) error
+	// Conns returns the number of the active connections.
+	Conns() int
+}
+
+// ConnService is a service whose active connections can be inspected and closed.
+type ConnService interface {
+	Connections() []xctx.ConnStat
+	// CloseConnection closes the connection with the id, it returns false if the connection is not found.
+	CloseConnection(id uint64) bool
+}
+
+var (
+	// connID is the last id of the connections of all the services.
+	connID atomic.Uint64
+	// allConns is the active connections of all the services by the id,
+	// including the services replaced or deleted but still draining.
+	allConns sync.Map
+)
+
+// CloseConnection closes the active connection with the id of any service,
+// it returns false if the connection is not found.
+func CloseConnection(id uint64) bool {
+	v, ok := allConns.Load(id)
+	if !ok {
+		return false
+	}
+	v.(net.Conn).Close()
+	return true
+}
+
+// RecorderService is a service whose recorders can be changed at runtime.
+type RecorderService interface {
+	Recorders() []recorder.RecorderObject
+	SetRecorders(recorders ...recorder.RecorderObject) error
+}
+
 type options struct {
-	admission admission.Admission
-	recorders []recorder.RecorderObject
-	logger    logger.Logger
+	admission     admission.Admission
+	recorders     []recorder.RecorderObject
+	recorderGroup *xrecorder.Group
+	gracePeriod   time.Duration
+	resolver      resolver.Resolver
+	logger        logger.Logger
 }
 
 type Option func(opts *options)
@@ -37,6 +97,30 @@ func RecordersOption(recorders ...recorder.RecorderObject) Option {
 	}
 }
 
+// RecorderGroupOption sets the recorder group of the service,
+// which is used to change the recorders of the service at runtime.
+func RecorderGroupOption(group *xrecorder.Group) Option {
+	return func(opts *options) {
+		opts.recorderGroup = group
+	}
+}
+
+// GracePeriodOption sets the period for the active connections to finish when the service is closed,
+// the connections are left to finish by themselves on Close if it is not positive.
+func GracePeriodOption(period time.Duration) Option {
+	return func(opts *options) {
+		opts.gracePeriod = period
+	}
+}
+
+// ResolverOption sets the resolver of the service,
+// the routes resolve the destination address to all its addresses by it.
+func ResolverOption(r resolver.Resolver) Option {
+	return func(opts *options) {
+		opts.resolver = r
+	}
+}
+
 func LoggerOption(logger logger.Logger) Option {
 	return func(opts *options) {
 		opts.logger = logger
@@ -49,6 +133,13 @@ type defaultService struct {
 	handler  handler.Handler
 	options  options
 	stun     stun.Spoof
+
+	stopOnce sync.Once
+	stopErr  error
+	conns    map[net.Conn]*xctx.ConnInfo
+	// connections are not accepted any more after they are closed forcibly.
+	forced bool
+	mu     sync.Mutex
 }
 
 func NewService(name string, ln listener.Listener, h handler.Handler, st stun.Spoof, opts ...Option) service.Service {
@@ -62,6 +153,7 @@ func NewService(name string, ln listener.Listener, h handler.Handler, st stun.Sp
 		handler:  h,
 		options:  options,
 		stun:     st,
+		conns:    make(map[net.Conn]*xctx.ConnInfo),
 	}
 }
 
@@ -69,9 +161,150 @@ func (s *defaultService) Addr() net.Addr {
 	return s.listener.Addr()
 }
 
+func (s *defaultService) Recorders() []recorder.RecorderObject {
+	if s.options.recorderGroup == nil {
+		return nil
+	}
+	return s.options.recorderGroup.Get()
+}
+
+func (s *defaultService) SetRecorders(recorders ...recorder.RecorderObject) error {
+	if s.options.recorderGroup == nil {
+		return ErrNoRecorderGroup
+	}
+	s.options.recorderGroup.Set(recorders...)
+	return nil
+}
+
+// Close stops accepting connections and returns,
+// the active connections are drained in background for the grace period then closed.
+// Without the grace period, the active connections are not closed.
 func (s *defaultService) Close() error {
-	s.stun.Close()
-	return s.listener.Close()
+	err := s.stop()
+
+	if s.options.gracePeriod <= 0 {
+		return err
+	}
+
+	if n := s.Conns(); n > 0 {
+		s.options.logger.Infof("draining %d connections in %v", n, s.options.gracePeriod)
+	}
+	go s.Shutdown(context.Background())
+
+	return err
+}
+
+func (s *defaultService) Shutdown(ctx context.Context) error {
+	err := s.stop()
+
+	if s.options.gracePeriod > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.options.gracePeriod)
+		defer cancel()
+	}
+
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+
+	for s.Conns() > 0 {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			if n := s.closeConns(); n > 0 {
+				s.options.logger.Infof("%d connections closed", n)
+			}
+			return err
+		}
+	}
+	return err
+}
+
+func (s *defaultService) Conns() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.conns)
+}
+
+func (s *defaultService) Connections() []xctx.ConnStat {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := make([]xctx.ConnStat, 0, len(s.conns))
+	for _, info := range s.conns {
+		stats = append(stats, info.Stat())
+	}
+	return stats
+}
+
+func (s *defaultService) CloseConnection(id uint64) bool {
+	var c net.Conn
+
+	s.mu.Lock()
+	for conn, info := range s.conns {
+		if info.ID == id {
+			c = conn
+			break
+		}
+	}
+	s.mu.Unlock()
+
+	// the connection is closed out of the lock as Close may block.
+	if c == nil {
+		return false
+	}
+	c.Close()
+	return true
+}
+
+// stop closes the listener once.
+func (s *defaultService) stop() error {
+	s.stopOnce.Do(func() {
+		if s.stun.SpoofEnable {
+			s.stun.Close()
+		}
+		s.stopErr = s.listener.Close()
+	})
+	return s.stopErr
+}
+
+func (s *defaultService) closeConns() int {
+	s.mu.Lock()
+	s.forced = true
+	conns := make([]net.Conn, 0, len(s.conns))
+	for conn := range s.conns {
+		conns = append(conns, conn)
+	}
+	s.mu.Unlock()
+
+	for _, conn := range conns {
+		conn.Close()
+	}
+	return len(conns)
+}
+
+// track adds the connection to the active connections,
+// it returns false if the connections of the service have been closed.
+func (s *defaultService) track(conn net.Conn, info *xctx.ConnInfo) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.forced {
+		return false
+	}
+	s.conns[conn] = info
+	allConns.Store(info.ID, conn)
+	return true
+}
+
+func (s *defaultService) untrack(conn net.Conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if info := s.conns[conn]; info != nil {
+		allConns.Delete(info.ID)
+	}
+	delete(s.conns, conn)
 }
 
 func (s *defaultService) Serve() error {
@@ -120,17 +353,13 @@ func (s *defaultService) Serve() error {
 		}
 		tempDelay = 0
 
-		for _, rec := range s.options.recorders {
-			host := conn.RemoteAddr().String()
-			if h, _, _ := net.SplitHostPort(host); h != "" {
-				host = h
-			}
-			if rec.Record == recorder.RecorderServiceClientAddress {
-				if err := rec.Recorder.Record(context.Background(), []byte(host)); err != nil {
-					s.options.logger.Errorf("record %s: %v", rec.Record, err)
-				}
-			}
+		// the recorders of the service are published to the handler by the context.
+		rctx := xrecorder.ContextWithRecorders(context.Background(), s.options.recorders, s.options.logger)
+		clientHost := conn.RemoteAddr().String()
+		if h, _, _ := net.SplitHostPort(clientHost); h != "" {
+			clientHost = h
 		}
+		xrecorder.Record(rctx, recorder.RecorderServiceClientAddress, []byte(clientHost))
 		if s.options.admission != nil &&
 			!s.options.admission.Admit(conn.RemoteAddr().String()) {
 			conn.Close()
@@ -138,7 +367,25 @@ func (s *defaultService) Serve() error {
 			continue
 		}
 
+		info := &xctx.ConnInfo{
+			ID:      connID.Add(1),
+			Service: s.name,
+			Client:  conn.RemoteAddr().String(),
+			Start:   time.Now(),
+		}
+		// the bytes are counted by the wrappers of the listener if possible,
+		// otherwise by the connections dialed for the client.
+		if cc, ok := conn.(xctx.ConnCounter); ok {
+			info.Counted = cc.CountConn(info)
+		}
+		if !s.track(conn, info) {
+			conn.Close()
+			continue
+		}
+
 		go func() {
+			defer s.untrack(conn)
+
 			if v := xmetrics.GetCounter(xmetrics.MetricServiceRequestsCounter,
 				metrics.Labels{"service": s.name}); v != nil {
 				v.Inc()
@@ -159,10 +406,15 @@ func (s *defaultService) Serve() error {
 			}
 
 			host, _, _ := net.SplitHostPort(conn.RemoteAddr().String())
-			ctx := sx.ContextWithHash(context.Background(), &sx.Hash{Source: host})
+			ctx := sx.ContextWithHash(rctx, &sx.Hash{Source: host})
+			ctx = xctx.ContextWithConnInfo(ctx, info)
+			if s.options.resolver != nil {
+				ctx = xctx.ContextWithResolver(ctx, s.options.resolver)
+			}
 
 			if err := s.handler.Handle(ctx, conn); err != nil {
 				s.options.logger.Error(err)
+				xrecorder.Record(ctx, xrecorder.RecorderServiceHandlerError, []byte(err.Error()))
 				if v := xmetrics.GetCounter(xmetrics.MetricServiceHandlerErrorsCounter,
 					metrics.Labels{"service": s.name}); v != nil {
 					v.Inc()
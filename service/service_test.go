@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/go-gost/core/handler"
+	"github.com/go-gost/core/metadata"
+	"github.com/go-gost/core/sniff/stun"
+	xlogger "github.com/go-gost/x/logger"
+)
+
+type testListener struct {
+	net.Listener
+}
+
+func (l *testListener) Init(metadata.Metadata) error {
+	return nil
+}
+
+// echoHandler echoes the data of the connection until it is closed.
+type echoHandler struct{}
+
+func (h *echoHandler) Init(metadata.Metadata) error {
+	return nil
+}
+
+func (h *echoHandler) Handle(ctx context.Context, conn net.Conn, opts ...handler.HandleOption) error {
+	defer conn.Close()
+	io.Copy(conn, conn)
+	return nil
+}
+
+// newTestService creates and serves the echo service with the grace period,
+// and returns the client connected to it.
+func newTestService(t *testing.T, gracePeriod time.Duration) (*defaultService, net.Conn) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewService("test", &testListener{ln}, &echoHandler{}, stun.Spoof{},
+		GracePeriodOption(gracePeriod),
+		LoggerOption(xlogger.Nop()),
+	).(*defaultService)
+	go s.Serve()
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+		s.closeConns()
+		s.stop()
+	})
+
+	echo(t, conn)
+	if n := s.Conns(); n != 1 {
+		t.Fatalf("got %d connections, want 1", n)
+	}
+	return s, conn
+}
+
+// echo checks that the connection is served.
+func echo(t *testing.T, conn net.Conn) {
+	t.Helper()
+
+	conn.SetDeadline(time.Now().Add(time.Second))
+	defer conn.SetDeadline(time.Time{})
+
+	if _, err := conn.Write([]byte("ping")); err != nil {
+		t.Fatal(err)
+	}
+	b := make([]byte, 4)
+	if _, err := io.ReadFull(conn, b); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// closed checks that the connection is closed by the service.
+func closed(t *testing.T, conn net.Conn) {
+	t.Helper()
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := conn.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("got error %v, want EOF", err)
+	}
+}
+
+func TestCloseWithoutGracePeriod(t *testing.T) {
+	s, conn := newTestService(t, 0)
+
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := net.Dial("tcp", s.Addr().String()); err == nil {
+		t.Error("the connection is accepted after close")
+	}
+
+	// the active connection is not closed without the grace period.
+	time.Sleep(2 * drainPollInterval)
+	echo(t, conn)
+	if n := s.Conns(); n != 1 {
+		t.Errorf("got %d connections, want 1", n)
+	}
+}
+
+func TestShutdownDrain(t *testing.T) {
+	s, conn := newTestService(t, time.Minute)
+
+	time.AfterFunc(100*time.Millisecond, func() { conn.Close() })
+
+	start := time.Now()
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("shutdown takes %v after the connection is finished", d)
+	}
+	if n := s.Conns(); n != 0 {
+		t.Errorf("got %d connections, want 0", n)
+	}
+}
+
+func TestShutdownForceClose(t *testing.T) {
+	s, conn := newTestService(t, 100*time.Millisecond)
+
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// the connection not finished in the grace period is closed.
+	closed(t, conn)
+}
+
+func TestShutdownContext(t *testing.T) {
+	// the service without grace period waits for the context.
+	s, conn := newTestService(t, 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := s.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	closed(t, conn)
+}
+
+func TestCloseDrain(t *testing.T) {
+	s, conn := newTestService(t, 100*time.Millisecond)
+
+	// the connection is drained in background.
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	echo(t, conn)
+	closed(t, conn)
+}
+
+func TestCloseConnection(t *testing.T) {
+	s, conn := newTestService(t, 0)
+
+	stats := s.Connections()
+	if len(stats) != 1 {
+		t.Fatalf("got %d connections, want 1", len(stats))
+	}
+	if !s.CloseConnection(stats[0].ID) {
+		t.Fatal("the connection is not found")
+	}
+	closed(t, conn)
+
+	if s.CloseConnection(stats[0].ID + 1) {
+		t.Error("the unknown connection is closed")
+	}
+}
+
+func TestCloseConnectionDraining(t *testing.T) {
+	s, conn := newTestService(t, time.Minute)
+
+	stats := s.Connections()
+	if len(stats) != 1 {
+		t.Fatalf("got %d connections, want 1", len(stats))
+	}
+	// the service is replaced or deleted and draining the connection.
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	echo(t, conn)
+
+	if !CloseConnection(stats[0].ID) {
+		t.Fatal("the connection of the draining service is not found")
+	}
+	closed(t, conn)
+
+	time.Sleep(2 * drainPollInterval)
+	if CloseConnection(stats[0].ID) {
+		t.Error("the connection closed is found")
+	}
+}